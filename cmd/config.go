@@ -4,7 +4,10 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"io"
+
 	"github.com/denglertai/gonfig/internal/config"
+	"github.com/denglertai/gonfig/internal/file"
 	"github.com/denglertai/gonfig/internal/general"
 	"github.com/denglertai/gonfig/pkg/logging"
 	"github.com/spf13/cobra"
@@ -32,17 +35,36 @@ func getConfigSettings(args []string) *config.Settings {
 		configSettings.FileType = general.Undefined
 	}
 
+	configSettings.Encoding = encoding
+
 	// Unset the global variables after reading the values to prevent them from being reused in subsequent tests
 	fileType = ""
 	fileName = ""
+	encoding = ""
 
 	logging.Debug("Config settings", "settings", configSettings)
 
 	return configSettings
 }
 
+// newFileProcessor creates a processor for the given file using the config settings
+func newFileProcessor(configSettings *config.Settings, fileName string, output io.Writer) (*file.FileProcessor, error) {
+	processor := file.NewFileProcessor(fileName, configSettings.FileType, output)
+
+	if configSettings.Encoding != "" {
+		encoding, err := file.ParsePropertiesEncoding(configSettings.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		processor.Encoding = encoding
+	}
+
+	return processor, nil
+}
+
 var fileType string
 var fileName string
+var encoding string
 
 func init() {
 	rootCmd.AddCommand(configCmd)
@@ -57,8 +79,10 @@ func init() {
 	// is called directly, e.g.:
 	// configCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 
-	configCmd.PersistentFlags().StringVarP(&fileName, "file", "f", "", "Path to the configuration file")
+	configCmd.PersistentFlags().StringVarP(&fileName, "file", "f", "", "Path to the configuration file. Use - to read from stdin, which requires -t / --file-type")
 	configCmd.MarkFlagRequired("file")
 
-	configCmd.PersistentFlags().StringVarP(&fileType, "file-type", "t", "", "Type of file to be read. If not set, the file type will be inferred from the file extension")
+	configCmd.PersistentFlags().StringVarP(&fileType, "file-type", "t", "", "Type of file to be read. If not set, the file type will be inferred from the file extension or detected from the content if the file has no extension")
+
+	configCmd.PersistentFlags().StringVar(&encoding, "encoding", "", "Encoding of properties files being read or written: utf-8 (default) or iso-8859-1 for legacy Java resource bundles")
 }
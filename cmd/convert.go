@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/denglertai/gonfig/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+var convertTo string
+var convertOutput string
+var convertOverwrite bool
+var convertRender bool
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Converts a file to another file type",
+	Long: `Converts a file to another file type, e.g. YAML to JSON. Supported file types are json, yaml, toml and properties.
+Nested maps are flattened into keys joined by dots for properties files and vice versa.
+By default the values are converted as is, use --render to process them on the way.`,
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configSettings := getConfigSettings(args)
+
+		logging.Info("RunE", "command", cmd.Name(), "args", args, "configSettings", configSettings)
+
+		// Unset the global variables after processing to prevent them from being reused in subsequent tests
+		defer func() {
+			convertTo = ""
+			convertOutput = "-"
+			convertOverwrite = false
+			convertRender = false
+		}()
+
+		configSettings.OutputType = general.FileType(convertTo)
+		if configSettings.OutputType == general.Undefined && convertOutput != "-" {
+			// Infer the type from the output file's extension
+			configSettings.OutputType = general.FileType(strings.TrimPrefix(filepath.Ext(convertOutput), "."))
+		}
+		if configSettings.OutputType == general.Undefined {
+			return fmt.Errorf("the type to convert to has to be provided using --to")
+		}
+
+		var o = new(bytes.Buffer)
+		processor, err := newFileProcessor(configSettings, configSettings.File, o)
+		if err != nil {
+			return err
+		}
+		processor.OutputType = configSettings.OutputType
+
+		if convertRender {
+			err = processor.Process()
+		} else {
+			err = processor.Convert()
+		}
+		if err != nil {
+			return err
+		}
+
+		return writeOutput(o.Bytes(), convertOutput, convertOverwrite, "")
+	},
+}
+
+func init() {
+	configCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVar(&convertTo, "to", "", "Type of file to convert to. If not set, the type will be inferred from the output file's extension")
+
+	convertCmd.Flags().StringVarP(&convertOutput, "output", "o", "-", "Controls where to put the results (defaults to stdout)")
+
+	convertCmd.Flags().BoolVarP(&convertOverwrite, "overwrite", "w", false, "Controls if the output should overwrite an existing file (defaults to false)")
+
+	convertCmd.Flags().BoolVar(&convertRender, "render", false, "Controls if the values should be processed while converting (defaults to false)")
+}
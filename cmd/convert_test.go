@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc   string
+		file   string
+		to     general.FileType
+		output string
+		render bool
+	}{
+		{
+			desc: "YAML to JSON",
+			file: path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			to:   general.JSON,
+		},
+		{
+			desc:   "YAML to JSON rendered",
+			file:   path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			to:     general.JSON,
+			render: true,
+		},
+		{
+			desc:   "JSON to TOML inferred from output",
+			file:   path.Join(wd, "./testdata/json/quiz_param.json"),
+			output: "quiz.toml",
+		},
+		{
+			desc:   "Properties to YAML rendered",
+			file:   path.Join(wd, "./testdata/properties/props_param.properties"),
+			to:     general.YAML,
+			render: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("BLA_BLUB", "yoyoyo")
+			t.Setenv("INT", "123")
+			t.Setenv("FLOAT", "123.123")
+			t.Setenv("BOOL", "true")
+			t.Setenv("STRING", "string")
+			t.Setenv("SPECIAL_CHARACTERS", "%^&*()_+")
+
+			output := path.Join(t.TempDir(), "output")
+			if tC.output != "" {
+				output = path.Join(t.TempDir(), tC.output)
+			}
+
+			args := []string{"config", "convert", "-f", tC.file, "-o", output, "-l", "trace", "-s"}
+			if tC.to != general.Undefined {
+				args = append(args, "--to", string(tC.to))
+			}
+			if tC.render {
+				args = append(args, "--render")
+			}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
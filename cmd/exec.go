@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/denglertai/gonfig/internal/config"
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/denglertai/gonfig/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+var renderFiles []string
+var renderFileType string
+var renderEncoding string
+
+// execTarget replaces the current process with the given command. It is a variable to allow replacing it within tests
+var execTarget = execProcess
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec [--render file]... -- command [args...]",
+	Short: "Renders files inline and executes the given command",
+	Long: `Renders the given files inline and replaces the gonfig process with the given command afterwards.
+This is intended to be used as a container entrypoint, the executed command takes over the process (e.g. PID 1) and receives signals directly.
+If rendering any of the files fails, the command is not executed.`,
+	Args:             cobra.MinimumNArgs(1),
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logging.Info("RunE", "command", cmd.Name(), "args", args, "render", renderFiles)
+
+		// Unset the global variables after processing to prevent them from being reused in subsequent tests
+		defer func() {
+			renderFiles = []string{}
+			renderFileType = ""
+			renderEncoding = ""
+		}()
+
+		for _, f := range renderFiles {
+			logging.Info("Rendering file", "file", f)
+			configSettings := config.NewSettings()
+			configSettings.File = f
+			configSettings.FileType = general.FileType(renderFileType)
+			configSettings.Encoding = renderEncoding
+
+			if err := processFile(configSettings, f, true, ""); err != nil {
+				return fmt.Errorf("failed to render %s: %w", f, err)
+			}
+		}
+
+		binary, err := exec.LookPath(args[0])
+		if err != nil {
+			return err
+		}
+
+		logging.Info("Executing command", "binary", binary, "args", args[1:])
+
+		return execTarget(binary, args, os.Environ())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().StringArrayVar(&renderFiles, "render", []string{}, "File to be rendered inline before executing the command. May be passed multiple times")
+
+	execCmd.Flags().StringVarP(&renderFileType, "file-type", "t", "", "Type of the files to be rendered. If not set, the type of each file will be inferred from its extension or detected from the content if the file has no extension")
+
+	execCmd.Flags().StringVar(&renderEncoding, "encoding", "", "Encoding of properties files being rendered: utf-8 (default) or iso-8859-1 for legacy Java resource bundles")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExec(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc    string
+		files   []string
+		flags   []string
+		noExt   bool
+		missing bool
+		wantErr bool
+	}{
+		{
+			desc: "Render multiple files",
+			files: []string{
+				path.Join(wd, "./testdata/xml/customers_param.xml"),
+				path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			},
+		},
+		{
+			desc:  "File type and encoding",
+			files: []string{path.Join(wd, "./testdata/properties/messages_de.properties")},
+			flags: []string{"-t", "properties", "--encoding", "iso-8859-1"},
+			noExt: true,
+		},
+		{
+			desc:    "Render failure prevents exec",
+			files:   []string{path.Join(wd, "./testdata/yaml/deployment_param.yaml")},
+			missing: true,
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("BLA_BLUB", "yoyoyo")
+			t.Setenv("INT", "123")
+			t.Setenv("FLOAT", "123.123")
+			t.Setenv("BOOL", "true")
+			t.Setenv("STRING", "string")
+			t.Setenv("SPECIAL_CHARACTERS", "%^&*()_+")
+			t.Setenv("NAME", "Jürgen")
+
+			var executed []string
+			execTarget = func(binary string, args []string, env []string) error {
+				executed = args
+				return nil
+			}
+			defer func() { execTarget = execProcess }()
+
+			args := append([]string{"exec", "-l", "trace"}, tC.flags...)
+			rendered := make([]string, 0)
+			for _, f := range tC.files {
+				ext := path.Ext(f)
+				if tC.noExt {
+					ext = ""
+				}
+				file, err := os.CreateTemp(os.TempDir(), fmt.Sprintf("gonfig-test-*%s", ext))
+				assert.NoError(t, err)
+				defer os.Remove(file.Name())
+
+				source, err := os.Open(f)
+				assert.NoError(t, err)
+				defer source.Close()
+
+				_, err = io.Copy(file, source)
+				assert.NoError(t, err)
+				file.Close()
+
+				args = append(args, "--render", file.Name())
+				rendered = append(rendered, file.Name())
+			}
+
+			if tC.missing {
+				args = append(args, "--render", path.Join(t.TempDir(), "missing.yaml"))
+			}
+
+			args = append(args, "--", "go", "version")
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			if tC.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, executed)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"go", "version"}, executed)
+
+			for _, f := range rendered {
+				res, err := os.ReadFile(f)
+				assert.NoError(t, err)
+
+				snaps.MatchSnapshot(t, string(res))
+			}
+		})
+	}
+}
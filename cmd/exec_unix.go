@@ -0,0 +1,10 @@
+//go:build unix
+
+package cmd
+
+import "syscall"
+
+// execProcess replaces the current process with the given binary
+func execProcess(binary string, args []string, env []string) error {
+	return syscall.Exec(binary, args, env)
+}
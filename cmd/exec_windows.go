@@ -0,0 +1,31 @@
+//go:build windows
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// execProcess runs the given binary as a child process and exits with its exit code
+// since Windows does not support replacing the current process
+func execProcess(binary string, args []string, env []string) error {
+	c := exec.Command(binary, args[1:]...)
+	c.Env = env
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	err := c.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+
+	os.Exit(0)
+	return nil
+}
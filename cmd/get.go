@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+
+	"github.com/denglertai/gonfig/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+// getCmd represents the get command
+var getCmd = &cobra.Command{
+	Use:   "get <path>",
+	Short: "Prints the value of a single entry",
+	Long: `Prints the raw value of the entry identified by the given path.
+The path is the same as reported for the entries while processing a file, e.g. spec.template.spec.containers.0.image for YAML or /customers/customer/name for XML files.`,
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configSettings := getConfigSettings(args)
+
+		logging.Info("RunE", "command", cmd.Name(), "args", args, "configSettings", configSettings)
+
+		processor, err := newFileProcessor(configSettings, configSettings.File, new(bytes.Buffer))
+		if err != nil {
+			return err
+		}
+
+		result, err := processor.Get(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.OutOrStdout().Write([]byte(result))
+
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(getCmd)
+}
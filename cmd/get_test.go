@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		file     string
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			desc:     "XML Element",
+			file:     path.Join(wd, "./testdata/xml/customers_param.xml"),
+			path:     "/customers/customer/name",
+			expected: "${BLA_BLUB|upper}",
+		},
+		{
+			desc:     "XML Attribute",
+			file:     path.Join(wd, "./testdata/xml/customers_param.xml"),
+			path:     "/customers/customer/@id",
+			expected: "${INT|to_int|multiply(m=2)}",
+		},
+		{
+			desc:     "JSON",
+			file:     path.Join(wd, "./testdata/json/quiz_param.json"),
+			path:     "quiz.sport.q1.options.1",
+			expected: "${SPECIAL_CHARACTERS}",
+		},
+		{
+			desc:     "YAML",
+			file:     path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			path:     "spec.template.spec.containers.0.image",
+			expected: "nginx:1.14.2",
+		},
+		{
+			desc:     "PROPERTIES",
+			file:     path.Join(wd, "./testdata/properties/props_param.properties"),
+			path:     "server.port",
+			expected: "8080",
+		},
+		{
+			desc:    "Unknown Path",
+			file:    path.Join(wd, "./testdata/properties/props_param.properties"),
+			path:    "does.not.exist",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			rootCmd.SetOut(out)
+			defer rootCmd.SetOut(nil)
+
+			rootCmd.SetArgs([]string{"config", "get", "-f", tC.file, tC.path})
+			err := rootCmd.Execute()
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/denglertai/gonfig/internal/file"
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/denglertai/gonfig/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+var mergeOutput string
+var mergeOverwrite bool
+var mergeListStrategy string
+var mergeKey string
+var mergeDeleteMarker string
+var mergeOutputType string
+var mergeSchema string
+var mergeCoerceTypes bool
+var mergeEscape string
+var mergeKeys bool
+
+// mergeCmd represents the merge command
+var mergeCmd = &cobra.Command{
+	Use:   "merge <base> <overlay>...",
+	Short: "Merges overlays into a base file",
+	Long: `Deep-merges one or more overlays into a base file in the given order and processes the values of the result afterwards.
+Maps are merged recursively, lists are merged according to --list-strategy and all other values of an overlay replace the ones of the base.
+Setting a value to the delete marker ($delete by default) removes the entry from the result. If lists are merged by key, an item containing the delete marker as key removes the matching item.
+Supported file types are json, yaml, toml and properties. The type of each file is inferred from its extension, overlays without extension have the type of the base. --encoding applies to all properties files; -f / --file may be used instead of the first argument.`,
+	Args:             cobra.MinimumNArgs(1),
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configSettings := getConfigSettings(args)
+
+		logging.Info("RunE", "command", cmd.Name(), "args", args, "configSettings", configSettings)
+
+		// Unset the global variables after processing to prevent them from being reused in subsequent tests
+		defer func() {
+			mergeOutput = "-"
+			mergeOverwrite = false
+			mergeListStrategy = string(file.ListReplace)
+			mergeKey = "name"
+			mergeDeleteMarker = file.DefaultDeleteMarker
+			mergeOutputType = ""
+			mergeSchema = ""
+			mergeCoerceTypes = false
+			mergeEscape = ""
+			mergeKeys = false
+		}()
+
+		files := args
+		if configSettings.File != "" {
+			files = append([]string{configSettings.File}, args...)
+		}
+		if len(files) < 2 {
+			return fmt.Errorf("at least a base and one overlay have to be provided")
+		}
+
+		options := file.NewMergeOptions()
+		strategy, err := file.ParseListStrategy(mergeListStrategy)
+		if err != nil {
+			return err
+		}
+		options.ListStrategy = strategy
+		options.MergeKey = mergeKey
+		options.DeleteMarker = mergeDeleteMarker
+
+		var o = new(bytes.Buffer)
+		processor, err := newFileProcessor(configSettings, files[0], o)
+		if err != nil {
+			return err
+		}
+		processor.OutputType = general.FileType(mergeOutputType)
+		processor.Escape = mergeEscape
+		processor.Keys = mergeKeys
+		if mergeSchema != "" {
+			schema, err := file.LoadSchema(mergeSchema)
+			if err != nil {
+				return err
+			}
+			processor.Schema = schema
+		}
+		processor.CoerceTypes = mergeCoerceTypes
+
+		if err := processor.Merge(files[1:], options); err != nil {
+			return err
+		}
+
+		return writeOutput(o.Bytes(), mergeOutput, mergeOverwrite, "")
+	},
+}
+
+func init() {
+	configCmd.AddCommand(mergeCmd)
+
+	mergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "-", "Controls where to put the results (defaults to stdout)")
+
+	mergeCmd.Flags().BoolVarP(&mergeOverwrite, "overwrite", "w", false, "Controls if the output should overwrite an existing file (defaults to false)")
+
+	mergeCmd.Flags().StringVar(&mergeListStrategy, "list-strategy", string(file.ListReplace), "Controls how lists are merged: replace, append or merge (merges maps having the same value for --merge-key)")
+
+	mergeCmd.Flags().StringVar(&mergeKey, "merge-key", "name", "Key identifying maps within lists if --list-strategy is merge")
+
+	mergeCmd.Flags().StringVar(&mergeDeleteMarker, "delete-marker", file.DefaultDeleteMarker, "Value marking an entry of the base to be deleted")
+
+	mergeCmd.Flags().StringVar(&mergeOutputType, "output-type", "", "Type of file to be written. If not set, the output has the same type as the base")
+
+	mergeCmd.Flags().StringVar(&mergeEscape, "escape", "", "Escapes the results of placeholders: auto escapes them for the context of the entry, any other value is the name of an escape filter applied to all results")
+
+	mergeCmd.Flags().BoolVar(&mergeKeys, "keys", false, "Processes placeholders within the keys of the merged document as well. Keys of YAML files are always processed")
+
+	mergeCmd.Flags().StringVar(&mergeSchema, "schema", "", "Path to a JSON Schema (draft 2020-12 unless declared otherwise) the merged document is validated against before it is written")
+
+	mergeCmd.Flags().BoolVar(&mergeCoerceTypes, "coerce-types", false, "Converts values produced by placeholders to the types required by --schema, e.g. \"3\" to 3 for an integer. Otherwise type violations are reported")
+}
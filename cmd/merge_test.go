@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	base := path.Join(wd, "./testdata/merge/base.yaml")
+
+	testCases := []struct {
+		desc     string
+		base     string
+		overlays []string
+		flags    []string
+	}{
+		{
+			desc:     "Replace lists",
+			overlays: []string{path.Join(wd, "./testdata/merge/overlay-prod.yaml")},
+		},
+		{
+			desc:     "Append lists",
+			overlays: []string{path.Join(wd, "./testdata/merge/overlay-prod.yaml")},
+			flags:    []string{"--list-strategy", "append"},
+		},
+		{
+			desc:     "Merge lists by key",
+			overlays: []string{path.Join(wd, "./testdata/merge/overlay-prod.yaml")},
+			flags:    []string{"--list-strategy", "merge", "--merge-key", "name"},
+		},
+		{
+			desc: "Multiple overlays of different types",
+			overlays: []string{
+				path.Join(wd, "./testdata/merge/overlay-prod.yaml"),
+				path.Join(wd, "./testdata/merge/overlay.json"),
+				path.Join(wd, "./testdata/merge/overlay.toml"),
+			},
+		},
+		{
+			desc:     "Output as JSON",
+			overlays: []string{path.Join(wd, "./testdata/merge/overlay.json")},
+			flags:    []string{"--output-type", "json"},
+		},
+		{
+			desc:     "Overlay without extension and encoding",
+			base:     path.Join(wd, "./testdata/merge/messages.properties"),
+			overlays: []string{path.Join(wd, "./testdata/merge/messages_prod")},
+			flags:    []string{"--encoding", "iso-8859-1"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("TAG", "1.2.3")
+			t.Setenv("OWNER", "ops")
+			t.Setenv("NAME", "Jürgen")
+
+			output := path.Join(t.TempDir(), "output")
+
+			baseFile := base
+			if tC.base != "" {
+				baseFile = tC.base
+			}
+
+			args := append([]string{"config", "merge", baseFile}, tC.overlays...)
+			args = append(args, "-o", output, "-l", "trace", "-s")
+			args = append(args, tC.flags...)
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
+
+func TestMergeInvalidListStrategy(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	rootCmd.SetArgs([]string{"config", "merge", path.Join(wd, "./testdata/merge/base.yaml"), path.Join(wd, "./testdata/merge/overlay.json"), "--list-strategy", "zip"})
+	err = rootCmd.Execute()
+	assert.Error(t, err)
+}
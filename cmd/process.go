@@ -5,10 +5,17 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/denglertai/gonfig/internal/config"
 	"github.com/denglertai/gonfig/internal/file"
+	"github.com/denglertai/gonfig/internal/filter"
+	"github.com/denglertai/gonfig/internal/general"
 	"github.com/denglertai/gonfig/pkg/logging"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +23,22 @@ import (
 var output string
 var inline bool
 var overwriteExistingFile bool
+var watch bool
+var watchFiles []string
+var reloadSignal string
+var reloadPid int
+var reloadPidFile string
+var reloadCommand string
+var backup string
+var outputType string
+var schema string
+var coerceTypes bool
+var xsd string
+var xsdCatalog string
+var escape string
+var keys bool
+var wholeFile bool
+var stateFile string
 
 // processCmd represents the process command
 var processCmd = &cobra.Command{
@@ -25,49 +48,88 @@ var processCmd = &cobra.Command{
 	TraverseChildren: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		configSettings := getConfigSettings(args)
+		if len(outputType) > 0 {
+			configSettings.OutputType = general.FileType(outputType)
+		}
+		configSettings.Schema = schema
+		configSettings.CoerceTypes = coerceTypes
+		configSettings.Xsd = xsd
+		configSettings.XsdCatalog = xsdCatalog
+		configSettings.Escape = escape
+		configSettings.Keys = keys
+		configSettings.WholeFile = wholeFile
+		configSettings.StateFile = stateFile
 
 		logging.Info("RunE", "command", cmd.Name(), "args", args, "configSettings", configSettings)
 
+		// Unset the global variables after processing to prevent them from being reused in subsequent tests
+		defer func() {
+			output = "-"
+			inline = false
+			overwriteExistingFile = false
+			watch = false
+			watchFiles = []string{}
+			reloadSignal = "HUP"
+			reloadPid = 0
+			reloadPidFile = ""
+			reloadCommand = ""
+			backup = ""
+			outputType = ""
+			schema = ""
+			coerceTypes = false
+			xsd = ""
+			xsdCatalog = ""
+			escape = ""
+			keys = false
+			wholeFile = false
+			stateFile = ""
+		}()
+
 		// In case we want to write the output to the source file directly
+		if inline && configSettings.File == file.Stdin {
+			return fmt.Errorf("inline processing is not possible when reading from stdin")
+		}
 		if inline {
 			logging.Debug("Inline processing enabled")
 			output = configSettings.File
 			overwriteExistingFile = true
 		}
 
-		// Store the output temporarily in a buffer
-		var o = new(bytes.Buffer)
 		logging.Info("Processing file", "file", configSettings.File, "type", configSettings.FileType)
-		processor := file.NewFileProcessor(configSettings.File, configSettings.FileType, o)
-		err := processor.Process()
-		if err != nil {
-			return err
-		}
 
+		// Hashes of an existing output are kept if they still verify, so rendering the same values again doesn't change the output.
+		// When processing in place, the source is the existing output. It is read before the result is written
 		if output != "-" {
-			// If the file exists and we don't want to overwrite it, return an error
+			configSettings.Previous = output
+		}
+
+		if watch {
+			if inline || output == "-" {
+				return fmt.Errorf("watch mode requires an output file other than the source file")
+			}
+
 			if _, err := os.Stat(output); err == nil && !overwriteExistingFile {
 				return ErrFileExists(fmt.Errorf("file %s already exists; Use -w / --overwrite if this is intended", output))
 			}
 
-			// Write the output to the file
-			logging.Info("Writing output", "file", output)
-
-			f, err := os.Create(output)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-			_, err = f.Write(o.Bytes())
+			reload, err := newReloadFunc(reloadSignal, reloadPid, reloadPidFile, reloadCommand)
 			if err != nil {
 				return err
 			}
-		} else {
-			// Dump the content to stdout
-			os.Stdout.Write(o.Bytes())
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return watchAndProcess(ctx, watchOptions{
+				settings: configSettings,
+				output:   output,
+				backup:   backup,
+				files:    watchFiles,
+				reload:   reload,
+			})
 		}
 
-		return nil
+		return processFile(configSettings, output, overwriteExistingFile, backup)
 	},
 }
 
@@ -79,6 +141,173 @@ func init() {
 	processCmd.Flags().BoolVarP(&inline, "inline", "i", false, "Controls if the output should get written to the source file directly (defaults to false)")
 
 	processCmd.Flags().BoolVarP(&overwriteExistingFile, "overwrite", "w", false, "Controls if the output should overwrite the source file (defaults to false). This implies -i (--inline). If the source file does not exist, it will be created.")
+
+	processCmd.Flags().StringVar(&outputType, "output-type", "", "Type of file to be written. If set and different from the file type, the output is converted to this type")
+
+	processCmd.Flags().StringVar(&schema, "schema", "", "Path to a JSON Schema (draft 2020-12 unless declared otherwise) the processed document is validated against before it is written")
+
+	processCmd.Flags().BoolVar(&coerceTypes, "coerce-types", false, "Converts values produced by placeholders to the types required by --schema, e.g. \"3\" to 3 for an integer. Otherwise type violations are reported")
+
+	processCmd.Flags().StringVar(&xsd, "xsd", "", "Path to an XML schema the processed XML document is validated against before it is written")
+
+	processCmd.Flags().StringVar(&xsdCatalog, "xsd-catalog", "", "Directory containing XML schemas. Schemas referenced by xsi:schemaLocation of processed XML documents are looked up by file name or namespace and used for validation")
+
+	processCmd.Flags().StringVar(&escape, "escape", "", "Escapes the results of placeholders: auto escapes them for the context of the entry, e.g. CDATA sections or JSON embedded into a string. Any other value is the name of an escape filter applied to all results, e.g. shell_quote")
+
+	processCmd.Flags().BoolVar(&keys, "keys", false, "Processes placeholders within keys as well, e.g. property keys, JSON keys or XML element and attribute names. Keys of YAML files are always processed")
+
+	processCmd.Flags().BoolVar(&wholeFile, "whole-file", false, "Processes plain files as a whole rather than line by line, so placeholders may span several lines")
+
+	processCmd.Flags().StringVar(&stateFile, "state-file", "", "File values created by generator filters like random_password are stored in, so subsequent renders reuse them. The file contains secrets and is created readable by the owner only")
+
+	processCmd.Flags().StringVar(&backup, "backup", "", "Keeps the previous content of an existing output file in a backup file with the given suffix appended to its name (defaults to .bak if no suffix is given)")
+	processCmd.Flags().Lookup("backup").NoOptDefVal = ".bak"
+
+	processCmd.Flags().BoolVar(&watch, "watch", false, "Keeps running and re-renders the output whenever the source file, a file referenced using @ or one of the files given by --watch-file changes")
+
+	processCmd.Flags().StringArrayVar(&watchFiles, "watch-file", []string{}, "Additional file to be watched in watch mode, e.g. a mounted secret. May be passed multiple times")
+
+	processCmd.Flags().StringVar(&reloadSignal, "signal", "HUP", "Signal to be sent to --pid / --pid-file after the output has changed in watch mode")
+
+	processCmd.Flags().IntVar(&reloadPid, "pid", 0, "Process to be signaled after the output has changed in watch mode")
+
+	processCmd.Flags().StringVar(&reloadPidFile, "pid-file", "", "File containing the pid of the process to be signaled after the output has changed in watch mode")
+
+	processCmd.Flags().StringVar(&reloadCommand, "reload-command", "", "Command to be run after the output has changed in watch mode. Arguments are split using the quoting rules of POSIX shells, but the command is not run through a shell")
+}
+
+// processFile processes the configured file and writes the result to output, which may be "-" for stdout.
+// If backupSuffix is not empty, the previous content of an existing output file is kept in a backup
+func processFile(configSettings *config.Settings, output string, overwrite bool, backupSuffix string) error {
+	o, state, err := renderFile(configSettings)
+	if err != nil {
+		return err
+	}
+
+	if err := writeOutput(o, output, overwrite, backupSuffix); err != nil {
+		return err
+	}
+
+	// Generated values are persisted only once the output containing them has been written
+	return saveState(configSettings.StateFile, state)
+}
+
+// writeOutput writes the result to output, which may be "-" for stdout.
+// If backupSuffix is not empty, the previous content of an existing output file is kept in a backup
+func writeOutput(o []byte, output string, overwrite bool, backupSuffix string) error {
+	if output == "-" {
+		// Dump the content to stdout
+		_, err := os.Stdout.Write(o)
+		return err
+	}
+
+	// If the file exists and we don't want to overwrite it, return an error
+	if _, err := os.Stat(output); err == nil && !overwrite {
+		return ErrFileExists(fmt.Errorf("file %s already exists; Use -w / --overwrite if this is intended", output))
+	}
+
+	// Write the output to the file
+	logging.Info("Writing output", "file", output)
+
+	return file.WriteFileAtomic(output, o, backupSuffix)
+}
+
+// renderFile processes the configured file and returns the result along with the state of generated values,
+// which has to be saved after the result has been written
+func renderFile(configSettings *config.Settings) ([]byte, *filter.State, error) {
+	// Store the output temporarily in a buffer
+	var o = new(bytes.Buffer)
+	processor, err := newFileProcessor(configSettings, configSettings.File, o)
+	if err != nil {
+		return nil, nil, err
+	}
+	processor.OutputType = configSettings.OutputType
+	processor.Escape = configSettings.Escape
+	processor.Keys = configSettings.Keys
+	processor.WholeFile = configSettings.WholeFile
+	processor.Previous = configSettings.Previous
+	if configSettings.Schema != "" {
+		schema, err := file.LoadSchema(configSettings.Schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		processor.Schema = schema
+	}
+	processor.CoerceTypes = configSettings.CoerceTypes
+
+	if configSettings.XsdCatalog != "" {
+		catalog, err := file.LoadXsdCatalog(configSettings.XsdCatalog)
+		if err != nil {
+			return nil, nil, err
+		}
+		processor.XsdCatalog = catalog
+	}
+	if configSettings.Xsd != "" {
+		xsd, err := file.LoadXsd(configSettings.Xsd, processor.XsdCatalog)
+		if err != nil {
+			return nil, nil, err
+		}
+		processor.Xsd = xsd
+	}
+
+	state, err := loadState(configSettings.StateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter.UseState(state)
+	defer filter.UseState(nil)
+
+	err = processor.Process()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return o.Bytes(), state, nil
+}
+
+// loadState reads the state of generated values from the given file. It returns nil if no file is given
+func loadState(name string) (*filter.State, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return filter.NewState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := filter.ParseState(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the state file %s: %w", name, err)
+	}
+
+	return state, nil
+}
+
+// saveState writes the state of generated values to the given file if values have been added
+func saveState(name string, state *filter.State) error {
+	if state == nil || !state.Changed() {
+		return nil
+	}
+
+	data, err := state.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// The state contains secrets, new files are created readable by the owner only and their mode is kept afterwards
+	if _, err := os.Stat(name); errors.Is(err, fs.ErrNotExist) {
+		if err := os.WriteFile(name, nil, 0o600); err != nil {
+			return err
+		}
+	}
+
+	logging.Info("Writing state", "file", name)
+
+	return file.WriteFileAtomic(name, data, "")
 }
 
 type ErrFileExists error
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/denglertai/gonfig/internal/general"
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestStdout(t *testing.T) {
@@ -467,3 +470,559 @@ func TestYamlMove(t *testing.T) {
 		})
 	}
 }
+
+func TestStdin(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		file     string
+		fileType general.FileType
+		wantErr  bool
+	}{
+		{
+			desc:     "XML Explicit",
+			file:     path.Join(wd, "./testdata/xml/customers_param.xml"),
+			fileType: general.XML,
+		},
+		{
+			desc:     "YAML Explicit",
+			file:     path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			fileType: general.YAML,
+		},
+		{
+			desc:    "YAML Missing Type",
+			file:    path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("BLA_BLUB", "yoyoyo")
+			t.Setenv("INT", "123")
+			t.Setenv("FLOAT", "123.123")
+			t.Setenv("BOOL", "true")
+			t.Setenv("STRING", "string")
+			t.Setenv("SPECIAL_CHARACTERS", "%^&*()_+")
+
+			source, err := os.Open(tC.file)
+			assert.NoError(t, err)
+			defer source.Close()
+
+			oldStdin := os.Stdin // keep backup of the real stdin
+			os.Stdin = source
+			defer func() { os.Stdin = oldStdin }()
+
+			old := os.Stdout // keep backup of the real stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			outC := make(chan string)
+			// copy the output in a separate goroutine so printing can't block indefinitely
+			go func() {
+				var buf bytes.Buffer
+				io.Copy(&buf, r)
+				outC <- buf.String()
+			}()
+
+			args := []string{"config", "process", "-f", "-", "-o", "-", "-l", "trace", "-s"}
+			if tC.fileType != general.Undefined {
+				args = append(args, "-t", string(tC.fileType))
+			}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+
+			// back to normal state
+			w.Close()
+			os.Stdout = old // restoring the real stdout
+			out := <-outC
+
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			snaps.MatchSnapshot(t, out)
+		})
+	}
+}
+
+func TestNoExtension(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc string
+		file string
+	}{
+		{
+			desc: "XML",
+			file: path.Join(wd, "./testdata/xml/customers_param.xml"),
+		},
+		{
+			desc: "JSON",
+			file: path.Join(wd, "./testdata/json/quiz_param.json"),
+		},
+		{
+			desc: "YAML",
+			file: path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("BLA_BLUB", "yoyoyo")
+			t.Setenv("INT", "123")
+			t.Setenv("FLOAT", "123.123")
+			t.Setenv("BOOL", "true")
+			t.Setenv("STRING", "string")
+			t.Setenv("SPECIAL_CHARACTERS", "%^&*()_+")
+
+			// Copy the file to a file without extension
+			fileName := path.Join(t.TempDir(), "config")
+			c, err := os.ReadFile(tC.file)
+			assert.NoError(t, err)
+			assert.NoError(t, os.WriteFile(fileName, c, 0644))
+
+			args := []string{"config", "process", "-f", fileName, "-i", "-l", "trace", "-s"}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(fileName)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		replicas string
+		wantErr  bool
+	}{
+		{
+			desc:     "Valid",
+			replicas: "3",
+		},
+		{
+			desc:     "Invalid",
+			replicas: "three",
+			wantErr:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("APP_NAME", "shop")
+			t.Setenv("REPLICAS", tC.replicas)
+			t.Setenv("TAG", "1.2.3")
+			t.Setenv("PORT", "8080")
+
+			output := path.Join(t.TempDir(), "output.yaml")
+
+			args := []string{"config", "process", "-f", path.Join(wd, "./testdata/schema/deployment.yaml"), "--schema", path.Join(wd, "./testdata/schema/deployment.schema.json"), "--coerce-types", "-o", output, "-l", "trace", "-s"}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			if tC.wantErr {
+				assert.ErrorContains(t, err, `replicas: got string, want integer (template "${REPLICAS}", variables REPLICAS)`)
+				assert.NoFileExists(t, output)
+				return
+			}
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
+
+func TestXsd(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc    string
+		port    string
+		wantErr bool
+	}{
+		{
+			desc: "Valid",
+			port: "8080",
+		},
+		{
+			desc:    "Invalid",
+			port:    "http",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("SHUTDOWN_PORT", "8005")
+			t.Setenv("HTTP_PORT", tC.port)
+			t.Setenv("PROTOCOL", "HTTP/1.1")
+			t.Setenv("REALM", "realm")
+
+			output := path.Join(t.TempDir(), "server.xml")
+
+			args := []string{"config", "process", "-f", path.Join(wd, "./testdata/xsd/server.xml"), "--xsd", path.Join(wd, "./testdata/xsd/server.xsd"), "-o", output, "-l", "trace", "-s"}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			if tC.wantErr {
+				assert.ErrorContains(t, err, "/Server/Service/Connector/@port: value 'http' is not a valid int")
+				assert.NoFileExists(t, output)
+				return
+			}
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		file     string
+		fileType general.FileType
+		escape   string
+		wantErr  bool
+	}{
+		{
+			desc:   "Auto",
+			file:   path.Join(wd, "./testdata/escape/logback.xml"),
+			escape: "auto",
+		},
+		{
+			desc:     "Filter",
+			file:     path.Join(wd, "./testdata/escape/start.sh"),
+			fileType: general.PLAIN,
+			escape:   "shell_quote",
+		},
+		{
+			desc:     "Unknown",
+			file:     path.Join(wd, "./testdata/escape/start.sh"),
+			fileType: general.PLAIN,
+			escape:   "html",
+			wantErr:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("SERVICE_NAME", `shop "eu" & <co> ]]>'`)
+
+			output := path.Join(t.TempDir(), "output")
+
+			args := []string{"config", "process", "-f", tC.file, "--escape", tC.escape, "-o", output, "-l", "trace", "-s"}
+			if tC.fileType != general.Undefined {
+				args = append(args, "-t", string(tC.fileType))
+			}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			if tC.wantErr {
+				assert.ErrorContains(t, err, "unknown escaping mode html")
+				assert.NoFileExists(t, output)
+				return
+			}
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		file     string
+		encoding string
+		expected string
+		wantErr  string
+	}{
+		{
+			desc:     "ISO-8859-1",
+			file:     path.Join(wd, "./testdata/properties/messages_de.properties"),
+			encoding: "iso-8859-1",
+			expected: "# Gr\xfc\xdfe\ngreeting=Hallo J\xfcrgen \\u20AC, sch\xf6n dich zu sehen\n",
+		},
+		{
+			desc:     "Unknown encoding",
+			file:     path.Join(wd, "./testdata/properties/messages_de.properties"),
+			encoding: "utf-16",
+			wantErr:  "unknown encoding utf-16",
+		},
+		{
+			desc:     "Unsupported file type",
+			file:     path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			encoding: "latin1",
+			wantErr:  "setting the encoding of yaml files is not supported",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("NAME", "Jürgen €")
+
+			output := path.Join(t.TempDir(), "output.properties")
+
+			args := []string{"config", "process", "-f", tC.file, "--encoding", tC.encoding, "-o", output, "-l", "trace", "-s"}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			if tC.wantErr != "" {
+				assert.ErrorContains(t, err, tC.wantErr)
+				assert.NoFileExists(t, output)
+				return
+			}
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, string(res))
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc        string
+		otherTenant string
+		expected    string
+		wantErr     string
+	}{
+		{
+			desc:        "Tenant specific keys",
+			otherTenant: "globex",
+			expected:    "acme.datasource.url=jdbc:postgresql://db/acme\nglobex.datasource.url=jdbc:postgresql://db/globex\n",
+		},
+		{
+			desc:        "Conflicting keys",
+			otherTenant: "acme",
+			wantErr:     "conflicting keys",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("TENANT", "acme")
+			t.Setenv("OTHER_TENANT", tC.otherTenant)
+
+			output := path.Join(t.TempDir(), "output.properties")
+
+			args := []string{"config", "process", "-f", path.Join(wd, "./testdata/properties/tenant_param.properties"), "--keys", "-o", output, "-l", "trace", "-s"}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			if tC.wantErr != "" {
+				assert.ErrorContains(t, err, tC.wantErr)
+				assert.NoFileExists(t, output)
+				return
+			}
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, string(res))
+		})
+	}
+}
+
+func TestPlainLineEndings(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc      string
+		wholeFile bool
+	}{
+		{
+			desc: "Line by line",
+		},
+		{
+			desc:      "Whole file",
+			wholeFile: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("TENANT", "acme")
+
+			output := path.Join(t.TempDir(), "start.bat")
+
+			args := []string{"config", "process", "-f", path.Join(wd, "./testdata/plain/start.bat"), "-t", "plain", "-o", output, "-l", "trace", "-s"}
+			if tC.wholeFile {
+				args = append(args, "--whole-file")
+			}
+
+			rootCmd.SetArgs(args)
+			err = rootCmd.Execute()
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(output)
+			assert.NoError(t, err)
+			assert.Equal(t, "@echo off\r\nset JAVA_OPTS=-Dtenant=acme\r\njava %JAVA_OPTS% -jar app.jar", string(res))
+		})
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	source := path.Join(wd, "./testdata/properties/credentials_param.properties")
+	t.Cleanup(func() {
+		rootCmd.PersistentFlags().Set("deterministic", "false")
+		// The process command doesn't reset its flags if it isn't run
+		output = "-"
+	})
+
+	render := func(t *testing.T, output string, extraArgs ...string) (string, error) {
+		rootCmd.SetArgs(append([]string{"config", "process", "-f", source, "-o", output}, extraArgs...))
+		if err := rootCmd.Execute(); err != nil {
+			return "", err
+		}
+
+		res, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		return string(res), nil
+	}
+
+	t.Run("Salts derived from the seed", func(t *testing.T) {
+		t.Setenv("ADMIN_PASSWORD", "s3cret")
+		t.Setenv("GONFIG_SEED", "gitops")
+
+		first, err := render(t, path.Join(t.TempDir(), "first.properties"), "--deterministic")
+		assert.NoError(t, err)
+		second, err := render(t, path.Join(t.TempDir(), "second.properties"), "--deterministic")
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+
+		hash := strings.TrimSpace(strings.SplitN(first, "admin.password=", 2)[1])
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cret")))
+	})
+
+	t.Run("Seed required", func(t *testing.T) {
+		t.Setenv("ADMIN_PASSWORD", "s3cret")
+		t.Setenv("GONFIG_SEED", "")
+
+		_, err := render(t, path.Join(t.TempDir(), "out.properties"), "--deterministic")
+		assert.ErrorContains(t, err, "deterministic mode requires a seed")
+	})
+
+	t.Run("Existing hashes are kept", func(t *testing.T) {
+		rootCmd.PersistentFlags().Set("deterministic", "false")
+		t.Setenv("ADMIN_PASSWORD", "s3cret")
+		output := path.Join(t.TempDir(), "out.properties")
+
+		first, err := render(t, output)
+		assert.NoError(t, err)
+		second, err := render(t, output, "-w")
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+
+		// A changed password doesn't verify against the existing hash
+		t.Setenv("ADMIN_PASSWORD", "changed")
+		third, err := render(t, output, "-w")
+		assert.NoError(t, err)
+		assert.NotEqual(t, first, third)
+	})
+
+	t.Run("In place", func(t *testing.T) {
+		rootCmd.PersistentFlags().Set("deterministic", "false")
+		t.Setenv("ADMIN_PASSWORD", "s3cret")
+		template, err := os.ReadFile(source)
+		assert.NoError(t, err)
+		inPlace := path.Join(t.TempDir(), "credentials.properties")
+		assert.NoError(t, os.WriteFile(inPlace, template, 0o644))
+
+		// The source is read as existing output before it is overwritten
+		rootCmd.SetArgs([]string{"config", "process", "-f", inPlace, "-i"})
+		assert.NoError(t, rootCmd.Execute())
+
+		res, err := os.ReadFile(inPlace)
+		assert.NoError(t, err)
+		hash := strings.TrimSpace(strings.SplitN(string(res), "admin.password=", 2)[1])
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cret")))
+	})
+}
+
+func TestStateFile(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	source := path.Join(wd, "./testdata/properties/generated_param.properties")
+	dir := t.TempDir()
+	stateFile := path.Join(dir, "state.json")
+
+	render := func(output string) string {
+		rootCmd.SetArgs([]string{"config", "process", "-f", source, "-o", output, "--state-file", stateFile})
+		assert.NoError(t, rootCmd.Execute())
+
+		res, err := os.ReadFile(output)
+		assert.NoError(t, err)
+		return string(res)
+	}
+
+	first := render(path.Join(dir, "first.properties"))
+	assert.Regexp(t, `^admin.password=[A-Za-z0-9]{16}\ninstance.id=[0-9a-f-]{36}\n$`, first)
+
+	// Subsequent renders reuse the generated values
+	assert.Equal(t, first, render(path.Join(dir, "second.properties")))
+
+	info, err := os.Stat(stateFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Given values take precedence and aren't stored
+	t.Setenv("ADMIN_PASSWORD", "given")
+	third := render(path.Join(dir, "third.properties"))
+	assert.True(t, strings.HasPrefix(third, "admin.password=given\n"))
+	assert.Equal(t, strings.SplitN(first, "\n", 2)[1], strings.SplitN(third, "\n", 2)[1])
+}
+
+func TestStateFileNotWrittenOnFailedWrite(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	source := path.Join(wd, "./testdata/properties/generated_param.properties")
+	dir := t.TempDir()
+	stateFile := path.Join(dir, "state.json")
+	output := path.Join(dir, "existing.properties")
+	assert.NoError(t, os.WriteFile(output, []byte("existing\n"), 0o644))
+
+	// The output isn't overwritten without -w, so the generated values must not be persisted either
+	rootCmd.SetArgs([]string{"config", "process", "-f", source, "-o", output, "--state-file", stateFile})
+	assert.Error(t, rootCmd.Execute())
+
+	_, err = os.Stat(stateFile)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	res, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "existing\n", string(res))
+}
@@ -5,9 +5,12 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/denglertai/gonfig/internal/config"
+	"github.com/denglertai/gonfig/internal/filter"
 	"github.com/denglertai/gonfig/internal/logging"
 	"github.com/denglertai/gonfig/internal/plugin"
 	"github.com/spf13/cobra"
@@ -28,6 +31,8 @@ var rootCmd = &cobra.Command{
 		v.BindPFlag("log-source", cmd.PersistentFlags().Lookup("log-source"))
 		v.BindPFlag("config-path", cmd.PersistentFlags().Lookup("config-path"))
 		v.BindPFlag("plugin-path", cmd.PersistentFlags().Lookup("plugin-path"))
+		// The flags of the subcommand being run include the persistent flags inherited from the root command
+		v.BindPFlag("deterministic", cmd.Flags().Lookup("deterministic"))
 
 		// Reload Viper after binding config-path flag to apply custom config path
 		v = config.SetupViper()
@@ -35,6 +40,7 @@ var rootCmd = &cobra.Command{
 		v.BindPFlag("log-source", cmd.PersistentFlags().Lookup("log-source"))
 		v.BindPFlag("config-path", cmd.PersistentFlags().Lookup("config-path"))
 		v.BindPFlag("plugin-path", cmd.PersistentFlags().Lookup("plugin-path"))
+		v.BindPFlag("deterministic", cmd.Flags().Lookup("deterministic"))
 
 		// Load into AppConfig
 		cfg := config.LoadAppConfig(v)
@@ -46,7 +52,32 @@ var rootCmd = &cobra.Command{
 		// Initialize the Plugin system
 		plugin.InitPlugins(cfg.PluginPath)
 
-		return logging.InitLogging(cfg.LogLevel, cfg.LogSource)
+		if err := logging.InitLogging(cfg.LogLevel, cfg.LogSource); err != nil {
+			return err
+		}
+
+		// Derive the salts of hashing filters from the seed in deterministic mode
+		if cfg.Deterministic && cfg.Seed == "" {
+			return fmt.Errorf("deterministic mode requires a seed, set seed in .gonfig.yaml or GONFIG_SEED")
+		}
+		if cfg.Deterministic {
+			filter.SetSeed(cfg.Seed)
+		} else {
+			filter.SetSeed("")
+		}
+
+		// Fix the clock of the date filters, e.g. for reproducible renders in tests
+		var fixedNow time.Time
+		if cfg.Now != "" {
+			t, err := time.Parse(time.RFC3339, cfg.Now)
+			if err != nil {
+				return fmt.Errorf("invalid now %q, expected a date like 2006-01-02T15:04:05Z07:00", cfg.Now)
+			}
+			fixedNow = t
+		}
+		filter.SetNow(fixedNow)
+
+		return nil
 	},
 	TraverseChildren: true,
 }
@@ -79,4 +110,5 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("log-source", "s", false, "Whether to include source location in log output or not")
 	rootCmd.PersistentFlags().String("config-path", "", "Path to the directory containing .gonfig config file (env var: GONFIG_CONFIG_PATH)")
 	rootCmd.PersistentFlags().String("plugin-path", "./plugins", "Path to plugin directory (env var: GONFIG_PLUGIN_PATH)")
+	rootCmd.PersistentFlags().Bool("deterministic", false, "Derives the salts of hashing filters from the seed set in the config file or GONFIG_SEED rather than using random salts, so renders are reproducible (env var: GONFIG_DETERMINISTIC)")
 }
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/denglertai/gonfig/internal/file"
+	"github.com/denglertai/gonfig/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+// setCmd represents the set command
+var setCmd = &cobra.Command{
+	Use:   "set <path> <value>",
+	Short: "Sets the value of a single entry",
+	Long: `Sets the value of the entry identified by the given path and writes the file back.
+The path is the same as reported for the entries while processing a file, e.g. spec.template.spec.containers.0.image for YAML or /customers/customer/name for XML files.
+The value is written as is, no filters are applied.`,
+	Args:             cobra.ExactArgs(2),
+	TraverseChildren: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configSettings := getConfigSettings(args)
+
+		logging.Info("RunE", "command", cmd.Name(), "args", args, "configSettings", configSettings)
+
+		// Store the output temporarily in a buffer so the source file is left untouched on errors
+		var o = new(bytes.Buffer)
+		processor, err := newFileProcessor(configSettings, configSettings.File, o)
+		if err != nil {
+			return err
+		}
+
+		err = processor.Set(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		// There is no file to be written back when reading from stdin
+		if configSettings.File == file.Stdin {
+			_, err = os.Stdout.Write(o.Bytes())
+			return err
+		}
+
+		logging.Info("Writing output", "file", configSettings.File)
+
+		return file.WriteFileAtomic(configSettings.File, o.Bytes(), "")
+	},
+}
+
+func init() {
+	configCmd.AddCommand(setCmd)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc    string
+		file    string
+		path    string
+		value   string
+		wantErr bool
+	}{
+		{
+			desc:  "XML Element",
+			file:  path.Join(wd, "./testdata/xml/customers_param.xml"),
+			path:  "/customers/customer/name",
+			value: "John & Doe",
+		},
+		{
+			desc:  "XML Attribute",
+			file:  path.Join(wd, "./testdata/xml/customers_param.xml"),
+			path:  "/customers/customer/@id",
+			value: "42",
+		},
+		{
+			desc:  "JSON",
+			file:  path.Join(wd, "./testdata/json/quiz_param.json"),
+			path:  "quiz.sport.q1.answer",
+			value: "Golden State Warriors",
+		},
+		{
+			desc:  "YAML",
+			file:  path.Join(wd, "./testdata/yaml/deployment_param.yaml"),
+			path:  "spec.template.spec.containers.0.image",
+			value: "nginx:1.27.0",
+		},
+		{
+			desc:  "PROPERTIES",
+			file:  path.Join(wd, "./testdata/properties/props_param.properties"),
+			path:  "server.port",
+			value: "9090",
+		},
+		{
+			desc:    "Unknown Path",
+			file:    path.Join(wd, "./testdata/properties/props_param.properties"),
+			path:    "does.not.exist",
+			value:   "x",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			tmp := os.TempDir()
+			file, err := os.CreateTemp(tmp, fmt.Sprintf("gonfig-test-*%s", path.Ext(tC.file)))
+			assert.NoError(t, err)
+			fileName := file.Name()
+
+			defer os.Remove(fileName)
+
+			// Copy the file to the temp file
+			source, err := os.Open(tC.file)
+			assert.NoError(t, err)
+			defer source.Close()
+
+			_, err = io.Copy(file, source)
+			assert.NoError(t, err)
+			file.Close()
+
+			rootCmd.SetArgs([]string{"config", "set", "-f", fileName, tC.path, tC.value})
+			err = rootCmd.Execute()
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			res, err := os.ReadFile(fileName)
+			assert.NoError(t, err)
+
+			snaps.MatchSnapshot(t, string(res))
+		})
+	}
+}
@@ -0,0 +1,2 @@
+#!/bin/sh
+exec java -Dservice.name=${SERVICE_NAME} -jar app.jar
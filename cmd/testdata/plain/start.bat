@@ -0,0 +1,3 @@
+@echo off
+set JAVA_OPTS=-Dtenant=${TENANT}
+java %JAVA_OPTS% -jar app.jar
\ No newline at end of file
@@ -4,6 +4,8 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/denglertai/gonfig/internal/value"
 	"github.com/spf13/cobra"
 )
@@ -26,7 +28,8 @@ to quickly create a Cobra application.`,
 				return err
 			}
 
-			cmd.OutOrStdout().Write([]byte(result.(string)))
+			// Typed results, e.g. numbers, are printed as text
+			fmt.Fprint(cmd.OutOrStdout(), result)
 		}
 
 		return nil
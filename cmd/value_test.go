@@ -29,6 +29,22 @@ func TestValue(t *testing.T) {
 				"ABC": "123",
 			},
 		},
+		{
+			name:     "fixed clock",
+			input:    "${RENDERED_AT | now(format=date)}",
+			expected: "2024-05-01",
+			env: map[string]string{
+				"GONFIG_NOW": "2024-05-01T12:00:00Z",
+			},
+		},
+		{
+			name:     "typed result",
+			input:    "${EXPIRY | to_unix}",
+			expected: "1714564800",
+			env: map[string]string{
+				"EXPIRY": "2024-05-01T12:00:00Z",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denglertai/gonfig/internal/config"
+	"github.com/denglertai/gonfig/internal/file"
+	"github.com/denglertai/gonfig/internal/filter"
+	"github.com/denglertai/gonfig/pkg/logging"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is the time to wait for further changes before re-rendering
+const watchDebounce = 200 * time.Millisecond
+
+// watchRetry is the time to wait before retrying to watch directories which don't exist yet, e.g. of secrets mounted later
+const watchRetry = 2 * time.Second
+
+// watchOptions configures the watch mode of the process command
+type watchOptions struct {
+	// settings describe the template to be rendered
+	settings *config.Settings
+	// output is the file the result is written to
+	output string
+	// backup is the suffix of the backup file the previous content of the output is kept in, if not empty
+	backup string
+	// files are additional files, e.g. mounted secrets, which trigger a re-render when changed
+	files []string
+	// reload is called after the output has been changed by a re-render
+	reload func() error
+}
+
+// watchAndProcess renders the source and re-renders it whenever the source, one of the additional files or
+// one of the files referenced using @ changes. It blocks until the context is done
+func watchAndProcess(ctx context.Context, opts watchOptions) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// Directories are watched instead of the files themselves since files are usually replaced rather than written,
+	// e.g. by editors or by Kubernetes swapping the symlinks of mounted secrets
+	watchedDirs := map[string]bool{}
+	var last []byte
+	var retry <-chan time.Time
+
+	render := func() (bool, error) {
+		referenced := make([]string, 0)
+		filter.ObserveFileReads(func(path string) {
+			referenced = append(referenced, path)
+		})
+		defer filter.ObserveFileReads(nil)
+
+		o, state, err := renderFile(opts.settings)
+		if err != nil {
+			return false, err
+		}
+
+		files := append([]string{opts.settings.File}, opts.files...)
+		for _, f := range append(files, referenced...) {
+			dir, err := filepath.Abs(filepath.Dir(f))
+			if err != nil {
+				return false, err
+			}
+			if watchedDirs[dir] {
+				continue
+			}
+
+			logging.Debug("Watching directory", "dir", dir)
+			if err := watcher.Add(dir); err != nil {
+				// The directory may not exist yet, it is watched as soon as it does
+				logging.Warn("Failed to watch directory, retrying", "dir", dir, "error", err)
+				if retry == nil {
+					retry = time.After(watchRetry)
+				}
+				continue
+			}
+			watchedDirs[dir] = true
+		}
+
+		if last != nil && bytes.Equal(last, o) {
+			logging.Debug("Output unchanged, skipping write", "file", opts.output)
+			return false, saveState(opts.settings.StateFile, state)
+		}
+
+		logging.Info("Writing output", "file", opts.output)
+		if err := file.WriteFileAtomic(opts.output, o, opts.backup); err != nil {
+			return false, err
+		}
+		last = o
+
+		return true, saveState(opts.settings.StateFile, state)
+	}
+
+	if _, err := render(); err != nil {
+		return err
+	}
+
+	output, err := filepath.Abs(opts.output)
+	if err != nil {
+		return err
+	}
+	tmpPrefix := file.TempFilePrefix(output)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// Ignore the changes caused by writing the output
+			if event.Name == output || strings.HasPrefix(filepath.Base(event.Name), tmpPrefix) {
+				continue
+			}
+
+			logging.Trace("Change detected", "file", event.Name, "op", event.Op.String())
+			debounce = time.After(watchDebounce)
+		case <-retry:
+			// Re-rendering watches the directories which didn't exist before and picks up the files within them
+			retry = nil
+			debounce = time.After(0)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logging.Error("Error watching files", "error", err)
+		case <-debounce:
+			debounce = nil
+
+			changed, err := render()
+			if err != nil {
+				// Keep the last good output and continue watching
+				logging.Error("Failed to re-render file", "file", opts.settings.File, "error", err)
+				continue
+			}
+
+			if changed && opts.reload != nil {
+				if err := opts.reload(); err != nil {
+					logging.Error("Failed to reload", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// newReloadFunc creates the function to be called after the output has been changed.
+// It signals the given process and / or runs the given command. Returns nil if there is nothing to do
+func newReloadFunc(signalName string, pid int, pidFile string, command string) (func() error, error) {
+	actions := make([]func() error, 0)
+
+	if pid > 0 || pidFile != "" {
+		sig, err := parseSignal(signalName)
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, func() error {
+			target := pid
+			if pidFile != "" {
+				// Read the pid file on every reload since the process may have been restarted in the meantime
+				content, err := os.ReadFile(pidFile)
+				if err != nil {
+					return err
+				}
+				target, err = strconv.Atoi(strings.TrimSpace(string(content)))
+				if err != nil {
+					return fmt.Errorf("invalid pid file %s: %w", pidFile, err)
+				}
+			}
+
+			process, err := os.FindProcess(target)
+			if err != nil {
+				return err
+			}
+
+			logging.Info("Sending signal", "pid", target, "signal", sig.String())
+			return process.Signal(sig)
+		})
+	}
+
+	if command != "" {
+		// The command is not run through a shell since minimal images usually don't have one
+		args, err := splitCommand(command)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reload command: %w", err)
+		}
+		actions = append(actions, func() error {
+			logging.Info("Running reload command", "command", command)
+
+			c := exec.Command(args[0], args[1:]...)
+			c.Stdout = os.Stderr
+			c.Stderr = os.Stderr
+			return c.Run()
+		})
+	}
+
+	if len(actions) == 0 {
+		return nil, nil
+	}
+
+	return func() error {
+		for _, action := range actions {
+			if err := action(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// splitCommand splits a command into its arguments following the quoting rules of POSIX shells:
+// arguments are separated by whitespace, single quotes keep their content as is, double quotes keep it except for
+// the escapes \", \\, \$ and \` and a backslash outside of quotes escapes the following character.
+// Expansions like $HOME or globs are not supported
+func splitCommand(command string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArg := false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote at position %d", i+1)
+			}
+			current.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			start := i
+			for i++; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0 {
+					i++
+				}
+				current.WriteByte(command[i])
+			}
+			if i >= len(command) {
+				return nil, fmt.Errorf("unterminated double quote at position %d", start+1)
+			}
+			inArg = true
+		case c == '\\':
+			if i+1 >= len(command) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			current.WriteByte(command[i])
+			inArg = true
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	return args, nil
+}
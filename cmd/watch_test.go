@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/denglertai/gonfig/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	secretDir := t.TempDir()
+
+	source := path.Join(dir, "template.yaml")
+	output := path.Join(dir, "output.yaml")
+	secret := path.Join(secretDir, "password")
+	extra := path.Join(secretDir, "extra")
+	// The directory of a watched file may not exist yet, e.g. of a secret mounted later
+	later := path.Join(t.TempDir(), "later", "secret")
+
+	assert.NoError(t, os.WriteFile(source, []byte("password: ${PASSWORD}\n"), 0644))
+	assert.NoError(t, os.WriteFile(secret, []byte("first"), 0600))
+	assert.NoError(t, os.WriteFile(extra, []byte("unused"), 0600))
+
+	t.Setenv("PASSWORD", "@"+secret)
+
+	var reloads atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		configSettings := config.NewSettings()
+		configSettings.File = source
+
+		done <- watchAndProcess(ctx, watchOptions{
+			settings: configSettings,
+			output:   output,
+			backup:   ".bak",
+			files:    []string{extra, later},
+			reload: func() error {
+				reloads.Add(1)
+				return nil
+			},
+		})
+	}()
+
+	readOutput := func() string {
+		content, _ := os.ReadFile(output)
+		return string(content)
+	}
+
+	// Initial render
+	assert.Eventually(t, func() bool { return readOutput() == "password: first\n" }, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, int32(0), reloads.Load())
+
+	// A change of the referenced file triggers a re-render and a reload
+	assert.NoError(t, os.WriteFile(secret, []byte("second"), 0600))
+	assert.Eventually(t, func() bool { return readOutput() == "password: second\n" }, 5*time.Second, 50*time.Millisecond)
+	assert.Eventually(t, func() bool { return reloads.Load() == 1 }, 5*time.Second, 50*time.Millisecond)
+
+	// The previous output is kept in a backup
+	previous, err := os.ReadFile(output + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, "password: first\n", string(previous))
+
+	// A change of the template triggers a re-render and a reload
+	assert.NoError(t, os.WriteFile(source, []byte("secret: ${PASSWORD}\n"), 0644))
+	assert.Eventually(t, func() bool { return readOutput() == "secret: second\n" }, 5*time.Second, 50*time.Millisecond)
+	assert.Eventually(t, func() bool { return reloads.Load() == 2 }, 5*time.Second, 50*time.Millisecond)
+
+	// A change that doesn't affect the output doesn't trigger a reload
+	assert.NoError(t, os.WriteFile(extra, []byte("still unused"), 0600))
+	time.Sleep(3 * watchDebounce)
+	assert.Equal(t, int32(2), reloads.Load())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestSplitCommand(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		command  string
+		expected []string
+		wantErr  string
+	}{
+		{desc: "Plain", command: "nginx -s reload", expected: []string{"nginx", "-s", "reload"}},
+		{desc: "Single quotes", command: `sh -c 'kill -HUP $(cat /run/app.pid)'`, expected: []string{"sh", "-c", "kill -HUP $(cat /run/app.pid)"}},
+		{desc: "Double quotes with escapes", command: `curl -d "{\"reload\": true}" "http://localhost/a b"`, expected: []string{"curl", "-d", `{"reload": true}`, "http://localhost/a b"}},
+		{desc: "Backslash outside of quotes", command: `touch /tmp/a\ b`, expected: []string{"touch", "/tmp/a b"}},
+		{desc: "Adjacent quotes", command: `echo 'a'"b"c ''`, expected: []string{"echo", "abc", ""}},
+		{desc: "Unterminated single quote", command: "sh -c 'kill", wantErr: "unterminated single quote at position 7"},
+		{desc: "Unterminated double quote", command: `echo "a`, wantErr: "unterminated double quote at position 6"},
+		{desc: "Empty", command: "  ", wantErr: "empty command"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			args, err := splitCommand(tC.command)
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, args)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// parseSignal parses a signal name like HUP or SIGHUP
+func parseSignal(name string) (os.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	sig := unix.SignalNum(name)
+	if sig == 0 {
+		return nil, fmt.Errorf("unknown signal: %s", name)
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseSignal parses a signal name. Windows only supports killing a process
+func parseSignal(name string) (os.Signal, error) {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "KILL":
+		return os.Kill, nil
+	}
+
+	return nil, fmt.Errorf("signal %s is not supported on windows", name)
+}
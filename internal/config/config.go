@@ -14,31 +14,71 @@ type Settings struct {
 
 	// FileType is the type of file to be read. If not set, the file type will be inferred from the file extension
 	FileType general.FileType
+
+	// OutputType is the type of file to be written. If not set, the output has the same type as the file
+	OutputType general.FileType
+
+	// Schema is the path to a JSON Schema the processed document is validated against. If not set, no validation takes place
+	Schema string
+
+	// CoerceTypes controls whether values produced by placeholders are converted to the types required by the schema
+	CoerceTypes bool
+
+	// Xsd is the path to an XML schema the processed XML document is validated against
+	Xsd string
+
+	// XsdCatalog is the path to a directory containing XML schemas referenced by xsi:schemaLocation of processed XML documents
+	XsdCatalog string
+
+	// Escape controls how the results of placeholders are escaped: empty for no escaping, auto or the name of an escape filter
+	Escape string
+
+	// Encoding is the encoding of properties files being read or written. If not set, UTF-8 is used
+	Encoding string
+
+	// Keys controls whether placeholders within keys, e.g. property keys or element names, are processed as well
+	Keys bool
+
+	// WholeFile controls whether plain files are processed as a single entry rather than line by line
+	WholeFile bool
+
+	// Previous is the path of an existing output. Hashes within it which still verify against the processed values are kept
+	Previous string
+
+	// StateFile is the path of the file generated values are stored in, so subsequent renders reuse them. If not set, nothing is stored
+	StateFile string
 }
 
 // NewSettings returns a new Settings instance
 func NewSettings() *Settings {
 	return &Settings{
-		FileType: general.Undefined,
+		FileType:   general.Undefined,
+		OutputType: general.Undefined,
 	}
 }
 
 // AppConfig holds all global application settings
 type AppConfig struct {
-	LogLevel   string
-	LogSource  bool
-	ConfigPath string
-	PluginPath string
+	LogLevel      string
+	LogSource     bool
+	ConfigPath    string
+	PluginPath    string
+	Deterministic bool
+	Seed          string
+	Now           string
 }
 
 // LoadAppConfig loads configuration from multiple sources
 // Precedence: CLI flags > env vars > config file > defaults
 func LoadAppConfig(v *viper.Viper) *AppConfig {
 	return &AppConfig{
-		LogLevel:   v.GetString("log-level"),
-		LogSource:  v.GetBool("log-source"),
-		ConfigPath: v.GetString("config-path"),
-		PluginPath: v.GetString("plugin-path"),
+		LogLevel:      v.GetString("log-level"),
+		LogSource:     v.GetBool("log-source"),
+		ConfigPath:    v.GetString("config-path"),
+		PluginPath:    v.GetString("plugin-path"),
+		Deterministic: v.GetBool("deterministic"),
+		Seed:          v.GetString("seed"),
+		Now:           v.GetString("now"),
 	}
 }
 
@@ -51,6 +91,9 @@ func SetupViper() *viper.Viper {
 	v.SetDefault("log-source", false)
 	v.SetDefault("config-path", "")
 	v.SetDefault("plugin-path", "./plugins")
+	v.SetDefault("deterministic", false)
+	v.SetDefault("seed", "")
+	v.SetDefault("now", "")
 
 	// Environment variables
 	v.SetEnvPrefix("GONFIG")
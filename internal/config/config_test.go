@@ -21,3 +21,26 @@ func TestLoadAppConfig_PluginPathFromEnv(t *testing.T) {
 		t.Fatalf("expected plugin path from env %q, got %q", "/tmp/custom-plugins", cfg.PluginPath)
 	}
 }
+
+func TestLoadAppConfig_DeterministicFromEnv(t *testing.T) {
+	t.Setenv("GONFIG_DETERMINISTIC", "true")
+	t.Setenv("GONFIG_SEED", "gitops")
+
+	v := SetupViper()
+	cfg := LoadAppConfig(v)
+
+	if !cfg.Deterministic || cfg.Seed != "gitops" {
+		t.Fatalf("expected deterministic mode with seed %q, got %v with seed %q", "gitops", cfg.Deterministic, cfg.Seed)
+	}
+}
+
+func TestLoadAppConfig_NowFromEnv(t *testing.T) {
+	t.Setenv("GONFIG_NOW", "2024-05-01T12:00:00Z")
+
+	v := SetupViper()
+	cfg := LoadAppConfig(v)
+
+	if cfg.Now != "2024-05-01T12:00:00Z" {
+		t.Fatalf("expected now from env %q, got %q", "2024-05-01T12:00:00Z", cfg.Now)
+	}
+}
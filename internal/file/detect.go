@@ -0,0 +1,40 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"gopkg.in/yaml.v3"
+)
+
+// utf8BOM is the byte order mark some editors put at the beginning of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// DetectFileType guesses the type of a file based on its content.
+// Only structured types are detected, properties and plain files have to be passed explicitly
+func DetectFileType(content []byte) (general.FileType, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, utf8BOM))
+
+	if len(trimmed) == 0 {
+		return general.Undefined, fmt.Errorf("unable to detect the file type of empty content")
+	}
+
+	switch trimmed[0] {
+	case '<':
+		return general.XML, nil
+	case '{', '[':
+		if json.Valid(trimmed) {
+			return general.JSON, nil
+		}
+	}
+
+	// YAML is a superset of many formats, thus only accept documents having a map at the top level
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(trimmed, &doc); err == nil && len(doc) > 0 {
+		return general.YAML, nil
+	}
+
+	return general.Undefined, fmt.Errorf("unable to detect the file type from the content, please provide it explicitly")
+}
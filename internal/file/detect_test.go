@@ -0,0 +1,70 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFileType(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		content  string
+		expected general.FileType
+		wantErr  bool
+	}{
+		{
+			desc:     "XML",
+			content:  "<?xml version=\"1.0\"?>\n<root/>",
+			expected: general.XML,
+		},
+		{
+			desc:     "XML with BOM",
+			content:  "\xEF\xBB\xBF<root/>",
+			expected: general.XML,
+		},
+		{
+			desc:     "JSON object",
+			content:  "  {\"a\": \"b\"}",
+			expected: general.JSON,
+		},
+		{
+			desc:     "JSON list",
+			content:  "[1, 2, 3]",
+			expected: general.JSON,
+		},
+		{
+			desc:     "YAML",
+			content:  "apiVersion: v1\nkind: ConfigMap\n",
+			expected: general.YAML,
+		},
+		{
+			desc:     "YAML flow mapping",
+			content:  "{a: b}",
+			expected: general.YAML,
+		},
+		{
+			desc:    "Plain text",
+			content: "just some text",
+			wantErr: true,
+		},
+		{
+			desc:    "Empty",
+			content: "  \n",
+			wantErr: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			fileType, err := DetectFileType([]byte(tC.content))
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, fileType)
+		})
+	}
+}
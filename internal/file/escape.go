@@ -0,0 +1,69 @@
+package file
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/denglertai/gonfig/internal/filter"
+)
+
+// EscapeAuto escapes the results of placeholders for the context of the entry they are part of
+const EscapeAuto = "auto"
+
+// EscapingConfigEntry is implemented by entries whose values aren't fully escaped by the handler while writing
+type EscapingConfigEntry interface {
+	// EscapeContexts returns the names of the escape filters required by the context the value is written to
+	EscapeContexts() []string
+}
+
+// validateEscape checks if escape is either empty, auto or the name of an escape filter
+func validateEscape(escape string) error {
+	if escape == "" || escape == EscapeAuto || filter.IsEscapeContext(escape) {
+		return nil
+	}
+
+	return fmt.Errorf("unknown escaping mode %s, use %s or the name of an escape filter", escape, EscapeAuto)
+}
+
+// escapeContexts returns the contexts the results of the placeholders of the entry are escaped for, innermost first.
+// In auto mode, the entry declares its contexts. Entries which don't, e.g. comments, are escaped for the documents embedded into them
+func escapeContexts(entry ConfigEntry, template string, escape string) []string {
+	switch escape {
+	case "":
+		return nil
+	case EscapeAuto:
+		if escaping, ok := entry.(EscapingConfigEntry); ok {
+			return escaping.EscapeContexts()
+		}
+		return embeddedContexts(entry.Key(), template)
+	default:
+		return []string{escape}
+	}
+}
+
+// embeddedContexts returns the contexts of a document embedded into a value, e.g. a properties file stored in a YAML string.
+// The document is identified by the file extension of the key, e.g. application.properties of a ConfigMap, or by the value
+// looking like a JSON object or array
+func embeddedContexts(key string, template string) []string {
+	switch strings.ToLower(path.Ext(key)) {
+	case ".json":
+		return []string{filter.JsonEscape}
+	case ".properties":
+		return []string{filter.PropertiesEscape}
+	}
+
+	if isEmbeddedJSON(template) {
+		return []string{filter.JsonEscape}
+	}
+
+	return nil
+}
+
+// isEmbeddedJSON checks if the template looks like a JSON object or array
+func isEmbeddedJSON(template string) bool {
+	trimmed := strings.TrimSpace(template)
+
+	return (strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}")) ||
+		(strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"))
+}
@@ -0,0 +1,107 @@
+package file
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeModes(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		content  string
+		fileType general.FileType
+		escape   string
+		expected string
+		wantErr  bool
+	}{
+		{
+			desc:     "No escaping",
+			content:  "config: '{\"user\": \"${USER_NAME}\"}'\n",
+			fileType: general.YAML,
+			expected: "config: '{\"user\": \"o''Neil \"the\" admin\"}'\n",
+		},
+		{
+			desc:     "Auto escapes embedded JSON",
+			content:  "config: '{\"user\": \"${USER_NAME}\"}'\nname: ${USER_NAME}\n",
+			fileType: general.YAML,
+			escape:   EscapeAuto,
+			expected: "config: '{\"user\": \"o''Neil \\\"the\\\" admin\"}'\nname: o'Neil \"the\" admin\n",
+		},
+		{
+			desc:     "Auto escapes embedded properties files",
+			content:  "data:\n  application.properties: |\n    password=${DB_PASSWORD}\n  password: ${DB_PASSWORD}\n",
+			fileType: general.YAML,
+			escape:   EscapeAuto,
+			expected: "data:\n  application.properties: |\n    password=s3cr\\=t\\\\x\n  password: s3cr=t\\x\n",
+		},
+		{
+			desc:     "Auto escapes JSON embedded into JSON",
+			content:  `{"config": "{\"user\": \"${USER_NAME}\"}", "name": "${USER_NAME}"}`,
+			fileType: general.JSON,
+			escape:   EscapeAuto,
+			expected: "{\n  \"config\": \"{\\\"user\\\": \\\"o'Neil \\\\\\\"the\\\\\\\" admin\\\"}\",\n  \"name\": \"o'Neil \\\"the\\\" admin\"\n}",
+		},
+		{
+			desc:     "Auto escapes JSON embedded into properties",
+			content:  "config={\"user\": \"${USER_NAME}\"}\nname=${USER_NAME}\n",
+			fileType: general.PROPERTIES,
+			escape:   EscapeAuto,
+			expected: "config={\"user\": \"o'Neil \\\\\"the\\\\\" admin\"}\nname=o'Neil \"the\" admin\n",
+		},
+		{
+			desc:     "Auto escapes JSON embedded into XML attributes",
+			content:  `<app config='{"user": "${USER_NAME}"}' name="${USER_NAME}"/>`,
+			fileType: general.XML,
+			escape:   EscapeAuto,
+			expected: "<app config=\"{&quot;user&quot;: &quot;o&apos;Neil \\&quot;the\\&quot; admin&quot;}\" name=\"o&apos;Neil &quot;the&quot; admin\"/>",
+		},
+		{
+			desc:     "Auto escapes plain JSON lines only",
+			content:  "{\"user\": \"${USER_NAME}\"}\nuser=${USER_NAME}\n",
+			fileType: general.PLAIN,
+			escape:   EscapeAuto,
+			expected: "{\"user\": \"o'Neil \\\"the\\\" admin\"}\nuser=o'Neil \"the\" admin\n",
+		},
+		{
+			desc:     "Filter",
+			content:  "user=${USER_NAME}",
+			fileType: general.PLAIN,
+			escape:   "url_encode",
+			expected: "user=o%27Neil%20%22the%22%20admin",
+		},
+		{
+			desc:     "Unknown",
+			content:  "user=${USER_NAME}\n",
+			fileType: general.PLAIN,
+			escape:   "unknown",
+			wantErr:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("USER_NAME", `o'Neil "the" admin`)
+			t.Setenv("DB_PASSWORD", `s3cr=t\x`)
+
+			input := path.Join(t.TempDir(), "input."+string(tC.fileType))
+			assert.NoError(t, os.WriteFile(input, []byte(tC.content), 0644))
+
+			output := new(bytes.Buffer)
+			processor := NewFileProcessor(input, tC.fileType, output)
+			processor.Escape = tC.escape
+
+			err := processor.Process()
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, output.String())
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package file
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// handleMap recursively appends the entries of a decoded map
+func (h *hierarchicalConfigHandler) handleMap(container map[string]interface{}, path string, hierarchy []string) error {
+	for key, value := range container {
+		currentPath := appendToPath(path, key)
+		copiedHierarchy := append(make([]string, 0), hierarchy...)
+		currentHierarchy := append(copiedHierarchy, key)
+		if h.keys {
+			// Append currenty key as entry as well
+			h.appendKey(currentPath, key, currentHierarchy, key)
+		}
+		err := h.handleValue(currentPath, key, currentHierarchy, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleValue appends the given value as entry or descends into it if it's a map or a list
+func (h *hierarchicalConfigHandler) handleValue(path string, key string, hierarchy []string, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		// There is nothing to be processed for empty values
+	case int, int64, float64, string, bool, time.Time, toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		h.appendEntry(path, key, hierarchy, v)
+	case map[interface{}]interface{}:
+		// Convert the map to a map[string]interface{}
+		m := make(map[string]interface{})
+		for k, v := range v {
+			m[fmt.Sprintf("%v", k)] = v
+		}
+		// Deeper down the rabbit hole
+		err := h.handleMap(m, path, hierarchy)
+		if err != nil {
+			return err
+		}
+	case map[string]interface{}:
+		// Deeper down the rabbit hole
+		err := h.handleMap(v, path, hierarchy)
+		if err != nil {
+			return err
+		}
+	case []interface{}:
+		for i, item := range v {
+			is := strconv.Itoa(i)
+			currentPath := appendToPath(path, is)
+			copiedHierarchy := append(make([]string, 0), hierarchy...)
+			currentHierarchy := append(copiedHierarchy, is)
+			err := h.handleValue(currentPath, is, currentHierarchy, item)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+	return nil
+}
+
+// SetKeys controls whether the keys of maps are returned as entries as well
+func (h *hierarchicalConfigHandler) SetKeys(enabled bool) {
+	h.keys = enabled
+}
+
+// applyKeys renames the keys whose entries have been edited.
+// Deeper nested keys are renamed first, so the hierarchies of their parents remain valid
+func (h *hierarchicalConfigHandler) applyKeys(container interface{}) error {
+	keys := make([]*HierarchicalConfigKey, 0)
+	for _, entry := range h.entries {
+		if hck, isHck := entry.(*HierarchicalConfigKey); isHck && hck.isEdited() {
+			keys = append(keys, hck)
+		}
+	}
+
+	slices.SortStableFunc(keys, func(i, j *HierarchicalConfigKey) int {
+		return len(j.hierarchy) - len(i.hierarchy)
+	})
+
+	for _, hck := range keys {
+		if err := moveKey(container, hck.hierarchy, hck.value); err != nil {
+			return err
+		}
+		hck.edited = false
+	}
+
+	return nil
+}
+
+// moveKey renames the key at the end of the hierarchy. Renaming a key to one which already exists is a conflict
+func moveKey(location interface{}, hierarchy []string, newKey string) error {
+	// If the hierarchy or the newKey is empty we cannot continue
+	if len(hierarchy) == 0 || len(newKey) == 0 {
+		return nil
+	}
+
+	switch l := location.(type) {
+	case map[string]interface{}:
+		if len(hierarchy) > 1 {
+			return moveKey(l[hierarchy[0]], hierarchy[1:], newKey)
+		}
+
+		oldKey := hierarchy[0]
+		if oldKey == newKey {
+			return nil
+		}
+		if _, exists := l[newKey]; exists {
+			return fmt.Errorf("%w: %s renders to %s, which already exists", ErrKeyConflict, oldKey, newKey)
+		}
+
+		l[newKey] = l[oldKey]
+		delete(l, oldKey)
+	case []interface{}:
+		// Lists don't have keys, only their items may contain maps
+		if len(hierarchy) == 1 {
+			return nil
+		}
+
+		index, err := strconv.Atoi(hierarchy[0])
+		if err != nil {
+			return err
+		}
+		if index < 0 || index >= len(l) {
+			return fmt.Errorf("index %d out of range", index)
+		}
+
+		return moveKey(l[index], hierarchy[1:], newKey)
+	case nil:
+		return fmt.Errorf("nil hierarchy")
+	default:
+		return fmt.Errorf("unsupported type: %T", l)
+	}
+
+	return nil
+}
@@ -5,9 +5,10 @@ import (
 	"io"
 	"iter"
 	"strconv"
+	"time"
 
 	"github.com/Jeffail/gabs/v2"
-	"github.com/samber/lo"
+	"github.com/pelletier/go-toml/v2"
 )
 
 // hierachicalConfigBase represents a base configuration entry
@@ -48,6 +49,12 @@ func (j *HierarchicalConfigEntry) SetValue(value string) {
 	j.value = value
 }
 
+// EscapeContexts returns the contexts the value has to be escaped for. Strings are escaped by the encoders of JSON, YAML and TOML,
+// only documents embedded into them are escaped
+func (j *HierarchicalConfigEntry) EscapeContexts() []string {
+	return embeddedContexts(j.key, j.value)
+}
+
 func (j *HierarchicalConfigEntry) getConvertedValue() (interface{}, error) {
 	// Convert the value to the original type and return it
 	switch j.originalValue.(type) {
@@ -59,6 +66,22 @@ func (j *HierarchicalConfigEntry) getConvertedValue() (interface{}, error) {
 		return j.value, nil
 	case bool:
 		return strconv.ParseBool(j.value)
+	case int64:
+		return strconv.ParseInt(j.value, 10, 64)
+	case time.Time:
+		return time.Parse(time.RFC3339Nano, j.value)
+	case toml.LocalDate:
+		var d toml.LocalDate
+		err := d.UnmarshalText([]byte(j.value))
+		return d, err
+	case toml.LocalTime:
+		var t toml.LocalTime
+		err := t.UnmarshalText([]byte(j.value))
+		return t, err
+	case toml.LocalDateTime:
+		var dt toml.LocalDateTime
+		err := dt.UnmarshalText([]byte(j.value))
+		return dt, err
 	}
 
 	return nil, fmt.Errorf("unsupported type: %T", j.originalValue)
@@ -69,6 +92,11 @@ func (j *HierarchicalConfigEntry) isEdited() bool {
 	return j.edited
 }
 
+// treeLocation returns the keys leading to the value within the tree of the file
+func (j *HierarchicalConfigEntry) treeLocation() []string {
+	return j.hierarchy
+}
+
 // HierarchicalConfigKey represents a single configuration entry's key
 type HierarchicalConfigKey struct {
 	hierachicalConfigBase
@@ -106,9 +134,14 @@ func (j *HierarchicalConfigKey) isEdited() bool {
 	return j.edited
 }
 
+// isKey marks the entry as key entry
+func (j *HierarchicalConfigKey) isKey() {}
+
 // hierarchicalConfigHandler represents a basic configuration handler for hierarchical configuration files
 type hierarchicalConfigHandler struct {
 	entries []ConfigEntry
+	// keys controls whether the keys of maps are added as entries as well
+	keys bool
 }
 
 // JsonConfigFileHandler represents a configuration file handler
@@ -153,7 +186,12 @@ func (j *JsonConfigFileHandler) handleChildren(container *gabs.Container, path s
 	if len(childrenMap) > 0 {
 		for key, value := range childrenMap {
 			currentPath := appendToPath(path, key)
-			currentHierarchy := append(hierarchy, key)
+			// Copy the hierarchy so siblings don't share the same backing array
+			copiedHierarchy := append(make([]string, 0), hierarchy...)
+			currentHierarchy := append(copiedHierarchy, key)
+			if j.keys {
+				j.appendKey(currentPath, key, currentHierarchy, key)
+			}
 			data := value.Data()
 			switch v := data.(type) {
 			case int:
@@ -180,8 +218,10 @@ func (j *JsonConfigFileHandler) handleChildren(container *gabs.Container, path s
 	children := container.Children()
 	for i, child := range children {
 		currentPath := appendToPath(path, fmt.Sprint(i))
-		currentHierarchy := append(hierarchy, fmt.Sprint(i))
+		copiedHierarchy := append(make([]string, 0), hierarchy...)
+		currentHierarchy := append(copiedHierarchy, fmt.Sprint(i))
 		if err := j.handleChildren(child, currentPath, currentHierarchy); err != nil {
+			return err
 		}
 	}
 
@@ -199,13 +239,22 @@ func (j *hierarchicalConfigHandler) appendEntry(path, key string, hierarchy []st
 		path:          path,
 		key:           key,
 		originalValue: value,
-		value:         fmt.Sprintf("%v", value),
+		value:         formatValue(value),
 		hierarchy:     hierarchy,
 	}
 
 	j.entries = append(j.entries, entry)
 }
 
+// formatValue returns the string representation of a decoded value
+func formatValue(value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
 func (j *hierarchicalConfigHandler) appendKey(path, key string, hierarchy []string, value interface{}) {
 	entry := &HierarchicalConfigKey{
 		path:          path,
@@ -225,26 +274,59 @@ func appendToPath(path string, key string) string {
 	return path + "." + key
 }
 
-// Write writes the configuration entries to the target
-func (j *JsonConfigFileHandler) Write(target io.Writer) error {
-	filteredEntries := lo.FilterMap(j.entries, func(entry ConfigEntry, _ int) (*HierarchicalConfigEntry, bool) {
-		jce := entry.(*HierarchicalConfigEntry)
-		return jce, jce.edited
-	})
+// apply writes the edited entries back to the container
+func (j *JsonConfigFileHandler) apply() error {
+	for _, entry := range j.entries {
+		jce, isJce := entry.(*HierarchicalConfigEntry)
+		if !isJce || !jce.isEdited() {
+			continue
+		}
 
-	for _, entry := range filteredEntries {
-		val, err := entry.getConvertedValue()
+		val, err := jce.getConvertedValue()
 		if err != nil {
 			return err
 		}
 
-		_, err = j.container.Set(val, entry.hierarchy...)
+		_, err = j.container.Set(val, jce.hierarchy...)
 		if err != nil {
 			return err
 		}
+		jce.edited = false
+	}
+
+	return j.applyKeys(j.container.Data())
+}
+
+// Write writes the configuration entries to the target
+func (j *JsonConfigFileHandler) Write(target io.Writer) error {
+	if err := j.apply(); err != nil {
+		return err
 	}
 
 	data := j.container.EncodeJSON(gabs.EncodeOptIndent("", "  "))
 	_, err := target.Write(data)
 	return err
 }
+
+// Tree returns the content as tree including all changes made to the entries
+func (j *JsonConfigFileHandler) Tree() (interface{}, error) {
+	if err := j.apply(); err != nil {
+		return nil, err
+	}
+
+	return normalizeTree(j.container.Data(), true), nil
+}
+
+// SetTree replaces the content with the given tree and recreates the entries
+func (j *JsonConfigFileHandler) SetTree(tree interface{}) error {
+	switch tree.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return fmt.Errorf("json documents must have a map or a list at the top level, got %T", tree)
+	}
+
+	j.container = gabs.Wrap(tree)
+	j.entries = make([]ConfigEntry, 0)
+
+	return j.handleChildren(j.container, "", []string{})
+}
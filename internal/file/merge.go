@@ -0,0 +1,294 @@
+package file
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/denglertai/gonfig/pkg/logging"
+)
+
+// ListStrategy controls how lists are merged
+type ListStrategy string
+
+const (
+	// ListReplace replaces the list of the base with the list of the overlay
+	ListReplace ListStrategy = "replace"
+	// ListAppend appends the items of the overlay to the list of the base
+	ListAppend ListStrategy = "append"
+	// ListMergeByKey merges maps having the same value for the merge key and appends all other items
+	ListMergeByKey ListStrategy = "merge"
+)
+
+// DefaultDeleteMarker is the value marking an entry of the base to be deleted
+const DefaultDeleteMarker = "$delete"
+
+// MergeOptions represents the options used for merging trees
+type MergeOptions struct {
+	// ListStrategy controls how lists are merged
+	ListStrategy ListStrategy
+	// MergeKey is the key identifying maps within lists if ListStrategy is ListMergeByKey
+	MergeKey string
+	// DeleteMarker is the value marking an entry of the base to be deleted.
+	// Within lists merged by key, an item containing the marker as key deletes the matching item of the base
+	DeleteMarker string
+}
+
+// NewMergeOptions creates new merge options using the defaults
+func NewMergeOptions() MergeOptions {
+	return MergeOptions{
+		ListStrategy: ListReplace,
+		MergeKey:     "name",
+		DeleteMarker: DefaultDeleteMarker,
+	}
+}
+
+// ParseListStrategy returns the list strategy with the given name
+func ParseListStrategy(name string) (ListStrategy, error) {
+	strategy := ListStrategy(name)
+	switch strategy {
+	case ListReplace, ListAppend, ListMergeByKey:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unsupported list strategy: %s", name)
+	}
+}
+
+// Merge merges the overlays into the file in the given order, processes the values of the result and writes it to the output
+func (fp *FileProcessor) Merge(overlays []string, options MergeOptions) error {
+	handler, _, err := fp.load()
+	if err != nil {
+		return err
+	}
+
+	base, ok := handler.(TreeConfigFileHandler)
+	if !ok {
+		return fmt.Errorf("merging %v files is not supported", fp.FileType)
+	}
+
+	merged, err := base.Tree()
+	if err != nil {
+		return err
+	}
+
+	for _, overlay := range overlays {
+		logging.Debug("Merging overlay", "file", fp.FileName, "overlay", overlay)
+
+		overlayProcessor := fp.newOverlayProcessor(overlay)
+		overlayHandler, _, err := overlayProcessor.load()
+		if err != nil {
+			return err
+		}
+
+		source, ok := overlayHandler.(TreeConfigFileHandler)
+		if !ok {
+			return fmt.Errorf("merging %v files is not supported", overlayProcessor.FileType)
+		}
+
+		tree, err := source.Tree()
+		if err != nil {
+			return err
+		}
+
+		merged, err = mergeTrees(merged, tree, options)
+		if err != nil {
+			return fmt.Errorf("failed to merge %s: %w", overlay, err)
+		}
+	}
+
+	if err := base.SetTree(merged); err != nil {
+		return err
+	}
+
+	entries, err := base.Process()
+	if err != nil {
+		return err
+	}
+
+	origins, err := processEntries(entries, slog.Group("file", "name", fp.FileName, "type", fp.FileType), fp.FileName, fp.Escape, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fp.validate(base, origins); err != nil {
+		return err
+	}
+
+	return fp.write(base)
+}
+
+// newOverlayProcessor creates the processor reading the given overlay. The type is inferred from the extension of the overlay,
+// overlays without extension have the same type as the file. The encoding of the file applies to properties overlays as well
+func (fp *FileProcessor) newOverlayProcessor(overlay string) *FileProcessor {
+	fileType := general.Undefined
+	if path.Ext(overlay) == "" {
+		fileType = fp.FileType
+	}
+
+	overlayProcessor := NewFileProcessor(overlay, fileType, nil)
+	if normalizeFileType(overlayProcessor.FileType) == general.PROPERTIES {
+		overlayProcessor.Encoding = fp.Encoding
+	}
+
+	return overlayProcessor
+}
+
+// mergeTrees deep-merges the overlay into the base.
+// Maps are merged recursively, lists according to the list strategy and all other values of the overlay replace the ones of the base
+func mergeTrees(base interface{}, overlay interface{}, options MergeOptions) (interface{}, error) {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return removeDeleteMarkers(o, options), nil
+		}
+
+		return mergeMaps(b, o, options)
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return removeDeleteMarkers(o, options), nil
+		}
+
+		return mergeLists(b, o, options)
+	default:
+		return overlay, nil
+	}
+}
+
+// mergeMaps merges the overlay map into a copy of the base map
+func mergeMaps(base map[string]interface{}, overlay map[string]interface{}, options MergeOptions) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, value := range overlay {
+		if isDeleteMarker(value, options) {
+			delete(result, key)
+			continue
+		}
+
+		existing, found := result[key]
+		if !found {
+			result[key] = removeDeleteMarkers(value, options)
+			continue
+		}
+
+		merged, err := mergeTrees(existing, value, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		result[key] = merged
+	}
+
+	return result, nil
+}
+
+// mergeLists merges the overlay list into the base list according to the list strategy
+func mergeLists(base []interface{}, overlay []interface{}, options MergeOptions) ([]interface{}, error) {
+	switch options.ListStrategy {
+	case ListReplace, "":
+		return removeDeleteMarkers(overlay, options).([]interface{}), nil
+	case ListAppend:
+		return append(slices.Clone(base), removeDeleteMarkers(overlay, options).([]interface{})...), nil
+	case ListMergeByKey:
+		if options.MergeKey == "" {
+			return nil, fmt.Errorf("merging lists by key requires a merge key")
+		}
+
+		result := slices.Clone(base)
+		for _, item := range overlay {
+			index := findListItem(result, item, options.MergeKey)
+			if index < 0 {
+				if !isDeletedItem(item, options) {
+					result = append(result, removeDeleteMarkers(item, options))
+				}
+				continue
+			}
+
+			if isDeletedItem(item, options) {
+				result = slices.Delete(result, index, index+1)
+				continue
+			}
+
+			merged, err := mergeTrees(result[index], item, options)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", index, err)
+			}
+			result[index] = merged
+		}
+
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported list strategy: %s", options.ListStrategy)
+	}
+}
+
+// findListItem returns the index of the map within the list having the same value for the merge key as the given item or -1
+func findListItem(list []interface{}, item interface{}, mergeKey string) int {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+
+	key, ok := m[mergeKey]
+	if !ok {
+		return -1
+	}
+
+	return slices.IndexFunc(list, func(candidate interface{}) bool {
+		c, ok := candidate.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		value, ok := c[mergeKey]
+		return ok && fmt.Sprintf("%v", value) == fmt.Sprintf("%v", key)
+	})
+}
+
+// isDeleteMarker checks if the value equals the delete marker
+func isDeleteMarker(value interface{}, options MergeOptions) bool {
+	s, ok := value.(string)
+	return ok && options.DeleteMarker != "" && s == options.DeleteMarker
+}
+
+// isDeletedItem checks if the list item is a map containing the delete marker as key
+func isDeletedItem(item interface{}, options MergeOptions) bool {
+	m, ok := item.(map[string]interface{})
+	if !ok || options.DeleteMarker == "" {
+		return false
+	}
+
+	_, found := m[options.DeleteMarker]
+	return found
+}
+
+// removeDeleteMarkers removes entries marked for deletion from values which don't have a counterpart in the base
+func removeDeleteMarkers(value interface{}, options MergeOptions) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if isDeleteMarker(item, options) {
+				continue
+			}
+			result[key] = removeDeleteMarkers(item, options)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if isDeleteMarker(item, options) || isDeletedItem(item, options) {
+				continue
+			}
+			result = append(result, removeDeleteMarkers(item, options))
+		}
+		return result
+	default:
+		return value
+	}
+}
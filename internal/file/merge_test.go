@@ -0,0 +1,144 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTrees(t *testing.T) {
+	base := map[string]interface{}{
+		"name": "shop",
+		"app": map[string]interface{}{
+			"replicas": int64(1),
+			"debug":    true,
+		},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "web", "port": int64(8080)},
+			map[string]interface{}{"name": "worker", "port": int64(9090)},
+		},
+	}
+
+	testCases := []struct {
+		desc     string
+		overlay  map[string]interface{}
+		strategy ListStrategy
+		mergeKey string
+		expected map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			desc: "Maps are merged recursively",
+			overlay: map[string]interface{}{
+				"app": map[string]interface{}{"replicas": int64(3), "region": "eu"},
+			},
+			strategy: ListReplace,
+			expected: map[string]interface{}{
+				"name": "shop",
+				"app": map[string]interface{}{
+					"replicas": int64(3),
+					"debug":    true,
+					"region":   "eu",
+				},
+				"containers": base["containers"],
+			},
+		},
+		{
+			desc: "Delete marker removes entries",
+			overlay: map[string]interface{}{
+				"name": "$delete",
+				"app":  map[string]interface{}{"debug": "$delete", "extra": map[string]interface{}{"a": "$delete", "b": "c"}},
+			},
+			strategy: ListReplace,
+			expected: map[string]interface{}{
+				"app": map[string]interface{}{
+					"replicas": int64(1),
+					"extra":    map[string]interface{}{"b": "c"},
+				},
+				"containers": base["containers"],
+			},
+		},
+		{
+			desc: "Lists are replaced",
+			overlay: map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "api"}},
+			},
+			strategy: ListReplace,
+			expected: map[string]interface{}{
+				"name":       "shop",
+				"app":        base["app"],
+				"containers": []interface{}{map[string]interface{}{"name": "api"}},
+			},
+		},
+		{
+			desc: "Lists are appended",
+			overlay: map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "api"}},
+			},
+			strategy: ListAppend,
+			expected: map[string]interface{}{
+				"name": "shop",
+				"app":  base["app"],
+				"containers": []interface{}{
+					map[string]interface{}{"name": "web", "port": int64(8080)},
+					map[string]interface{}{"name": "worker", "port": int64(9090)},
+					map[string]interface{}{"name": "api"},
+				},
+			},
+		},
+		{
+			desc: "Lists are merged by key",
+			overlay: map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "web", "port": int64(80)},
+					map[string]interface{}{"name": "worker", "$delete": true},
+					map[string]interface{}{"name": "api"},
+					map[string]interface{}{"name": "unknown", "$delete": true},
+				},
+			},
+			strategy: ListMergeByKey,
+			mergeKey: "name",
+			expected: map[string]interface{}{
+				"name": "shop",
+				"app":  base["app"],
+				"containers": []interface{}{
+					map[string]interface{}{"name": "web", "port": int64(80)},
+					map[string]interface{}{"name": "api"},
+				},
+			},
+		},
+		{
+			desc: "Merging by key requires a key",
+			overlay: map[string]interface{}{
+				"containers": []interface{}{},
+			},
+			strategy: ListMergeByKey,
+			wantErr:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			options := NewMergeOptions()
+			options.ListStrategy = tC.strategy
+			options.MergeKey = tC.mergeKey
+
+			result, err := mergeTrees(base, tC.overlay, options)
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestParseListStrategy(t *testing.T) {
+	strategy, err := ParseListStrategy("append")
+	assert.NoError(t, err)
+	assert.Equal(t, ListAppend, strategy)
+
+	_, err = ParseListStrategy("zip")
+	assert.Error(t, err)
+}
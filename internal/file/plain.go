@@ -5,17 +5,25 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"strings"
 )
 
 // PlainFileProcessor processes a file in plain text format without any specific structure or format.
+// The content is written exactly as read, only changed lines are replaced.
 type PlainFileProcessor struct {
 	lines []*plainFileLine
+	// wholeFile controls whether the whole file is a single entry rather than an entry per line
+	wholeFile bool
 }
 
 // plainFileLine represents a single line in a plain text file, treated as a configuration entry with the line content as the key and value.
+// If the whole file is processed as a single entry, it represents the whole content.
 type plainFileLine struct {
-	value      string
-	eol        string
+	value string
+	// eol is the line ending of the line, it is empty for the last line of a file without a trailing newline
+	eol string
+	// newline is the line ending used for line breaks within the results of placeholders inserted into a line without line ending
+	newline    string
 	lineNumber uint32
 }
 
@@ -39,26 +47,77 @@ func (p *plainFileLine) SetValue(value string) {
 	p.value = value
 }
 
+// EscapeContexts returns the contexts the value has to be escaped for. Plain files are written as is and their format is unknown,
+// so only JSON documents are escaped. Other formats require the escape filter to be given, e.g. --escape shell_quote
+func (p *plainFileLine) EscapeContexts() []string {
+	return embeddedContexts("", p.value)
+}
+
+// lineEnding returns the line ending used for line breaks within the results of placeholders. Results inserted into a line
+// of a whole file use the line ending of that line, so the line endings of a file with mixed line endings are kept
+func (p *plainFileLine) lineEnding() string {
+	return p.newline
+}
+
+// render returns the line including its line ending
+func (p *plainFileLine) render() string {
+	return p.value + p.eol
+}
+
 func NewPlainFileProcessor() *PlainFileProcessor {
 	return &PlainFileProcessor{}
 }
 
+// SetWholeFile controls whether the whole file is processed as a single entry, so placeholders may span several lines
+func (p *PlainFileProcessor) SetWholeFile(enabled bool) {
+	p.wholeFile = enabled
+}
+
 // Read reads the content of the plain text file and stores it in the processor, treating each line as a separate entry with the line content as the key and value.
+// Lines may end with \n or \r\n, the last line may have no line ending at all
 func (p *PlainFileProcessor) Read(source io.Reader) error {
 	buf := new(bytes.Buffer)
 	_, err := buf.ReadFrom(source)
 	if err != nil {
 		return err
 	}
-	// Split the content into lines and create a plainFileLine for each line with the line content as the value and the end of line character as the eol
-	lines := bytes.Split(buf.Bytes(), []byte{'\n'})
-	p.lines = make([]*plainFileLine, len(lines))
-	for i, line := range lines {
-		p.lines[i] = &plainFileLine{
-			value:      string(line),
-			eol:        "\n",
-			lineNumber: uint32(i),
+	content := buf.String()
+
+	// Line breaks within changed values use the first line ending of the file
+	newline := "\n"
+	if i := strings.IndexByte(content, '\n'); i > 0 && content[i-1] == '\r' {
+		newline = "\r\n"
+	}
+
+	p.lines = make([]*plainFileLine, 0)
+	if p.wholeFile {
+		p.lines = append(p.lines, &plainFileLine{
+			value:   content,
+			newline: newline,
+		})
+		return nil
+	}
+
+	for len(content) > 0 {
+		line, eol := content, ""
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			line, eol = content[:i], "\n"
+			if strings.HasSuffix(line, "\r") {
+				line, eol = line[:len(line)-1], "\r\n"
+			}
+		}
+		content = content[len(line)+len(eol):]
+
+		lineNewline := eol
+		if lineNewline == "" {
+			lineNewline = newline
 		}
+		p.lines = append(p.lines, &plainFileLine{
+			value:      line,
+			eol:        eol,
+			newline:    lineNewline,
+			lineNumber: uint32(len(p.lines)),
+		})
 	}
 	return nil
 }
@@ -78,7 +137,7 @@ func (p *PlainFileProcessor) Process() (iter.Seq[ConfigEntry], error) {
 // Write writes the configuration entries back to the target, preserving the original line content and end of line characters.
 func (p *PlainFileProcessor) Write(target io.Writer) error {
 	for _, line := range p.lines {
-		_, err := target.Write([]byte(line.value + line.eol))
+		_, err := target.Write([]byte(line.render()))
 		if err != nil {
 			return err
 		}
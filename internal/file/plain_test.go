@@ -6,6 +6,7 @@ import (
 	"path"
 	"testing"
 
+	"github.com/denglertai/gonfig/internal/general"
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/stretchr/testify/assert"
 )
@@ -42,3 +43,116 @@ func TestPlainFileHandler(t *testing.T) {
 
 	snaps.MatchSnapshot(t, output.String())
 }
+
+func TestPlainLineEndings(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		content   string
+		wholeFile bool
+		cert      string
+		expected  string
+	}{
+		{
+			desc:     "Trailing newline",
+			content:  "echo ${NAME}\nexit 0\n",
+			expected: "echo world\nexit 0\n",
+		},
+		{
+			desc:     "No trailing newline",
+			content:  "echo ${NAME}\nexit 0",
+			expected: "echo world\nexit 0",
+		},
+		{
+			desc:     "CRLF",
+			content:  "@echo off\r\necho ${NAME}\r\n",
+			expected: "@echo off\r\necho world\r\n",
+		},
+		{
+			desc:     "Mixed line endings",
+			content:  "a\r\nb\n${NAME}\r\n",
+			expected: "a\r\nb\nworld\r\n",
+		},
+		{
+			desc:     "Multi-line value",
+			content:  "-----BEGIN-----\r\n${CERT}\r\n-----END-----\r\n",
+			expected: "-----BEGIN-----\r\nline1\r\nline2\r\n-----END-----\r\n",
+		},
+		{
+			desc:      "Whole file",
+			content:   "-----BEGIN-----\r\n${CERT}\r\necho ${NAME}",
+			wholeFile: true,
+			expected:  "-----BEGIN-----\r\nline1\r\nline2\r\necho world",
+		},
+		{
+			desc:      "Whole file with mixed line endings",
+			content:   "a\n${CERT}\r\nb\n${CERT}\necho ${NAME}",
+			wholeFile: true,
+			expected:  "a\nline1\r\nline2\r\nb\nline1\nline2\necho world",
+		},
+		{
+			desc:      "Whole file with LF line endings",
+			content:   "a\r\nb\n${CERT}\n",
+			wholeFile: true,
+			expected:  "a\r\nb\nline1\nline2\n",
+		},
+		{
+			desc:     "CRLF value in LF file",
+			content:  "-----BEGIN-----\n${CERT}\n-----END-----\n",
+			cert:     "line1\r\nline2",
+			expected: "-----BEGIN-----\nline1\nline2\n-----END-----\n",
+		},
+		{
+			desc:      "Whole file with CRLF value",
+			content:   "a\n${CERT}\r\nb\n${CERT}\n",
+			wholeFile: true,
+			cert:      "line1\r\nline2",
+			expected:  "a\nline1\r\nline2\r\nb\nline1\nline2\n",
+		},
+		{
+			desc:      "Empty file",
+			content:   "",
+			wholeFile: true,
+			expected:  "",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			cert := tC.cert
+			if cert == "" {
+				cert = "line1\nline2"
+			}
+			t.Setenv("NAME", "world")
+			t.Setenv("CERT", cert)
+
+			input := path.Join(t.TempDir(), "input.txt")
+			assert.NoError(t, os.WriteFile(input, []byte(tC.content), 0644))
+
+			output := new(bytes.Buffer)
+			processor := NewFileProcessor(input, general.PLAIN, output)
+			processor.WholeFile = tC.wholeFile
+			assert.NoError(t, processor.Process())
+
+			assert.Equal(t, tC.expected, output.String())
+		})
+	}
+}
+
+func TestPlainWholeFileUnsupported(t *testing.T) {
+	processor := NewFileProcessor("testdata/yaml/key.yaml", general.Undefined, new(bytes.Buffer))
+	processor.WholeFile = true
+	assert.ErrorContains(t, processor.Process(), "processing yaml files as a whole is not supported")
+}
+
+func TestPlainBinaryValue(t *testing.T) {
+	t.Setenv("KEYSTORE_B64", "/+7dzAANCg==")
+
+	input := path.Join(t.TempDir(), "keystore.p12")
+	assert.NoError(t, os.WriteFile(input, []byte("${KEYSTORE_B64|base64_decode}"), 0644))
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor(input, general.PLAIN, output)
+	processor.WholeFile = true
+	assert.NoError(t, processor.Process())
+
+	assert.Equal(t, []byte{0xff, 0xee, 0xdd, 0xcc, 0x00, '\r', '\n'}, output.Bytes())
+}
@@ -1,13 +1,18 @@
 package file
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"log/slog"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
+	"github.com/denglertai/gonfig/internal/filter"
 	"github.com/denglertai/gonfig/internal/general"
 	"github.com/denglertai/gonfig/internal/value"
 	"github.com/denglertai/gonfig/pkg/logging"
@@ -25,6 +30,29 @@ type ConfigEntry interface {
 	SetValue(value string)
 }
 
+// KeyConfigEntry is implemented by entries representing the key of another entry rather than a value.
+// Setting their value renames the key
+type KeyConfigEntry interface {
+	ConfigEntry
+	// isKey marks the entry as key entry
+	isKey()
+}
+
+// lineEndingConfigEntry is implemented by entries of line based files. Line breaks within the results of placeholders are
+// converted to the line ending of the line they are inserted into, or to the returned line ending if that line has none
+type lineEndingConfigEntry interface {
+	ConfigEntry
+	// lineEnding returns the line ending used for lines without line ending
+	lineEnding() string
+}
+
+// treeConfigEntry is implemented by entries of files which can be represented as tree, e.g. for validating them against a JSON Schema
+type treeConfigEntry interface {
+	ConfigEntry
+	// treeLocation returns the keys leading to the value within the tree, list items are addressed by their index
+	treeLocation() []string
+}
+
 // ConfigFileHandler represents a configuration file handler
 type ConfigFileHandler interface {
 	// Read reads the configuration file
@@ -35,24 +63,68 @@ type ConfigFileHandler interface {
 	Write(target io.Writer) error
 }
 
+// KeyConfigFileHandler is implemented by handlers which are able to return the keys of their entries as entries as well
+type KeyConfigFileHandler interface {
+	ConfigFileHandler
+	// SetKeys controls whether the keys are returned as entries as well
+	SetKeys(enabled bool)
+}
+
+// ErrEntryNotFound is returned if no entry matches the requested path
+var ErrEntryNotFound = errors.New("entry not found")
+
+// ErrKeyConflict is returned if a processed key renders to a name which is already in use
+var ErrKeyConflict = errors.New("conflicting keys")
+
+// Stdin is the file name used for reading from stdin
+const Stdin = "-"
+
 // FileProcessor represents a file processor
 type FileProcessor struct {
 	// FileName represents the name of the file to be processed
 	FileName string
 	// FileType represents the type of the file to be processed
 	FileType general.FileType
+	// OutputType represents the type the output is converted to. If not set, the output has the same type as the file
+	OutputType general.FileType
 	// Output represents the output writer
 	Output io.Writer
+	// Schema is used to validate the processed document before it is written, if set
+	Schema *Schema
+	// CoerceTypes controls whether values produced by placeholders are converted to the types required by the schema,
+	// e.g. "3" to 3 for an integer, rather than being reported as violations
+	CoerceTypes bool
+	// Xsd is used to validate XML files before they are written, if set
+	Xsd *XsdSchema
+	// XsdCatalog is used to resolve the schema referenced by XML files, if set
+	XsdCatalog *XsdCatalog
+	// Escape controls how the results of placeholders are escaped. It is either empty for no escaping,
+	// EscapeAuto for escaping according to the context of the entries or the name of an escape filter applied to all results
+	Escape string
+	// Encoding is the encoding of properties files being read or written. If not set, UTF-8 is used
+	Encoding PropertiesEncoding
+	// Keys controls whether placeholders within keys, e.g. property keys or element names, are processed as well.
+	// The keys of YAML files are always processed
+	Keys bool
+	// WholeFile controls whether plain files are processed as a single entry rather than line by line,
+	// so placeholders may span several lines
+	WholeFile bool
+	// Previous is the path of an existing output of the file. Hashes within it which still verify against the processed values
+	// are kept rather than being replaced by new hashes using other salts
+	Previous string
 }
 
 // NewFileProcessor creates a new file processor
 func NewFileProcessor(fileName string, fileType general.FileType, output io.Writer) *FileProcessor {
-	if fileType == general.Undefined {
+	if fileType == general.Undefined && fileName != Stdin {
 		ext := path.Ext(fileName)
-		// strip the leading dot
-		ext = ext[1:]
-		fileType = general.FileType(ext)
-		logging.Debug("File type not provided, using the file's extension", "file", fileName, "type", fileType)
+		if len(ext) > 0 {
+			// strip the leading dot
+			fileType = general.FileType(ext[1:])
+			logging.Debug("File type not provided, using the file's extension", "file", fileName, "type", fileType)
+		} else {
+			logging.Debug("File type not provided and the file has no extension, detecting it from the content", "file", fileName)
+		}
 	}
 
 	return &FileProcessor{
@@ -66,56 +138,375 @@ func NewFileProcessor(fileName string, fileType general.FileType, output io.Writ
 func (fp *FileProcessor) Process() error {
 	fileGroup := slog.Group("file", "name", fp.FileName, "type", fp.FileType)
 
-	handler, err := fp.getFileProcessor()
+	handler, entries, err := fp.load()
 	if err != nil {
-		logging.Error("Error initializing file processor", "err", err, fileGroup)
 		return err
 	}
 
-	file, err := os.Open(fp.FileName)
+	origins, err := processEntries(entries, fileGroup, fp.FileName, fp.Escape, fp.previousValues())
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	err = handler.Read(file)
-	if err != nil {
-		logging.Error("Failed to read the file", "err", err, fileGroup)
+	if err := fp.validate(handler, origins); err != nil {
 		return err
 	}
 
-	entries, err := handler.Process()
-	if err != nil {
-		logging.Error("Failed to process the file", "err", err, fileGroup)
-		return err
+	return fp.write(handler)
+}
+
+// processEntries processes the values of the entries and replaces them with the result.
+// The results of placeholders are escaped according to escape, previous contains the values of the existing output by their path.
+// It returns the origins of the entries containing placeholders by their location within the tree of the file, see originKey
+func processEntries(entries iter.Seq[ConfigEntry], fileGroup slog.Attr, fileName string, escape string, previous map[string]string) (map[string]entryOrigin, error) {
+	if err := validateEscape(escape); err != nil {
+		return nil, err
 	}
 
+	// Filters identify the file by its absolute path, e.g. for persisting generated values
+	if fileName == Stdin {
+		fileName = ""
+	} else if abs, err := filepath.Abs(fileName); err == nil {
+		fileName = abs
+	}
+
+	origins := make(map[string]entryOrigin)
 	for entry := range entries {
 		logging.Debug("Processing entry", "entry", entry.Path(), fileGroup)
 
-		newVal, err := value.ProcessValue(entry.GetValue())
+		template := entry.GetValue()
+		// Key entries share their path with their value, only the origin of the value is of interest
+		_, isKey := entry.(KeyConfigEntry)
+
+		processed := filter.Entry{File: fileName, Path: entry.Path()}
+		if lines, ok := entry.(lineEndingConfigEntry); ok {
+			processed.Newline = lines.lineEnding()
+		}
+		if !isKey {
+			processed.Previous = previous[entry.Path()]
+		}
+		newVal, err := value.ProcessEntryValue(template, processed, escapeContexts(entry, template, escape)...)
 
 		if err != nil {
 			logging.Error("Failed to process the value", "err", err, "entry", entry.Path(), fileGroup)
-			return err
+			return nil, fmt.Errorf("failed to process %s: %w", entry.Path(), err)
+		}
+
+		if treeEntry, ok := entry.(treeConfigEntry); ok && !isKey {
+			if variables, err := value.Variables(template); err == nil && len(variables) > 0 {
+				origins[originKey(treeEntry.treeLocation())] = entryOrigin{template: template, variables: variables}
+			}
 		}
 
 		logging.Debug("Setting new value", "entry", entry.Path(), "value", newVal, fileGroup)
 		entry.SetValue(fmt.Sprintf("%v", newVal))
 	}
 
+	return origins, nil
+}
+
+// previousValues returns the values of the existing output by their path.
+// The existing output is ignored if it doesn't exist, can't be read or is of another type than the file
+func (fp *FileProcessor) previousValues() map[string]string {
+	if fp.Previous == "" || (fp.OutputType != general.Undefined && normalizeFileType(fp.OutputType) != normalizeFileType(fp.FileType)) {
+		return nil
+	}
+
+	source, err := os.Open(fp.Previous)
+	if err != nil {
+		logging.Debug("Not using the existing output", "file", fp.Previous, "err", err)
+		return nil
+	}
+	defer source.Close()
+
+	handler, err := fp.getFileProcessor()
+	if err == nil {
+		err = handler.Read(source)
+	}
+	var entries iter.Seq[ConfigEntry]
+	if err == nil {
+		entries, err = handler.Process()
+	}
+	if err != nil {
+		logging.Warn("Failed to read the existing output, hashes are not kept", "file", fp.Previous, "err", err)
+		return nil
+	}
+
+	values := make(map[string]string)
+	for entry := range entries {
+		if _, isKey := entry.(KeyConfigEntry); !isKey {
+			values[entry.Path()] = entry.GetValue()
+		}
+	}
+
+	return values
+}
+
+// validate validates the processed content of the handler against the schema, if set.
+// Values produced by placeholders are converted to the types required by the schema if CoerceTypes is set
+func (fp *FileProcessor) validate(handler ConfigFileHandler, origins map[string]entryOrigin) error {
+	if fp.Schema == nil {
+		return nil
+	}
+
+	source, ok := handler.(TreeConfigFileHandler)
+	if !ok {
+		return fmt.Errorf("validating %v files against a JSON Schema is not supported", fp.FileType)
+	}
+
+	tree, err := source.Tree()
+	if err != nil {
+		return err
+	}
+
+	logging.Debug("Validating against schema", "file", fp.FileName, "schema", fp.Schema.location)
+
+	conversions, err := fp.Schema.validate(tree, origins, fp.CoerceTypes)
+	if err != nil {
+		return err
+	}
+
+	if len(conversions) == 0 {
+		return nil
+	}
+
+	// Take over the values converted to the types required by the schema
+	for _, conversion := range conversions {
+		logging.Debug("Converting value to the type required by the schema", "entry", strings.Join(conversion.location, "."), "value", conversion.value)
+		setTreeValue(tree, conversion.location, conversion.value)
+	}
+
+	return source.SetTree(tree)
+}
+
+// Convert converts the file to the output type without processing its values
+func (fp *FileProcessor) Convert() error {
+	handler, _, err := fp.load()
+	if err != nil {
+		return err
+	}
+
+	return fp.write(handler)
+}
+
+// Get looks up the entry with the given path and returns its raw value
+func (fp *FileProcessor) Get(entryPath string) (string, error) {
+	_, entries, err := fp.load()
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := findEntry(entries, entryPath)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.GetValue(), nil
+}
+
+// Set looks up the entry with the given path, replaces its value and writes the result to the output
+func (fp *FileProcessor) Set(entryPath string, newValue string) error {
+	fileGroup := slog.Group("file", "name", fp.FileName, "type", fp.FileType)
+
+	handler, entries, err := fp.load()
+	if err != nil {
+		return err
+	}
+
+	entry, err := findEntry(entries, entryPath)
+	if err != nil {
+		return err
+	}
+
+	logging.Debug("Setting new value", "entry", entry.Path(), "value", newValue, fileGroup)
+	entry.SetValue(newValue)
+
 	return handler.Write(fp.Output)
 }
 
+// load reads the file using the matching handler and returns the handler along with its entries
+func (fp *FileProcessor) load() (ConfigFileHandler, iter.Seq[ConfigEntry], error) {
+	source, err := fp.open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer source.Close()
+
+	var content io.Reader = source
+	if fp.FileType == general.Undefined {
+		buf, err := io.ReadAll(source)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		fp.FileType, err = DetectFileType(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+		logging.Debug("Detected file type", "file", fp.FileName, "type", fp.FileType)
+
+		content = bytes.NewReader(buf)
+	}
+
+	fileGroup := slog.Group("file", "name", fp.FileName, "type", fp.FileType)
+
+	handler, err := fp.getFileProcessor()
+	if err != nil {
+		logging.Error("Error initializing file processor", "err", err, fileGroup)
+		return nil, nil, err
+	}
+
+	err = handler.Read(content)
+	if err != nil {
+		logging.Error("Failed to read the file", "err", err, fileGroup)
+		return nil, nil, err
+	}
+
+	entries, err := handler.Process()
+	if err != nil {
+		logging.Error("Failed to process the file", "err", err, fileGroup)
+		return nil, nil, err
+	}
+
+	return handler, entries, nil
+}
+
+// write writes the content of the handler to the output and converts it to the output type if needed
+func (fp *FileProcessor) write(handler ConfigFileHandler) error {
+	if fp.OutputType == general.Undefined || normalizeFileType(fp.OutputType) == normalizeFileType(fp.FileType) {
+		return handler.Write(fp.Output)
+	}
+
+	logging.Debug("Converting file", "file", fp.FileName, "from", fp.FileType, "to", fp.OutputType)
+
+	source, ok := handler.(TreeConfigFileHandler)
+	if !ok {
+		return fmt.Errorf("converting from %v is not supported", fp.FileType)
+	}
+
+	targetHandler, err := newConfigFileHandler(fp.OutputType)
+	if err != nil {
+		return err
+	}
+	fp.setEncoding(targetHandler)
+
+	target, ok := targetHandler.(TreeConfigFileHandler)
+	if !ok {
+		return fmt.Errorf("converting to %v is not supported", fp.OutputType)
+	}
+
+	tree, err := source.Tree()
+	if err != nil {
+		return err
+	}
+
+	if err := target.SetTree(tree); err != nil {
+		return err
+	}
+
+	return target.Write(fp.Output)
+}
+
+// normalizeFileType maps aliases of file types to a single type
+func normalizeFileType(fileType general.FileType) general.FileType {
+	if fileType == general.YML {
+		return general.YAML
+	}
+
+	return fileType
+}
+
+// open opens the file to be processed or returns stdin
+func (fp *FileProcessor) open() (io.ReadCloser, error) {
+	if fp.FileName != Stdin {
+		return os.Open(fp.FileName)
+	}
+
+	// Reading from stdin requires the file type to be set explicitly
+	if fp.FileType == general.Undefined {
+		return nil, fmt.Errorf("the file type has to be provided explicitly when reading from stdin")
+	}
+
+	return io.NopCloser(os.Stdin), nil
+}
+
+// findEntry returns the first value entry matching the given path.
+// Key entries share their path with the value they name and are therefore skipped.
+func findEntry(entries iter.Seq[ConfigEntry], entryPath string) (ConfigEntry, error) {
+	for entry := range entries {
+		if _, isKey := entry.(KeyConfigEntry); isKey {
+			continue
+		}
+
+		if entry.Path() == entryPath {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrEntryNotFound, entryPath)
+}
+
 // getFileProcessor returns the file processor based on the file type
 func (fp *FileProcessor) getFileProcessor() (ConfigFileHandler, error) {
-	switch fp.FileType {
+	handler, err := newConfigFileHandler(fp.FileType)
+	if err != nil {
+		return nil, err
+	}
+
+	if fp.Xsd != nil || fp.XsdCatalog != nil {
+		xmlHandler, ok := handler.(*XmlConfigFileHandler)
+		if !ok {
+			return nil, fmt.Errorf("validating %v files against an XML schema is not supported", fp.FileType)
+		}
+		xmlHandler.SetSchema(fp.Xsd, fp.XsdCatalog)
+	}
+
+	if fp.Keys {
+		keyHandler, ok := handler.(KeyConfigFileHandler)
+		if !ok {
+			return nil, fmt.Errorf("processing the keys of %v files is not supported", fp.FileType)
+		}
+		keyHandler.SetKeys(true)
+	}
+
+	if fp.WholeFile {
+		plainHandler, ok := handler.(*PlainFileProcessor)
+		if !ok {
+			return nil, fmt.Errorf("processing %v files as a whole is not supported", fp.FileType)
+		}
+		plainHandler.SetWholeFile(true)
+	}
+
+	if fp.Encoding != "" {
+		if !fp.setEncoding(handler) && normalizeFileType(fp.OutputType) != general.PROPERTIES {
+			return nil, fmt.Errorf("setting the encoding of %v files is not supported", fp.FileType)
+		}
+	}
+
+	return handler, nil
+}
+
+// setEncoding sets the encoding if the handler is a properties handler and reports whether it is
+func (fp *FileProcessor) setEncoding(handler ConfigFileHandler) bool {
+	propertiesHandler, ok := handler.(*PropertiesFileHandler)
+	if ok && fp.Encoding != "" {
+		propertiesHandler.SetEncoding(fp.Encoding)
+	}
+
+	return ok
+}
+
+// newConfigFileHandler returns the handler for the given file type
+func newConfigFileHandler(fileType general.FileType) (ConfigFileHandler, error) {
+	switch fileType {
 	case general.YAML:
 		fallthrough
 	case general.YML:
 		return NewYamlConfigFileHandler(), nil
 	case general.JSON:
 		return NewJsonConfigFileHandler(), nil
+	case general.TOML:
+		return NewTomlConfigFileHandler(), nil
 	case general.XML:
 		return NewXmlConfigFileHandler(), nil
 	case general.PROPERTIES:
@@ -123,6 +514,6 @@ func (fp *FileProcessor) getFileProcessor() (ConfigFileHandler, error) {
 	case general.PLAIN:
 		return NewPlainFileProcessor(), nil
 	default:
-		return nil, fmt.Errorf("unsupported file type: %v", fp.FileType)
+		return nil, fmt.Errorf("unsupported file type: %v", fileType)
 	}
 }
@@ -1,11 +1,15 @@
 package file
 
 import (
+	"bytes"
 	"io"
 	"os"
+	"path"
 	"testing"
 
 	"github.com/denglertai/gonfig/internal/general"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(m *testing.M) {
@@ -36,3 +40,70 @@ func TestFileProcessor_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestFileProcessorKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "json", file: "testdata/keys/tenant.json"},
+		{name: "properties", file: "testdata/keys/tenant.properties"},
+		{name: "toml", file: "testdata/keys/tenant.toml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TENANT", "acme")
+
+			output := new(bytes.Buffer)
+			processor := NewFileProcessor(tt.file, general.Undefined, output)
+			processor.Keys = true
+			assert.NoError(t, processor.Process())
+
+			assert.NotContains(t, output.String(), "${TENANT}")
+			snaps.MatchSnapshot(t, output.String())
+		})
+	}
+}
+
+func TestFileProcessorKeysDisabled(t *testing.T) {
+	t.Setenv("TENANT", "acme")
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor("testdata/keys/tenant.properties", general.Undefined, output)
+	assert.NoError(t, processor.Process())
+
+	assert.Contains(t, output.String(), "${TENANT}.datasource.url = jdbc:postgresql://db/acme")
+}
+
+func TestFileProcessorKeyConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "json", file: "testdata/keys/conflict.json"},
+		{name: "properties", file: "testdata/keys/conflict.properties"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TENANT", "acme")
+
+			processor := NewFileProcessor(tt.file, general.Undefined, new(bytes.Buffer))
+			processor.Keys = true
+			assert.ErrorIs(t, processor.Process(), ErrKeyConflict)
+		})
+	}
+}
+
+func TestFileProcessorKeysUnsupported(t *testing.T) {
+	processor := NewFileProcessor("testdata/plain/test.sh", general.PLAIN, new(bytes.Buffer))
+	processor.Keys = true
+	assert.ErrorContains(t, processor.Process(), "processing the keys of plain files is not supported")
+}
+
+func TestFileProcessorFilterError(t *testing.T) {
+	input := path.Join(t.TempDir(), "input.yaml")
+	assert.NoError(t, os.WriteFile(input, []byte("db:\n  port: ${PORT | pad(len=five)}\n"), 0644))
+
+	processor := NewFileProcessor(input, general.Undefined, new(bytes.Buffer))
+	assert.EqualError(t, processor.Process(), `failed to process db.port: filter pad: parameter len must be an integer, got "five"`)
+}
@@ -2,22 +2,95 @@ package file
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"iter"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
 
-	"github.com/magiconair/properties"
+	"golang.org/x/text/encoding/charmap"
 )
 
-// PropertiesFileHandler is a handler for properties files
+// PropertiesEncoding is the character encoding of a properties file
+type PropertiesEncoding string
+
+const (
+	// PropertiesUTF8 reads and writes properties files as UTF-8
+	PropertiesUTF8 PropertiesEncoding = "utf-8"
+	// PropertiesISO88591 reads and writes properties files as ISO-8859-1 like java.util.Properties does.
+	// Characters which can't be represented are written as Unicode escapes
+	PropertiesISO88591 PropertiesEncoding = "iso-8859-1"
+)
+
+// ParsePropertiesEncoding parses the name of a properties encoding
+func ParsePropertiesEncoding(encoding string) (PropertiesEncoding, error) {
+	switch strings.ToLower(encoding) {
+	case "utf-8", "utf8":
+		return PropertiesUTF8, nil
+	case "iso-8859-1", "iso8859-1", "latin1":
+		return PropertiesISO88591, nil
+	default:
+		return "", fmt.Errorf("unknown encoding %s, use %s or %s", encoding, PropertiesUTF8, PropertiesISO88591)
+	}
+}
+
+// PropertiesFileHandler is a handler for properties files.
+// Only the values of changed entries are rewritten, everything else is written exactly as read
 type PropertiesFileHandler struct {
-	props   *properties.Properties
-	wrapped []ConfigEntry
+	encoding PropertiesEncoding
+	lines    []*propertiesLine
+	// keys controls whether the keys are returned as entries as well
+	keys bool
+}
+
+// propertiesLine is a logical line of a properties file, which may span several physical lines
+type propertiesLine struct {
+	// raw is the text of the line as read including continuations and the line ending
+	raw string
+	// entry is the entry defined by the line or nil for comments and blank lines
+	entry *PropertiesConfigEntry
+}
+
+// render returns the text of the line including the changes made to its entry
+func (l *propertiesLine) render(encoding PropertiesEncoding) string {
+	entry := l.entry
+	if entry == nil || (entry.val == entry.original && entry.key == entry.originalKey) {
+		return l.raw
+	}
+
+	keyStart, keyEnd := entry.keyStart, entry.keyEnd
+	key := l.raw[keyStart:keyEnd]
+	if entry.key != entry.originalKey {
+		key = escapePropertiesText(entry.key, true, encoding)
+	}
+
+	if entry.val == entry.original {
+		// Keep the value as it is written if only the key has changed
+		return l.raw[:keyStart] + key + l.raw[keyEnd:]
+	}
+
+	return entry.prefix[:keyStart] + key + entry.prefix[keyEnd:] + escapePropertiesText(entry.val, false, encoding) + entry.eol
 }
 
 // PropertiesConfigEntry is a configuration entry for properties files
 type PropertiesConfigEntry struct {
 	key string
 	val string
+	// originalKey is the key as read, it is used as path and the key is only rewritten if it differs
+	originalKey string
+	// original is the value as read, the line is only rewritten if the value differs
+	original string
+	// prefix is the text of the line preceding the value, i.e. the key and the separator
+	prefix string
+	// keyStart and keyEnd are the position of the key within the prefix
+	keyStart int
+	keyEnd   int
+	// eol is the line ending of the line
+	eol string
 }
 
 // Key returns the key of the configuration entry
@@ -27,7 +100,7 @@ func (p *PropertiesConfigEntry) Key() string {
 
 // Path returns the path of the configuration entry
 func (p *PropertiesConfigEntry) Path() string {
-	return p.key
+	return p.originalKey
 }
 
 // GetValue returns the value of the configuration entry
@@ -35,66 +108,580 @@ func (p *PropertiesConfigEntry) GetValue() string {
 	return p.val
 }
 
+// SetValue sets the value of the configuration entry
 func (p *PropertiesConfigEntry) SetValue(value string) {
 	p.val = value
 }
 
+// EscapeContexts returns the contexts the value has to be escaped for. Values are escaped while writing,
+// only documents embedded into them are escaped
+func (p *PropertiesConfigEntry) EscapeContexts() []string {
+	return embeddedContexts(p.key, p.val)
+}
+
+// treeLocation returns the keys leading to the value within the tree of the file. Indices like [0] address list items
+func (p *PropertiesConfigEntry) treeLocation() []string {
+	location := splitPropertyKey(p.key)
+	for i, segment := range location {
+		if index, ok := parsePropertyIndex(segment); ok {
+			location[i] = strconv.Itoa(index)
+		}
+	}
+
+	return location
+}
+
+// PropertiesKeyConfigEntry is the key of a properties entry. Setting its value renames the property
+type PropertiesKeyConfigEntry struct {
+	entry *PropertiesConfigEntry
+}
+
+// Key returns the key of the configuration entry
+func (p *PropertiesKeyConfigEntry) Key() string {
+	return p.entry.originalKey
+}
+
+// Path returns the path of the configuration entry
+func (p *PropertiesKeyConfigEntry) Path() string {
+	return p.entry.originalKey
+}
+
+// GetValue returns the value of the configuration entry
+func (p *PropertiesKeyConfigEntry) GetValue() string {
+	return p.entry.key
+}
+
+// SetValue sets the value of the configuration entry
+func (p *PropertiesKeyConfigEntry) SetValue(value string) {
+	p.entry.key = value
+}
+
+// isKey marks the entry as key entry
+func (p *PropertiesKeyConfigEntry) isKey() {}
+
 // NewPropertiesConfigFileHandler creates a new PropertiesFileHandler
 func NewPropertiesConfigFileHandler() *PropertiesFileHandler {
-	return &PropertiesFileHandler{}
+	return &PropertiesFileHandler{
+		encoding: PropertiesUTF8,
+		lines:    make([]*propertiesLine, 0),
+	}
+}
+
+// SetEncoding sets the encoding used for reading and writing
+func (p *PropertiesFileHandler) SetEncoding(encoding PropertiesEncoding) {
+	p.encoding = encoding
+}
+
+// SetKeys controls whether the keys are returned as entries as well
+func (p *PropertiesFileHandler) SetKeys(enabled bool) {
+	p.keys = enabled
 }
 
 // Read reads the configuration file
 func (p *PropertiesFileHandler) Read(source io.Reader) (err error) {
-	ldr := properties.Loader{
-		Encoding:         properties.UTF8,
-		DisableExpansion: true,
-		IgnoreMissing:    true,
-	}
-
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(source)
 
-	props, err := ldr.LoadBytes(buf.Bytes())
+	content := buf.Bytes()
+	if p.encoding == PropertiesISO88591 {
+		content, err = charmap.ISO8859_1.NewDecoder().Bytes(content)
+		if err != nil {
+			return err
+		}
+	}
 
-	if err != nil {
+	p.lines, err = parseProperties(string(content))
+
+	return err
+}
+
+// Process processes the configuration file
+func (p *PropertiesFileHandler) Process() (iter.Seq[ConfigEntry], error) {
+	return func(yield func(ConfigEntry) bool) {
+		for _, line := range p.lines {
+			if line.entry == nil {
+				continue
+			}
+			if p.keys && !yield(&PropertiesKeyConfigEntry{entry: line.entry}) {
+				break
+			}
+			if !yield(line.entry) {
+				break
+			}
+		}
+	}, nil
+}
+
+// Write writes the configuration file
+func (p *PropertiesFileHandler) Write(destination io.Writer) (err error) {
+	if err := p.checkKeys(); err != nil {
 		return err
 	}
 
-	p.props = props
+	var sb strings.Builder
+	for _, line := range p.lines {
+		sb.WriteString(line.render(p.encoding))
+	}
+
+	content := []byte(sb.String())
+	if p.encoding == PropertiesISO88591 {
+		content, err = charmap.ISO8859_1.NewEncoder().Bytes(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = destination.Write(content)
+
+	return err
+}
+
+// Tree returns the content as tree including all changes made to the entries.
+// Keys are split at dots into nested maps, indices like key[0] are turned into lists.
+// Values stay strings, since properties files don't have data types.
+// If a key is defined more than once, the last value wins
+func (p *PropertiesFileHandler) Tree() (interface{}, error) {
+	if err := p.checkKeys(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+	values := make(map[string]string)
+	for _, line := range p.lines {
+		if line.entry == nil {
+			continue
+		}
+		if _, found := values[line.entry.key]; !found {
+			keys = append(keys, line.entry.key)
+		}
+		values[line.entry.key] = line.entry.val
+	}
+
+	tree := make(map[string]interface{})
+	for _, key := range keys {
+		if err := setPropertyPath(tree, key, splitPropertyKey(key), values[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	return indexedMapsToLists("", tree)
+}
+
+// checkKeys makes sure no key has been renamed to a key which is already in use
+func (p *PropertiesFileHandler) checkKeys() error {
+	counts := make(map[string]int)
+	for _, line := range p.lines {
+		if line.entry != nil {
+			counts[line.entry.key]++
+		}
+	}
+
+	for _, line := range p.lines {
+		if line.entry == nil || line.entry.key == line.entry.originalKey {
+			continue
+		}
+		if counts[line.entry.key] > 1 {
+			return fmt.Errorf("%w: %s renders to %s, which already exists", ErrKeyConflict, line.entry.originalKey, line.entry.key)
+		}
+	}
+
+	return nil
+}
+
+// SetTree replaces the content with the given tree. Nested maps are flattened into keys joined by dots,
+// lists are flattened into keys with indices like key[0]
+func (p *PropertiesFileHandler) SetTree(tree interface{}) error {
+	container, ok := tree.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("properties files must have a map at the top level, got %T", tree)
+	}
+
+	p.lines = make([]*propertiesLine, 0)
+	p.flatten("", container)
 
 	return nil
 }
 
-// Process processes the configuration file
-func (p *PropertiesFileHandler) Process() (iter.Seq[ConfigEntry], error) {
-	p.wrapped = make([]ConfigEntry, 0)
+// appendProperty appends a line defining the property
+func (p *PropertiesFileHandler) appendProperty(key string, value string) {
+	escapedKey := escapePropertiesText(key, true, p.encoding)
+	entry := &PropertiesConfigEntry{
+		key:         key,
+		val:         value,
+		originalKey: key,
+		original:    value,
+		prefix:      escapedKey + " = ",
+		keyEnd:      len(escapedKey),
+		eol:         "\n",
+	}
+
+	p.lines = append(p.lines, &propertiesLine{
+		raw:   entry.prefix + escapePropertiesText(value, false, p.encoding) + entry.eol,
+		entry: entry,
+	})
+}
+
+// parseProperties splits the content into logical lines and parses the entries like java.util.Properties does
+func parseProperties(content string) ([]*propertiesLine, error) {
+	lines := make([]*propertiesLine, 0)
 
-	for _, key := range p.props.Keys() {
-		wrapped := PropertiesConfigEntry{
-			key: key,
-			val: p.props.GetString(key, ""),
+	for start := 0; start < len(content); {
+		end, eol := nextPropertiesLine(content, start)
+		text := content[start : end-len(eol)]
+
+		// Comments and blank lines can't be continued
+		trimmed := strings.TrimLeft(text, " \t\f")
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			lines = append(lines, &propertiesLine{raw: content[start:end]})
+			start = end
+			continue
+		}
+
+		// Lines ending with an odd number of backslashes are continued on the next line
+		for continuesPropertiesLine(text) && end < len(content) {
+			end, eol = nextPropertiesLine(content, end)
+			text = content[start : end-len(eol)]
 		}
 
-		p.wrapped = append(p.wrapped, &wrapped)
+		entry, err := parsePropertiesEntry(text)
+		if err != nil {
+			return nil, err
+		}
+		entry.eol = eol
+
+		lines = append(lines, &propertiesLine{raw: content[start:end], entry: entry})
+		start = end
 	}
 
-	return func(yield func(ConfigEntry) bool) {
-		for _, entry := range p.wrapped {
-			if !yield(entry) {
-				break
+	return lines, nil
+}
+
+// nextPropertiesLine returns the end of the physical line starting at start including its line ending and the line ending
+func nextPropertiesLine(content string, start int) (int, string) {
+	for i := start; i < len(content); i++ {
+		switch content[i] {
+		case '\n':
+			return i + 1, "\n"
+		case '\r':
+			if i+1 < len(content) && content[i+1] == '\n' {
+				return i + 2, "\r\n"
+			}
+			return i + 1, "\r"
+		}
+	}
+
+	return len(content), ""
+}
+
+// continuesPropertiesLine checks if the line ends with an odd number of backslashes
+func continuesPropertiesLine(line string) bool {
+	backslashes := len(line) - len(strings.TrimRight(line, `\`))
+	return backslashes%2 == 1
+}
+
+// parsePropertiesEntry parses the key and value of a logical line, which may contain continuations
+func parsePropertiesEntry(text string) (*PropertiesConfigEntry, error) {
+	// Join the physical lines while remembering the position of each character within the text
+	logical := make([]byte, 0, len(text))
+	positions := make([]int, 0, len(text))
+	i := len(text) - len(strings.TrimLeft(text, " \t\f"))
+	for i < len(text) {
+		c := text[i]
+		if c == '\\' && i+1 < len(text) && (text[i+1] == '\n' || text[i+1] == '\r') {
+			// Skip the line ending and the leading whitespace of the continuation line
+			i++
+			if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+				i++
 			}
+			i++
+			for i < len(text) && strings.IndexByte(" \t\f", text[i]) >= 0 {
+				i++
+			}
+			continue
+		}
+
+		logical = append(logical, c)
+		positions = append(positions, i)
+		if c == '\\' && i+1 < len(text) {
+			// Keep escape sequences intact, so an escaped separator doesn't end the key
+			logical = append(logical, text[i+1])
+			positions = append(positions, i+1)
+			i++
 		}
+		i++
+	}
+
+	// The key ends at the first unescaped separator or whitespace
+	keyEnd := 0
+	for keyEnd < len(logical) && strings.IndexByte("=: \t\f", logical[keyEnd]) < 0 {
+		if logical[keyEnd] == '\\' {
+			keyEnd++
+		}
+		keyEnd++
+	}
+	keyEnd = min(keyEnd, len(logical))
+
+	valueStart := keyEnd
+	for valueStart < len(logical) && strings.IndexByte(" \t\f", logical[valueStart]) >= 0 {
+		valueStart++
+	}
+	if valueStart < len(logical) && (logical[valueStart] == '=' || logical[valueStart] == ':') {
+		valueStart++
+	}
+	for valueStart < len(logical) && strings.IndexByte(" \t\f", logical[valueStart]) >= 0 {
+		valueStart++
+	}
+
+	key, err := unescapeProperties(string(logical[:keyEnd]))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := unescapeProperties(string(logical[valueStart:]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of property %s: %w", key, err)
+	}
+
+	// Remember where the key is written, it may be empty, e.g. for a line starting with a separator
+	keyStart := len(text) - len(strings.TrimLeft(text, " \t\f"))
+	keyStop := keyStart
+	if keyEnd > 0 {
+		keyStart = positions[0]
+		keyStop = positions[keyEnd-1] + 1
+	}
+
+	prefix := text
+	if valueStart < len(logical) {
+		prefix = text[:positions[valueStart]]
+	} else if keyEnd == len(logical) {
+		// The key has no separator, one is needed before a value can be added
+		prefix += "="
+	}
+
+	return &PropertiesConfigEntry{
+		key:         key,
+		val:         value,
+		originalKey: key,
+		original:    value,
+		prefix:      prefix,
+		keyStart:    keyStart,
+		keyEnd:      keyStop,
 	}, nil
 }
 
-// Write writes the configuration file
-func (p *PropertiesFileHandler) Write(destination io.Writer) (err error) {
-	for _, w := range p.wrapped {
-		p.props.Set(w.Key(), w.GetValue())
+// unescapeProperties resolves the escape sequences of a key or value
+func unescapeProperties(text string) (string, error) {
+	if !strings.Contains(text, `\`) {
+		return text, nil
 	}
 
-	_, err = p.props.Write(destination, properties.UTF8)
+	runes := []rune(text)
+	result := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\\' || i+1 == len(runes) {
+			result = append(result, runes[i])
+			continue
+		}
 
-	return err
+		i++
+		switch runes[i] {
+		case 't':
+			result = append(result, '\t')
+		case 'n':
+			result = append(result, '\n')
+		case 'r':
+			result = append(result, '\r')
+		case 'f':
+			result = append(result, '\f')
+		case 'u':
+			if i+4 >= len(runes) {
+				return "", fmt.Errorf("malformed \\uxxxx encoding in %q", text)
+			}
+			code, err := strconv.ParseUint(string(runes[i+1:i+5]), 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx encoding in %q", text)
+			}
+			i += 4
+
+			// Characters outside of the basic multilingual plane are given as surrogate pairs
+			r := rune(code)
+			if n := len(result); n > 0 && utf16.IsSurrogate(result[n-1]) {
+				if combined := utf16.DecodeRune(result[n-1], r); combined != unicode.ReplacementChar {
+					result[n-1] = combined
+					continue
+				}
+			}
+			result = append(result, r)
+		default:
+			result = append(result, runes[i])
+		}
+	}
+
+	return string(result), nil
+}
+
+// escapePropertiesText escapes a key or value, so it is read back unchanged.
+// Characters which can't be represented by the encoding are written as Unicode escapes
+func escapePropertiesText(text string, isKey bool, encoding PropertiesEncoding) string {
+	var sb strings.Builder
+	for i, r := range text {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r == ' ' && (isKey || i == 0):
+			// Leading whitespace of values would be skipped while reading
+			sb.WriteString(`\ `)
+		case isKey && strings.ContainsRune("=:#!", r):
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case encoding == PropertiesISO88591 && r > 0xFF:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&sb, `\u%04X`, unit)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+// propertyIndexRe matches list indices within property keys
+var propertyIndexRe = regexp.MustCompile(`^(.*?)((?:\[\d+\])+)$`)
+
+// splitPropertyKey splits a property key into its segments, e.g. a.b[0].c becomes a, b, [0], c
+func splitPropertyKey(key string) []string {
+	segments := make([]string, 0)
+	for _, part := range strings.Split(key, ".") {
+		match := propertyIndexRe.FindStringSubmatch(part)
+		if match == nil {
+			segments = append(segments, part)
+			continue
+		}
+
+		if match[1] != "" {
+			segments = append(segments, match[1])
+		}
+		for _, index := range strings.SplitAfter(match[2], "]") {
+			if index != "" {
+				segments = append(segments, index)
+			}
+		}
+	}
+
+	return segments
+}
+
+// setPropertyPath sets the value at the location described by the segments, creating nested maps as needed
+func setPropertyPath(container map[string]interface{}, key string, segments []string, value string) error {
+	if len(segments) == 1 {
+		if _, exists := container[segments[0]]; exists {
+			return fmt.Errorf("conflicting property keys: %s is also used as parent of other keys", key)
+		}
+		container[segments[0]] = value
+		return nil
+	}
+
+	child, exists := container[segments[0]]
+	if !exists {
+		child = make(map[string]interface{})
+		container[segments[0]] = child
+	}
+
+	childMap, ok := child.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("conflicting property keys: %s has a value and is used as parent of %s", segments[0], key)
+	}
+
+	return setPropertyPath(childMap, key, segments[1:], value)
+}
+
+// indexedMapsToLists converts maps whose keys are all indices like [0] into lists.
+// The indices have to be contiguous starting at 0, so the size of a list is bound by the number of keys
+func indexedMapsToLists(path string, value interface{}) (interface{}, error) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value, nil
+	}
+
+	indexed := len(m) > 0
+	for key, item := range m {
+		_, isIndex := parsePropertyIndex(key)
+		if !isIndex {
+			indexed = false
+		}
+
+		childPath := path + key
+		if !isIndex {
+			childPath = appendToPath(path, key)
+		}
+		converted, err := indexedMapsToLists(childPath, item)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = converted
+	}
+
+	if !indexed {
+		return m, nil
+	}
+
+	l := make([]interface{}, len(m))
+	for key, item := range m {
+		index, _ := parsePropertyIndex(key)
+		if index >= len(m) {
+			return nil, fmt.Errorf("the indices of %s have to be contiguous starting at 0, got %s for a list of %d items", path, key, len(m))
+		}
+		l[index] = item
+	}
+
+	return l, nil
+}
+
+// parsePropertyIndex parses a key segment like [0] and returns the index. Indices with leading zeros or signs aren't accepted
+func parsePropertyIndex(segment string) (int, bool) {
+	if !strings.HasPrefix(segment, "[") || !strings.HasSuffix(segment, "]") {
+		return 0, false
+	}
+
+	digits := segment[1 : len(segment)-1]
+	index, err := strconv.Atoi(digits)
+	if err != nil || index < 0 || strconv.Itoa(index) != digits {
+		return 0, false
+	}
+
+	return index, true
+}
+
+// flatten appends the values of the tree as properties using their path as key
+func (p *PropertiesFileHandler) flatten(prefix string, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		// Empty values can't be represented
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// Sort the keys to get a stable output
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			p.flatten(appendToPath(prefix, key), v[key])
+		}
+	case []interface{}:
+		for i, item := range v {
+			p.flatten(fmt.Sprintf("%s[%d]", prefix, i), item)
+		}
+	default:
+		p.appendProperty(prefix, formatValue(v))
+	}
 }
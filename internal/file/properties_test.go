@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/gkampitakis/go-snaps/snaps"
@@ -81,3 +82,159 @@ func TestPropertiesFileHandlerEdit(t *testing.T) {
 
 	snaps.MatchSnapshot(t, output.String())
 }
+
+func TestPropertiesFormatting(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(path.Join(wd, "/testdata/properties/formatting.properties"))
+	assert.NoError(t, err)
+
+	handler := NewPropertiesConfigFileHandler()
+	assert.NoError(t, handler.Read(bytes.NewReader(content)))
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	values := make(map[string]string)
+	for entry := range entries {
+		values[entry.Key()] = entry.GetValue()
+	}
+
+	assert.Equal(t, map[string]string{
+		"greeting":        "Hello ${USER_NAME}",
+		"farewell":        "Goodbye",
+		"key with spaces": "value with spaces   ",
+		"multiline":       "first, second, ${THIRD}",
+		"unicode":         "Grüße 😀",
+		"escaped=key":     "a=b",
+		"empty":           "",
+		"flag":            "",
+		"indented":        "${INDENTED}",
+	}, values)
+
+	// Unchanged files are written exactly as read
+	output := new(bytes.Buffer)
+	assert.NoError(t, handler.Write(output))
+	assert.Equal(t, string(content), output.String())
+
+	for entry := range entries {
+		switch entry.Key() {
+		case "greeting":
+			entry.SetValue("Hello Jane")
+		case "multiline":
+			entry.SetValue("first, second, third")
+		case "flag":
+			entry.SetValue("on")
+		case "indented":
+			entry.SetValue(" leading\\space\n")
+		}
+	}
+
+	// Only the values of changed entries are rewritten
+	expected := strings.NewReplacer(
+		"greeting = Hello ${USER_NAME}", "greeting = Hello Jane",
+		"multiline = first, \\\r\n            second, \\\r\n            ${THIRD}", "multiline = first, second, third",
+		"flag\r\n", "flag=on\r\n",
+		"indented   :   ${INDENTED}", `indented   :   \ leading\\space\n`,
+	).Replace(string(content))
+
+	output = new(bytes.Buffer)
+	assert.NoError(t, handler.Write(output))
+	assert.Equal(t, expected, output.String())
+}
+
+func TestPropertiesISO88591(t *testing.T) {
+	// "Grüße" encoded as ISO-8859-1 followed by an escaped euro sign
+	content := []byte("# Gr\xfc\xdfe\ngreeting=Gr\xfc\xdfe ${NAME}\ncurrency=\\u20ac\n")
+
+	handler := NewPropertiesConfigFileHandler()
+	handler.SetEncoding(PropertiesISO88591)
+	assert.NoError(t, handler.Read(bytes.NewReader(content)))
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	for entry := range entries {
+		switch entry.Key() {
+		case "greeting":
+			assert.Equal(t, "Grüße ${NAME}", entry.GetValue())
+			entry.SetValue("Grüße Zoë, 5 €")
+		case "currency":
+			assert.Equal(t, "€", entry.GetValue())
+		}
+	}
+
+	output := new(bytes.Buffer)
+	assert.NoError(t, handler.Write(output))
+	assert.Equal(t, "# Gr\xfc\xdfe\ngreeting=Gr\xfc\xdfe Zo\xeb, 5 \\u20AC\ncurrency=\\u20ac\n", output.String())
+}
+
+func TestParsePropertiesEncoding(t *testing.T) {
+	for input, expected := range map[string]PropertiesEncoding{"UTF-8": PropertiesUTF8, "utf8": PropertiesUTF8, "latin1": PropertiesISO88591, "ISO-8859-1": PropertiesISO88591} {
+		encoding, err := ParsePropertiesEncoding(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, encoding)
+	}
+
+	_, err := ParsePropertiesEncoding("utf-16")
+	assert.Error(t, err)
+}
+
+func TestPropertiesTree(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		input    string
+		expected interface{}
+		wantErr  string
+	}{
+		{
+			desc:  "Lists",
+			input: "servers[1].host=b\nservers[0].host=a\nport=8080\nenabled=true\n",
+			// Properties files don't have data types, so all values stay strings
+			expected: map[string]interface{}{
+				"servers": []interface{}{
+					map[string]interface{}{"host": "a"},
+					map[string]interface{}{"host": "b"},
+				},
+				"port":    "8080",
+				"enabled": "true",
+			},
+		},
+		{
+			desc:    "Missing index",
+			input:   "servers[0]=a\nservers[2]=c\n",
+			wantErr: "the indices of servers have to be contiguous starting at 0, got [2] for a list of 2 items",
+		},
+		{
+			desc:    "Huge index",
+			input:   "servers[9000000000000000000]=a\n",
+			wantErr: "the indices of servers have to be contiguous starting at 0, got [9000000000000000000] for a list of 1 items",
+		},
+		{
+			desc:    "Nested list",
+			input:   "a.b[0][1]=x\n",
+			wantErr: "the indices of a.b[0] have to be contiguous starting at 0, got [1] for a list of 1 items",
+		},
+		{
+			desc:     "Leading zeros aren't indices",
+			input:    "servers[00]=a\n",
+			expected: map[string]interface{}{"servers": map[string]interface{}{"[00]": "a"}},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			handler := NewPropertiesConfigFileHandler()
+			assert.NoError(t, handler.Read(strings.NewReader(tC.input)))
+
+			tree, err := handler.Tree()
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, tree)
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Schema validates rendered documents against a JSON Schema
+type Schema struct {
+	location string
+	schema   *jsonschema.Schema
+}
+
+// LoadSchema loads and compiles the JSON Schema at the given location. Schemas without $schema are treated as draft 2020-12
+func LoadSchema(location string) (*Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+
+	schema, err := compiler.Compile(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema %s: %w", location, err)
+	}
+
+	return &Schema{
+		location: location,
+		schema:   schema,
+	}, nil
+}
+
+// entryOrigin records where the rendered value of an entry came from
+type entryOrigin struct {
+	// template is the value of the entry before processing
+	template string
+	// variables are the names of the variables referenced by the template
+	variables []string
+}
+
+// originKey returns the key of the origin of the entry at the given location within the tree. Keys may contain any character
+// but NUL, e.g. dots, so the location is joined using NUL
+func originKey(location []string) string {
+	return strings.Join(location, "\x00")
+}
+
+// SchemaViolation represents a single violation of the schema
+type SchemaViolation struct {
+	// Path is the path of the offending entry
+	Path string
+	// Message describes the violation
+	Message string
+	// Template is the value of the entry before processing, if the entry was found
+	Template string
+	// Variables are the names of the variables referenced by the template
+	Variables []string
+}
+
+// String returns the violation including the template and variables it originates from
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	result := fmt.Sprintf("%s: %s", path, v.Message)
+	if v.Template != "" {
+		result += fmt.Sprintf(" (template %q", v.Template)
+		if len(v.Variables) > 0 {
+			result += fmt.Sprintf(", variables %s", strings.Join(v.Variables, ", "))
+		}
+		result += ")"
+	}
+
+	return result
+}
+
+// SchemaValidationError is returned if a rendered document doesn't match the schema
+type SchemaValidationError struct {
+	// Schema is the location of the schema
+	Schema string
+	// Violations are the individual violations of the schema
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "the document doesn't match the schema %s:", e.Schema)
+	for _, violation := range e.Violations {
+		sb.WriteString("\n  ")
+		sb.WriteString(violation.String())
+	}
+
+	return sb.String()
+}
+
+// schemaConversion represents a value converted to the type required by the schema
+type schemaConversion struct {
+	// location is the location of the value within the tree
+	location []string
+	// value is the converted value
+	value interface{}
+}
+
+// validate validates the tree against the schema and maps violations back to the entries using the given origins.
+// Values produced by placeholders are strings. If coerce is set, they are converted to the type required by the schema if possible,
+// otherwise type violations are reported like any other violation. It returns the conversions which have to be applied to the tree
+func (s *Schema) validate(tree interface{}, origins map[string]entryOrigin, coerce bool) ([]schemaConversion, error) {
+	instance, err := roundTripJSON(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+
+	validationError, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	conversions := make([]schemaConversion, 0)
+	for _, cause := range leafErrors(validationError) {
+		if _, found := origins[originKey(cause.InstanceLocation)]; !found || !coerce {
+			continue
+		}
+
+		if converted, ok := convertToSchemaType(instance, cause); ok {
+			setTreeValue(instance, cause.InstanceLocation, converted)
+			conversions = append(conversions, schemaConversion{location: cause.InstanceLocation, value: converted})
+		}
+	}
+
+	if len(conversions) > 0 {
+		err = s.schema.Validate(instance)
+		if err == nil {
+			return conversions, nil
+		}
+
+		validationError, ok = err.(*jsonschema.ValidationError)
+		if !ok {
+			return nil, err
+		}
+	}
+
+	result := &SchemaValidationError{Schema: s.location}
+	printer := message.NewPrinter(language.English)
+	for _, cause := range leafErrors(validationError) {
+		path := strings.Join(cause.InstanceLocation, ".")
+		violation := SchemaViolation{
+			Path:    path,
+			Message: cause.ErrorKind.LocalizedString(printer),
+		}
+
+		if origin, found := origins[originKey(cause.InstanceLocation)]; found {
+			violation.Template = origin.template
+			violation.Variables = origin.variables
+		}
+
+		result.Violations = append(result.Violations, violation)
+	}
+
+	return nil, result
+}
+
+// roundTripJSON converts the tree to the representation expected by the validator, e.g. for dates
+func roundTripJSON(tree interface{}) (interface{}, error) {
+	content, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonschema.UnmarshalJSON(bytes.NewReader(content))
+}
+
+// convertToSchemaType converts the string causing a type violation to the wanted type.
+// It returns false if the value can't be converted
+func convertToSchemaType(tree interface{}, cause *jsonschema.ValidationError) (interface{}, bool) {
+	typeError, ok := cause.ErrorKind.(*kind.Type)
+	if !ok || typeError.Got != "string" {
+		return nil, false
+	}
+
+	value := tree
+	for _, token := range cause.InstanceLocation {
+		value = treeChild(value, token)
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+
+	for _, want := range typeError.Want {
+		switch want {
+		case "integer":
+			if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+				return i, true
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(str, 64); err == nil {
+				return f, true
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(str); err == nil {
+				return b, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// setTreeValue replaces the value at the given location within the tree
+func setTreeValue(tree interface{}, location []string, value interface{}) bool {
+	if len(location) == 0 {
+		return false
+	}
+
+	container := tree
+	for _, token := range location[:len(location)-1] {
+		container = treeChild(container, token)
+	}
+
+	last := location[len(location)-1]
+	switch c := container.(type) {
+	case map[string]interface{}:
+		c[last] = value
+	case []interface{}:
+		index, err := strconv.Atoi(last)
+		if err != nil || index < 0 || index >= len(c) {
+			return false
+		}
+		c[index] = value
+	default:
+		return false
+	}
+
+	return true
+}
+
+// treeChild returns the child of the map or list identified by the token or nil
+func treeChild(container interface{}, token string) interface{} {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		return c[token]
+	case []interface{}:
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(c) {
+			return nil
+		}
+		return c[index]
+	default:
+		return nil
+	}
+}
+
+// leafErrors returns the errors without causes, which describe the actual violations
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	result := make([]*jsonschema.ValidationError, 0, len(err.Causes))
+	for _, cause := range err.Causes {
+		result = append(result, leafErrors(cause)...)
+	}
+
+	return result
+}
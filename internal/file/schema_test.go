@@ -0,0 +1,141 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidation(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	schema, err := LoadSchema(path.Join(wd, "testdata/schema/deployment.schema.json"))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		content    string
+		fileType   general.FileType
+		env        map[string]string
+		coerce     bool
+		expected   string
+		violations []SchemaViolation
+	}{
+		{
+			desc:     "Valid YAML with converted values",
+			content:  "name: shop\nreplicas: ${REPLICAS}\nimage:\n  repository: shop\n  tag: ${TAG}\nports:\n  - ${PORT}\n",
+			fileType: general.YAML,
+			env:      map[string]string{"REPLICAS": "3", "TAG": "1.2.3", "PORT": "8080"},
+			coerce:   true,
+			expected: "image:\n  repository: shop\n  tag: 1.2.3\nname: shop\nports:\n  - 8080\nreplicas: 3\n",
+		},
+		{
+			desc:     "Type violations without coercion",
+			content:  "name: shop\nreplicas: ${REPLICAS}\nimage:\n  repository: shop\n  tag: ${TAG}\nports:\n  - ${PORT}\n",
+			fileType: general.YAML,
+			env:      map[string]string{"REPLICAS": "3", "TAG": "1.2.3", "PORT": "8080"},
+			violations: []SchemaViolation{
+				{Path: "replicas", Message: "got string, want integer", Template: "${REPLICAS}", Variables: []string{"REPLICAS"}},
+				{Path: "ports.0", Message: "got string, want integer", Template: "${PORT}", Variables: []string{"PORT"}},
+			},
+		},
+		{
+			desc:     "Valid JSON",
+			content:  `{"name": "shop", "replicas": 2, "image": {"repository": "shop", "tag": "${TAG}"}}`,
+			fileType: general.JSON,
+			env:      map[string]string{"TAG": "1.2.3"},
+			expected: "{\n  \"image\": {\n    \"repository\": \"shop\",\n    \"tag\": \"1.2.3\"\n  },\n  \"name\": \"shop\",\n  \"replicas\": 2\n}",
+		},
+		{
+			desc:     "Invalid YAML",
+			content:  "name: shop\nreplicas: ${REPLICAS}\nimage:\n  repository: shop\n  tag: ${TAG|lower}\n",
+			fileType: general.YAML,
+			env:      map[string]string{"REPLICAS": "three", "TAG": "LATEST"},
+			violations: []SchemaViolation{
+				{Path: "image.tag", Message: "'latest' does not match pattern '^[0-9]+\\\\.[0-9]+\\\\.[0-9]+$'", Template: "${TAG|lower}", Variables: []string{"TAG"}},
+				{Path: "replicas", Message: "got string, want integer", Template: "${REPLICAS}", Variables: []string{"REPLICAS"}},
+			},
+		},
+		{
+			desc:     "Invalid literal value",
+			content:  "name: shop\nreplicas: 0\nimage:\n  repository: shop\n  tag: 1.2.3\n",
+			fileType: general.YAML,
+			violations: []SchemaViolation{
+				{Path: "replicas", Message: "minimum: got 0, want 1"},
+			},
+		},
+		{
+			desc:     "Properties with converted list items",
+			content:  "name=shop\nreplicas=${REPLICAS}\nimage.repository=shop\nimage.tag=1.2.3\nports[0]=${PORT}\n",
+			fileType: general.PROPERTIES,
+			env:      map[string]string{"REPLICAS": "3", "PORT": "8080"},
+			coerce:   true,
+			expected: "image.repository = shop\nimage.tag = 1.2.3\nname = shop\nports[0] = 8080\nreplicas = 3\n",
+		},
+		{
+			desc:     "Properties with list items",
+			content:  "name=shop\nreplicas=${REPLICAS}\nimage.repository=shop\nimage.tag=1.2.3\nports[0]=${PORT}\n",
+			fileType: general.PROPERTIES,
+			env:      map[string]string{"REPLICAS": "3", "PORT": "8080"},
+			violations: []SchemaViolation{
+				{Path: "replicas", Message: "got string, want integer", Template: "${REPLICAS}", Variables: []string{"REPLICAS"}},
+				{Path: "ports.0", Message: "got string, want integer", Template: "${PORT}", Variables: []string{"PORT"}},
+			},
+		},
+		{
+			desc:     "Keys containing dots",
+			content:  `{"name": "shop", "replicas": 1, "image": {"repository": "shop", "tag": "1.2.3"}, "annotations": {"app.kubernetes.io/name": "${NAME}"}}`,
+			fileType: general.JSON,
+			env:      map[string]string{"NAME": "a-very-long-name"},
+			violations: []SchemaViolation{
+				{Path: "annotations.app.kubernetes.io/name", Message: "maxLength: got 16, want 10", Template: "${NAME}", Variables: []string{"NAME"}},
+			},
+		},
+		{
+			desc:     "Missing property",
+			content:  "name: shop\nreplicas: 1\n",
+			fileType: general.YAML,
+			violations: []SchemaViolation{
+				{Path: "", Message: "missing property 'image'"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			for key, value := range tC.env {
+				t.Setenv(key, value)
+			}
+
+			input := path.Join(t.TempDir(), "input."+string(tC.fileType))
+			assert.NoError(t, os.WriteFile(input, []byte(tC.content), 0644))
+
+			output := new(bytes.Buffer)
+			processor := NewFileProcessor(input, tC.fileType, output)
+			processor.Schema = schema
+			processor.CoerceTypes = tC.coerce
+
+			err := processor.Process()
+			if tC.violations == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expected, output.String())
+				return
+			}
+
+			var validationError *SchemaValidationError
+			assert.True(t, errors.As(err, &validationError))
+			assert.ElementsMatch(t, tC.violations, validationError.Violations)
+			assert.Empty(t, output.String())
+		})
+	}
+}
+
+func TestLoadSchemaMissing(t *testing.T) {
+	_, err := LoadSchema(path.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// TomlConfigFileHandler represents a configuration file handler
+type TomlConfigFileHandler struct {
+	hierarchicalConfigHandler
+	container map[string]interface{}
+}
+
+// NewTomlConfigFileHandler creates a new TOML configuration file handler
+func NewTomlConfigFileHandler() *TomlConfigFileHandler {
+	return &TomlConfigFileHandler{
+		hierarchicalConfigHandler: hierarchicalConfigHandler{
+			entries: make([]ConfigEntry, 0),
+		},
+		container: make(map[string]interface{}),
+	}
+}
+
+// Read reads the configuration file
+func (t *TomlConfigFileHandler) Read(source io.Reader) (err error) {
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(source)
+	if err != nil {
+		return err
+	}
+	err = toml.Unmarshal(buf.Bytes(), &t.container)
+	if err != nil {
+		return err
+	}
+
+	return t.handleMap(t.container, "", []string{})
+}
+
+// Process processes the configuration file and returns the configuration entries
+func (t *TomlConfigFileHandler) Process() (iter.Seq[ConfigEntry], error) {
+	return func(yield func(ConfigEntry) bool) {
+		for _, entry := range t.entries {
+			if !yield(entry) {
+				break
+			}
+		}
+	}, nil
+}
+
+// apply writes the edited entries back to the container
+func (t *TomlConfigFileHandler) apply() error {
+	for _, entry := range t.entries {
+		hce, isHce := entry.(*HierarchicalConfigEntry)
+		if !isHce || !hce.isEdited() {
+			continue
+		}
+
+		val, err := hce.getConvertedValue()
+		if err != nil {
+			return err
+		}
+
+		err = setHierarchical(t.container, val, hce.hierarchy...)
+		if err != nil {
+			return err
+		}
+		hce.edited = false
+	}
+
+	return t.applyKeys(t.container)
+}
+
+// Write writes the configuration entries to the target
+func (t *TomlConfigFileHandler) Write(target io.Writer) error {
+	if err := t.apply(); err != nil {
+		return err
+	}
+
+	buf := bytes.Buffer{}
+	enc := toml.NewEncoder(&buf)
+	enc.SetIndentTables(true)
+	err := enc.Encode(t.container)
+	if err != nil {
+		return err
+	}
+
+	_, err = target.Write(buf.Bytes())
+
+	return err
+}
+
+// Tree returns the content as tree including all changes made to the entries
+func (t *TomlConfigFileHandler) Tree() (interface{}, error) {
+	if err := t.apply(); err != nil {
+		return nil, err
+	}
+
+	return normalizeTree(t.container, false), nil
+}
+
+// SetTree replaces the content with the given tree and recreates the entries
+func (t *TomlConfigFileHandler) SetTree(tree interface{}) error {
+	container, ok := tree.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("toml documents must have a map at the top level, got %T", tree)
+	}
+
+	t.container = container
+	t.entries = make([]ConfigEntry, 0)
+
+	return t.handleMap(t.container, "", []string{})
+}
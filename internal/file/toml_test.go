@@ -0,0 +1,90 @@
+package file
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTomlProcessor(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	file := path.Join(wd, "/testdata/toml/config.toml")
+
+	input, err := os.Open(file)
+	assert.NoError(t, err)
+
+	defer input.Close()
+
+	handler := NewTomlConfigFileHandler()
+
+	err = handler.Read(input)
+	assert.NoError(t, err)
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+	assert.NotNil(t, entries)
+
+	count := 0
+	for entry := range entries {
+		hce, ok := entry.(*HierarchicalConfigEntry)
+		if ok {
+			assert.Equal(t, hce.path, strings.Join(hce.hierarchy, "."))
+			count++
+		}
+	}
+
+	assert.Equal(t, 22, count)
+
+	output := new(bytes.Buffer)
+	err = handler.Write(output)
+	assert.NoError(t, err)
+
+	snaps.MatchSnapshot(t, output.String())
+}
+
+func TestTomlProcessorEdit(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	file := path.Join(wd, "/testdata/toml/config.toml")
+
+	input, err := os.Open(file)
+	assert.NoError(t, err)
+
+	defer input.Close()
+
+	handler := NewTomlConfigFileHandler()
+
+	err = handler.Read(input)
+	assert.NoError(t, err)
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+	assert.NotNil(t, entries)
+
+	for entry := range entries {
+		switch entry.Path() {
+		case "database.ports.1":
+			entry.SetValue("9001")
+		case "owner.dob":
+			entry.SetValue("2000-01-01T00:00:00Z")
+		case "products.1.release":
+			entry.SetValue("2025-02-28")
+		case "servers.alpha.role":
+			entry.SetValue("edited")
+		}
+	}
+
+	output := new(bytes.Buffer)
+	err = handler.Write(output)
+	assert.NoError(t, err)
+
+	snaps.MatchSnapshot(t, output.String())
+}
@@ -0,0 +1,63 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// TreeConfigFileHandler represents a configuration file handler whose content can be represented as a tree of maps,
+// lists and scalar values. This allows converting between the different file types
+type TreeConfigFileHandler interface {
+	ConfigFileHandler
+	// Tree returns the content as tree including all changes made to the entries
+	Tree() (interface{}, error)
+	// SetTree replaces the content with the given tree and recreates the entries
+	SetTree(tree interface{}) error
+}
+
+// normalizeTree converts the decoded values of the different handlers to a common representation.
+// Maps are converted to map[string]interface{}, lists to []interface{} and numbers to int64 or float64.
+// If jsonNumbers is set, floats without a fraction are converted to integers since JSON doesn't distinguish between them
+func normalizeTree(value interface{}, jsonNumbers bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = normalizeTree(item, jsonNumbers)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprintf("%v", key)] = normalizeTree(item, jsonNumbers)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = normalizeTree(item, jsonNumbers)
+		}
+		return l
+	case int:
+		return int64(v)
+	case float64:
+		if jsonNumbers && v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		// Local dates and times have no equivalent in other formats
+		return fmt.Sprintf("%v", v)
+	default:
+		return v
+	}
+}
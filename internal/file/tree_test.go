@@ -0,0 +1,113 @@
+package file
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		content    string
+		fileType   general.FileType
+		outputType general.FileType
+		expected   string
+		wantErr    bool
+	}{
+		{
+			desc:       "YAML to JSON",
+			content:    "a:\n  b: [1, 2.5]\n  c: x\nd: true\n",
+			fileType:   general.YAML,
+			outputType: general.JSON,
+			expected:   "{\n  \"a\": {\n    \"b\": [\n      1,\n      2.5\n    ],\n    \"c\": \"x\"\n  },\n  \"d\": true\n}",
+		},
+		{
+			desc:       "JSON to TOML",
+			content:    `{"title": "x", "owner": {"age": 42, "ratio": 0.5}}`,
+			fileType:   general.JSON,
+			outputType: general.TOML,
+			expected:   "title = 'x'\n\n[owner]\n  age = 42\n  ratio = 0.5\n",
+		},
+		{
+			desc:       "Properties to YAML",
+			content:    "server.host=localhost\nserver.port=8080\nlist[0]=a\nlist[1]=b\n",
+			fileType:   general.PROPERTIES,
+			outputType: general.YAML,
+			expected:   "list:\n  - a\n  - b\nserver:\n  host: localhost\n  port: \"8080\"\n",
+		},
+		{
+			desc:       "YAML to Properties",
+			content:    "server:\n  host: localhost\n  port: 8080\nlist:\n  - a\n  - b\n",
+			fileType:   general.YAML,
+			outputType: general.PROPERTIES,
+			expected:   "list[0] = a\nlist[1] = b\nserver.host = localhost\nserver.port = 8080\n",
+		},
+		{
+			desc:       "TOML to YAML",
+			content:    "[server]\nhost = \"localhost\"\nport = 8080\n",
+			fileType:   general.TOML,
+			outputType: general.YAML,
+			expected:   "server:\n  host: localhost\n  port: 8080\n",
+		},
+		{
+			desc:       "Conflicting Properties",
+			content:    "server=localhost\nserver.port=8080\n",
+			fileType:   general.PROPERTIES,
+			outputType: general.YAML,
+			wantErr:    true,
+		},
+		{
+			desc:       "XML is not supported",
+			content:    "<root/>",
+			fileType:   general.XML,
+			outputType: general.YAML,
+			wantErr:    true,
+		},
+		{
+			desc:       "JSON list to YAML is not supported",
+			content:    "[1, 2]",
+			fileType:   general.JSON,
+			outputType: general.YAML,
+			wantErr:    true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			fileName := path.Join(t.TempDir(), "config")
+			assert.NoError(t, os.WriteFile(fileName, []byte(tC.content), 0644))
+
+			output := new(bytes.Buffer)
+			processor := NewFileProcessor(fileName, tC.fileType, output)
+			processor.OutputType = tC.outputType
+
+			err := processor.Convert()
+			if tC.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, output.String())
+		})
+	}
+}
+
+func TestConvertRender(t *testing.T) {
+	t.Setenv("PORT", "8080")
+
+	fileName := path.Join(t.TempDir(), "config.properties")
+	assert.NoError(t, os.WriteFile(fileName, []byte("server.port=${PORT}\n"), 0644))
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor(fileName, general.Undefined, output)
+	processor.OutputType = general.JSON
+
+	err := processor.Process()
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"server\": {\n    \"port\": \"8080\"\n  }\n}", output.String())
+}
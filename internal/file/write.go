@@ -0,0 +1,120 @@
+package file
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/denglertai/gonfig/pkg/logging"
+)
+
+// defaultFileMode is used for files which didn't exist before
+const defaultFileMode = os.FileMode(0644)
+
+// renameFile renames files. It is a variable to allow replacing it within tests
+var renameFile = os.Rename
+
+// TempFilePrefix returns the prefix of the temporary files created while writing the given file
+func TempFilePrefix(name string) string {
+	return "." + filepath.Base(name) + ".gonfig-"
+}
+
+// WriteFileAtomic writes the data to a temporary file next to the target and renames it afterwards,
+// so readers of the target never see a partially written file. Mode, ownership and SELinux label of an
+// existing target are preserved. If backupSuffix is not empty, the previous content of an existing
+// target is kept in a file with the suffix appended to its name
+func WriteFileAtomic(name string, data []byte, backupSuffix string) error {
+	// Replace the file a symlink points to rather than the symlink itself
+	target := name
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		target = resolved
+	}
+
+	original, err := os.Stat(target)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if original != nil && backupSuffix != "" {
+		content, err := os.ReadFile(target)
+		if err != nil {
+			return err
+		}
+
+		logging.Debug("Creating backup", "file", target, "backup", target+backupSuffix)
+		if err := writeAtomic(target+backupSuffix, content, target, original); err != nil {
+			return err
+		}
+	}
+
+	return writeAtomic(target, data, target, original)
+}
+
+// writeAtomic writes the data to the given file using a temporary file. The attributes are copied
+// from the original file if it exists
+func writeAtomic(name string, data []byte, originalName string, original os.FileInfo) error {
+	dir := filepath.Dir(name)
+
+	tmp, err := os.CreateTemp(dir, TempFilePrefix(name)+"*")
+	if err != nil {
+		return err
+	}
+	// Removing the temporary file fails after a successful rename which is fine
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		// Make sure the content is on disk before the rename makes it visible
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if original == nil {
+		if err := os.Chmod(tmp.Name(), defaultFileMode); err != nil {
+			return err
+		}
+	} else {
+		if err := os.Chmod(tmp.Name(), original.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+			return err
+		}
+		if err := preserveAttributes(tmp.Name(), originalName, original); err != nil {
+			return err
+		}
+	}
+
+	if err := renameFile(tmp.Name(), name); err != nil {
+		// Files bind-mounted into containers, e.g. single files of a Kubernetes ConfigMap, can't be replaced, only written
+		if original != nil && isRenameUnsupported(err) {
+			logging.Warn("Unable to replace the file atomically, writing it in place", "file", name, "error", err)
+			return writeInPlace(name, data)
+		}
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// writeInPlace truncates the existing file and writes the data to it. Readers may see a partially written file,
+// but the file keeps its identity and attributes
+func writeInPlace(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
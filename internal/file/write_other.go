@@ -0,0 +1,20 @@
+//go:build !unix
+
+package file
+
+import "os"
+
+// preserveAttributes is a no-op since ownership and SELinux labels only exist on unix systems
+func preserveAttributes(name string, originalName string, original os.FileInfo) error {
+	return nil
+}
+
+// isRenameUnsupported always returns false since bind mounts only exist on unix systems
+func isRenameUnsupported(err error) bool {
+	return false
+}
+
+// syncDir is a no-op since directories can't be synced on non-unix systems
+func syncDir(dir string) error {
+	return nil
+}
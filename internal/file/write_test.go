@@ -0,0 +1,98 @@
+package file
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		existing     string
+		mode         os.FileMode
+		backupSuffix string
+		symlink      bool
+		expectedMode os.FileMode
+	}{
+		{
+			desc:         "New file",
+			expectedMode: defaultFileMode,
+		},
+		{
+			desc:         "Existing file keeps its mode",
+			existing:     "old",
+			mode:         0600,
+			expectedMode: 0600,
+		},
+		{
+			desc:         "Existing file with backup",
+			existing:     "old",
+			mode:         0640,
+			backupSuffix: ".bak",
+			expectedMode: 0640,
+		},
+		{
+			desc:         "Symlink target is replaced",
+			existing:     "old",
+			mode:         0600,
+			symlink:      true,
+			expectedMode: 0600,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			name := path.Join(dir, "config.xml")
+			target := name
+
+			if tC.existing != "" {
+				assert.NoError(t, os.WriteFile(name, []byte(tC.existing), tC.mode))
+				assert.NoError(t, os.Chmod(name, tC.mode))
+			}
+
+			if tC.symlink {
+				target = path.Join(dir, "target.xml")
+				assert.NoError(t, os.Rename(name, target))
+				assert.NoError(t, os.Symlink(target, name))
+			}
+
+			err := WriteFileAtomic(name, []byte("new"), tC.backupSuffix)
+			assert.NoError(t, err)
+
+			content, err := os.ReadFile(name)
+			assert.NoError(t, err)
+			assert.Equal(t, "new", string(content))
+
+			stat, err := os.Stat(target)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expectedMode, stat.Mode().Perm())
+
+			if tC.symlink {
+				lstat, err := os.Lstat(name)
+				assert.NoError(t, err)
+				assert.NotZero(t, lstat.Mode()&os.ModeSymlink)
+			}
+
+			if tC.backupSuffix != "" {
+				backup, err := os.ReadFile(target + tC.backupSuffix)
+				assert.NoError(t, err)
+				assert.Equal(t, tC.existing, string(backup))
+
+				stat, err := os.Stat(target + tC.backupSuffix)
+				assert.NoError(t, err)
+				assert.Equal(t, tC.expectedMode, stat.Mode().Perm())
+			}
+
+			// No temporary files are left behind
+			files, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			for _, f := range files {
+				assert.False(t, strings.HasPrefix(f.Name(), "."), "unexpected file %s", f.Name())
+			}
+		})
+	}
+}
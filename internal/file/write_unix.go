@@ -0,0 +1,75 @@
+//go:build unix
+
+package file
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/denglertai/gonfig/pkg/logging"
+	"golang.org/x/sys/unix"
+)
+
+// selinuxAttr is the extended attribute holding the SELinux label of a file
+const selinuxAttr = "security.selinux"
+
+// preserveAttributes copies the ownership and the SELinux label of the original file to the given file
+func preserveAttributes(name string, originalName string, original os.FileInfo) error {
+	if stat, ok := original.Sys().(*syscall.Stat_t); ok {
+		err := os.Lchown(name, int(stat.Uid), int(stat.Gid))
+		// Unprivileged users may not be allowed to hand over files, keep the file owned by the current user then
+		if errors.Is(err, os.ErrPermission) {
+			logging.Warn("Unable to preserve the ownership of the file", "file", originalName, "uid", stat.Uid, "gid", stat.Gid, "error", err)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	label, err := getXattr(originalName, selinuxAttr)
+	if err != nil {
+		// The attribute is missing if SELinux is not in use or not supported by the file system
+		logging.Trace("No SELinux label to preserve", "file", originalName, "error", err)
+		return nil
+	}
+
+	// Many file systems reject security attributes, e.g. with ENOTSUP, keep the label assigned by the file system then
+	if err := unix.Lsetxattr(name, selinuxAttr, label, 0); err != nil {
+		logging.Warn("Unable to preserve the SELinux label of the file", "file", originalName, "label", string(label), "error", err)
+	}
+
+	return nil
+}
+
+// isRenameUnsupported reports whether the rename failed since the target can't be replaced, e.g. with EBUSY for files
+// bind-mounted into containers or with EXDEV for targets on another file system
+func isRenameUnsupported(err error) bool {
+	return errors.Is(err, unix.EBUSY) || errors.Is(err, unix.EXDEV)
+}
+
+// getXattr reads an extended attribute of a file without following symlinks
+func getXattr(name string, attr string) ([]byte, error) {
+	size, err := unix.Lgetxattr(name, attr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	value := make([]byte, size)
+	size, err = unix.Lgetxattr(name, attr, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value[:size], nil
+}
+
+// syncDir flushes the directory so a rename within it is persisted
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
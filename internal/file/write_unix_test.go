@@ -0,0 +1,96 @@
+//go:build unix
+
+package file
+
+import (
+	"os"
+	"path"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestWriteFileAtomicOwnership(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the ownership of files requires root")
+	}
+
+	name := path.Join(t.TempDir(), "config.xml")
+	assert.NoError(t, os.WriteFile(name, []byte("old"), 0644))
+	assert.NoError(t, os.Chown(name, 1234, 5678))
+
+	err := WriteFileAtomic(name, []byte("new"), "")
+	assert.NoError(t, err)
+
+	stat, err := os.Stat(name)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1234), stat.Sys().(*syscall.Stat_t).Uid)
+	assert.Equal(t, uint32(5678), stat.Sys().(*syscall.Stat_t).Gid)
+}
+
+func TestWriteFileAtomicRenameUnsupported(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		existing bool
+		wantErr  bool
+	}{
+		{
+			desc:     "Bind-mounted file",
+			err:      unix.EBUSY,
+			existing: true,
+		},
+		{
+			desc:     "Other file system",
+			err:      unix.EXDEV,
+			existing: true,
+		},
+		{
+			desc:    "New file",
+			err:     unix.EBUSY,
+			wantErr: true,
+		},
+		{
+			desc:     "Other error",
+			err:      unix.EACCES,
+			existing: true,
+			wantErr:  true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			renameFile = func(oldpath, newpath string) error {
+				return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: tC.err}
+			}
+			defer func() { renameFile = os.Rename }()
+
+			dir := t.TempDir()
+			name := path.Join(dir, "config.xml")
+			if tC.existing {
+				assert.NoError(t, os.WriteFile(name, []byte("previous content"), 0600))
+			}
+
+			err := WriteFileAtomic(name, []byte("new"), "")
+			if tC.wantErr {
+				assert.ErrorIs(t, err, tC.err)
+				return
+			}
+			assert.NoError(t, err)
+
+			content, err := os.ReadFile(name)
+			assert.NoError(t, err)
+			assert.Equal(t, "new", string(content))
+
+			stat, err := os.Stat(name)
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+			// The temporary file is removed
+			entries, err := os.ReadDir(dir)
+			assert.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"slices"
+	"strings"
 
 	"github.com/beevik/etree"
-	"github.com/denglertai/gonfig/internal/value"
+	"github.com/denglertai/gonfig/internal/filter"
 )
 
 // XmlConfigEntry represents a single configuration entry for an attribute
@@ -15,23 +17,18 @@ type XmlAttributeConfigEntry struct {
 	attribute *etree.Attr
 	value     string
 	edited    bool
-	pathBuilt bool
-	path      string
+	// paths holds the paths of the nodes of the document
+	paths *xmlPaths
 }
 
-// Key returns the key of the configuration entry
+// Key returns the key of the configuration entry including its namespace prefix
 func (x *XmlAttributeConfigEntry) Key() string {
-	return x.attribute.Key
+	return x.attribute.FullKey()
 }
 
 // Path returns the path of the configuration entry
 func (x *XmlAttributeConfigEntry) Path() string {
-	if !x.pathBuilt {
-		x.path = fmt.Sprintf("%s@%s", x.attribute.Element().GetPath(), x.attribute.Key)
-		x.pathBuilt = true
-	}
-
-	return x.path
+	return x.paths.attribute(x.attribute)
 }
 
 // GetValue returns the value of the configuration entry
@@ -45,43 +42,193 @@ func (x *XmlAttributeConfigEntry) SetValue(value string) {
 	x.edited = true
 }
 
-// XmlConfigEntry represents a single configuration entry for an element
-type XmlElementConfigEntry struct {
-	element   *etree.Element
-	pathBuilt bool
-	path      string
-	fromCData bool
+// EscapeContexts returns the contexts the value has to be escaped for. Attributes are escaped by the encoder,
+// only documents embedded into them are escaped
+func (x *XmlAttributeConfigEntry) EscapeContexts() []string {
+	return embeddedContexts(x.Key(), x.GetValue())
+}
+
+// XmlElementNameConfigEntry represents the name of an element including its namespace prefix.
+// The element is renamed when the document is written
+type XmlElementNameConfigEntry struct {
+	element *etree.Element
+	name    string
+	edited  bool
+	// paths holds the paths of the nodes of the document
+	paths *xmlPaths
 }
 
 // Key returns the key of the configuration entry
-func (x *XmlElementConfigEntry) Key() string {
-	return x.element.Tag
+func (x *XmlElementNameConfigEntry) Key() string {
+	return x.element.FullTag()
 }
 
 // Path returns the path of the configuration entry
-func (x *XmlElementConfigEntry) Path() string {
-	if !x.pathBuilt {
-		x.path = x.element.GetPath()
-		x.pathBuilt = true
-	}
+func (x *XmlElementNameConfigEntry) Path() string {
+	return x.paths.element(x.element)
+}
+
+// GetValue returns the value of the configuration entry
+func (x *XmlElementNameConfigEntry) GetValue() string {
+	return x.name
+}
 
-	return x.element.GetPath()
+// SetValue sets the value of the configuration entry
+func (x *XmlElementNameConfigEntry) SetValue(value string) {
+	x.edited = x.edited || x.name != value
+	x.name = value
+}
+
+// isKey marks the entry as key entry
+func (x *XmlElementNameConfigEntry) isKey() {}
+
+// XmlAttributeNameConfigEntry represents the name of an attribute including its namespace prefix.
+// The attribute is renamed when the document is written
+type XmlAttributeNameConfigEntry struct {
+	attribute *etree.Attr
+	name      string
+	edited    bool
+	// paths holds the paths of the nodes of the document
+	paths *xmlPaths
+}
+
+// Key returns the key of the configuration entry
+func (x *XmlAttributeNameConfigEntry) Key() string {
+	return x.attribute.FullKey()
+}
+
+// Path returns the path of the configuration entry
+func (x *XmlAttributeNameConfigEntry) Path() string {
+	return x.paths.attribute(x.attribute)
 }
 
 // GetValue returns the value of the configuration entry
-func (x *XmlElementConfigEntry) GetValue() string {
-	return x.element.Text()
+func (x *XmlAttributeNameConfigEntry) GetValue() string {
+	return x.name
 }
 
 // SetValue sets the value of the configuration entry
-func (x *XmlElementConfigEntry) SetValue(value string) {
+func (x *XmlAttributeNameConfigEntry) SetValue(value string) {
+	x.edited = x.edited || x.name != value
+	x.name = value
+}
+
+// isKey marks the entry as key entry
+func (x *XmlAttributeNameConfigEntry) isKey() {}
+
+// XmlTextConfigEntry represents a single configuration entry for character data of an element, including CDATA sections.
+// Each text node is an entry of its own, thus text following child elements is handled as well.
+// If charData is nil, the entry represents the text of an element without content
+type XmlTextConfigEntry struct {
+	element  *etree.Element
+	charData *etree.CharData
+	// paths holds the paths of the nodes of the document
+	paths *xmlPaths
+}
+
+// Key returns the tag of the element containing the text including its namespace prefix
+func (x *XmlTextConfigEntry) Key() string {
+	return x.element.FullTag()
+}
+
+// Path returns the path of the configuration entry
+func (x *XmlTextConfigEntry) Path() string {
+	return x.paths.text(x.element, x.charData)
+}
+
+// GetValue returns the value of the configuration entry
+func (x *XmlTextConfigEntry) GetValue() string {
+	if x.charData == nil {
+		return x.element.Text()
+	}
+
+	return x.charData.Data
+}
+
+// SetValue sets the value of the configuration entry. Only the text node itself is replaced, CDATA sections stay CDATA sections
+func (x *XmlTextConfigEntry) SetValue(value string) {
+	if x.charData != nil {
+		x.charData.SetData(value)
+		return
+	}
+
 	x.element.SetText(value)
 }
 
+// EscapeContexts returns the contexts the value has to be escaped for. Text is escaped by the encoder, CDATA sections are not
+func (x *XmlTextConfigEntry) EscapeContexts() []string {
+	contexts := embeddedContexts(x.Key(), x.GetValue())
+	if x.charData != nil && x.charData.IsCData() {
+		contexts = append(contexts, filter.CDataEscape)
+	}
+
+	return contexts
+}
+
+// XmlCommentConfigEntry represents a single configuration entry for a comment
+type XmlCommentConfigEntry struct {
+	comment *etree.Comment
+	// paths holds the paths of the nodes of the document
+	paths *xmlPaths
+}
+
+// Key returns the key of the configuration entry
+func (x *XmlCommentConfigEntry) Key() string {
+	return "comment()"
+}
+
+// Path returns the path of the configuration entry
+func (x *XmlCommentConfigEntry) Path() string {
+	return x.paths.token(x.comment)
+}
+
+// GetValue returns the value of the configuration entry
+func (x *XmlCommentConfigEntry) GetValue() string {
+	return x.comment.Data
+}
+
+// SetValue sets the value of the configuration entry
+func (x *XmlCommentConfigEntry) SetValue(value string) {
+	x.comment.Data = value
+}
+
+// XmlProcInstConfigEntry represents a single configuration entry for a processing instruction
+type XmlProcInstConfigEntry struct {
+	procInst *etree.ProcInst
+	// paths holds the paths of the nodes of the document
+	paths *xmlPaths
+}
+
+// Key returns the target of the processing instruction
+func (x *XmlProcInstConfigEntry) Key() string {
+	return x.procInst.Target
+}
+
+// Path returns the path of the configuration entry
+func (x *XmlProcInstConfigEntry) Path() string {
+	return x.paths.token(x.procInst)
+}
+
+// GetValue returns the value of the configuration entry
+func (x *XmlProcInstConfigEntry) GetValue() string {
+	return x.procInst.Inst
+}
+
+// SetValue sets the value of the configuration entry
+func (x *XmlProcInstConfigEntry) SetValue(value string) {
+	x.procInst.Inst = value
+}
+
 // XmlConfigFileHandler represents a configuration file handler
 type XmlConfigFileHandler struct {
 	document *etree.Document
 	entries  []ConfigEntry
+	schema   *XsdSchema
+	catalog  *XsdCatalog
+	// paths holds the paths of the nodes of the document as read
+	paths *xmlPaths
+	// keys controls whether the names of elements and attributes are returned as entries as well
+	keys bool
 }
 
 // NewXmlConfigFileHandler creates a new XML configuration file handler
@@ -92,6 +239,19 @@ func NewXmlConfigFileHandler() *XmlConfigFileHandler {
 	}
 }
 
+// SetSchema enables validating the document before it is written.
+// If schema is nil, the schema referenced by xsi:schemaLocation or xsi:noNamespaceSchemaLocation is resolved using the catalog.
+// Validation is disabled if both are nil
+func (x *XmlConfigFileHandler) SetSchema(schema *XsdSchema, catalog *XsdCatalog) {
+	x.schema = schema
+	x.catalog = catalog
+}
+
+// SetKeys controls whether the names of elements and attributes are returned as entries as well
+func (x *XmlConfigFileHandler) SetKeys(enabled bool) {
+	x.keys = enabled
+}
+
 // Read reads the configuration file
 func (x *XmlConfigFileHandler) Read(source io.Reader) error {
 	buf := new(bytes.Buffer)
@@ -105,53 +265,62 @@ func (x *XmlConfigFileHandler) Read(source io.Reader) error {
 		return err
 	}
 
-	root := x.document.Root()
-	if root == nil {
+	if x.document.Root() == nil {
 		return fmt.Errorf("no root element in file found")
 	}
 
-	for _, attr := range root.Attr {
-		x.entries = append(x.entries, &XmlAttributeConfigEntry{
-			attribute: &attr,
-		})
-	}
-
-	x.handleChildElements(root.ChildElements())
+	x.paths = newXmlPaths(&x.document.Element)
+	x.handleChildren(&x.document.Element)
 
 	return nil
 }
 
-// handleChildElements recursively processes the child elements and their attributes
-func (x *XmlConfigFileHandler) handleChildElements(elements []*etree.Element) {
-	for _, element := range elements {
-		fromCData := false
-		for _, child := range element.Child {
-			if cdata, ok := child.(*etree.CharData); ok && cdata.IsCData() {
-				processed := cdata.Data
-				if val, err := value.ProcessValue(cdata.Data); err == nil {
-					if str, ok := val.(string); ok {
-						processed = str
-					}
-				}
-				element.SetText(processed)
-				fromCData = true
-				break
+// handleChildren recursively creates entries for the child tokens of the element in document order
+func (x *XmlConfigFileHandler) handleChildren(element *etree.Element) {
+	for _, child := range element.Child {
+		switch token := child.(type) {
+		case *etree.Element:
+			x.handleElement(token)
+		case *etree.CharData:
+			// Whitespace between elements is layout, not content
+			if !token.IsWhitespace() {
+				x.entries = append(x.entries, &XmlTextConfigEntry{element: element, charData: token, paths: x.paths})
+			}
+		case *etree.Comment:
+			x.entries = append(x.entries, &XmlCommentConfigEntry{comment: token, paths: x.paths})
+		case *etree.ProcInst:
+			// The XML declaration looks like a processing instruction, but isn't one
+			if token.Target != "xml" {
+				x.entries = append(x.entries, &XmlProcInstConfigEntry{procInst: token, paths: x.paths})
 			}
 		}
+	}
+}
 
-		x.entries = append(x.entries, &XmlElementConfigEntry{
-			element:   element,
-			fromCData: fromCData,
-		})
+// handleElement creates the entries for the attributes and the content of the element
+func (x *XmlConfigFileHandler) handleElement(element *etree.Element) {
+	if x.keys {
+		x.entries = append(x.entries, &XmlElementNameConfigEntry{element: element, name: element.FullTag(), paths: x.paths})
+	}
 
-		for _, attr := range element.Attr {
-			x.entries = append(x.entries, &XmlAttributeConfigEntry{
-				attribute: &attr,
-			})
+	for _, attr := range element.Attr {
+		// The name and the value entry share the attribute, so a renamed attribute keeps its value
+		attribute := &attr
+		if x.keys {
+			x.entries = append(x.entries, &XmlAttributeNameConfigEntry{attribute: attribute, name: attribute.FullKey(), paths: x.paths})
 		}
+		x.entries = append(x.entries, &XmlAttributeConfigEntry{
+			attribute: attribute,
+			paths:     x.paths,
+		})
+	}
 
-		x.handleChildElements(element.ChildElements())
+	// Empty elements get an entry as well, so text can be set using their path
+	if len(element.ChildElements()) == 0 && !hasText(element) {
+		x.entries = append(x.entries, &XmlTextConfigEntry{element: element, paths: x.paths})
 	}
+
+	x.handleChildren(element)
 }
 
 // Process processes the configuration file and returns the configuration entries
@@ -171,18 +340,257 @@ func (x *XmlConfigFileHandler) Write(target io.Writer) error {
 	for _, entry := range x.entries {
 		if attr, ok := entry.(*XmlAttributeConfigEntry); ok {
 			if attr.edited {
-				attr.attribute.Element().CreateAttr(attr.attribute.Key, attr.value)
-			}
-		}
-		if elem, ok := entry.(*XmlElementConfigEntry); ok {
-			val := elem.element.Text()
-			if elem.fromCData {
-				elem.element.SetCData(val)
-			} else {
-				elem.element.SetText(val)
+				attr.attribute.Element().CreateAttr(attr.attribute.FullKey(), attr.value)
 			}
 		}
 	}
+
+	if err := x.rename(); err != nil {
+		return err
+	}
+
+	if err := x.validate(); err != nil {
+		return err
+	}
+
 	_, err := x.document.WriteTo(target)
 	return err
 }
+
+// rename renames the elements and attributes whose name entries have been edited.
+// Renaming an attribute to the name of another attribute of the same element is a conflict
+func (x *XmlConfigFileHandler) rename() error {
+	for _, entry := range x.entries {
+		switch e := entry.(type) {
+		case *XmlElementNameConfigEntry:
+			if !e.edited {
+				continue
+			}
+			if e.name == "" {
+				return fmt.Errorf("element %s renders to an empty name", e.element.FullTag())
+			}
+
+			e.element.Space, e.element.Tag = splitXmlName(e.name)
+			e.edited = false
+		case *XmlAttributeNameConfigEntry:
+			if !e.edited {
+				continue
+			}
+			oldName := e.attribute.FullKey()
+			if e.name == "" {
+				return fmt.Errorf("attribute %s renders to an empty name", oldName)
+			}
+
+			element := e.attribute.Element()
+			if e.name != oldName && element.SelectAttr(e.name) != nil {
+				return fmt.Errorf("%w: attribute %s of element %s renders to %s, which already exists", ErrKeyConflict, oldName, element.FullTag(), e.name)
+			}
+
+			e.attribute.Space, e.attribute.Key = splitXmlName(e.name)
+			if attr := element.SelectAttr(oldName); attr != nil {
+				attr.Space, attr.Key = e.attribute.Space, e.attribute.Key
+			}
+			e.edited = false
+		}
+	}
+
+	return nil
+}
+
+// splitXmlName splits a name into its namespace prefix and its local part
+func splitXmlName(name string) (string, string) {
+	if space, local, found := strings.Cut(name, ":"); found {
+		return space, local
+	}
+
+	return "", name
+}
+
+// validate validates the document against the schema, if any
+func (x *XmlConfigFileHandler) validate() error {
+	schema := x.schema
+	if schema == nil && x.catalog != nil {
+		var err error
+		schema, err = LoadXsdFromDocument(x.document, x.catalog)
+		if err != nil {
+			return err
+		}
+	}
+
+	if schema == nil {
+		return nil
+	}
+
+	return schema.Validate(x.document)
+}
+
+// xmlPaths holds the unique paths identifying the nodes of a document. They are built for the whole document at once,
+// since building the path of every node on its own takes quadratic time for large documents
+type xmlPaths struct {
+	// elements holds the paths of the elements.
+	// Segments use the tag including its namespace prefix. If an element has siblings with the same tag,
+	// its 1-based position among them is appended, e.g. /ns:beans/ns:bean[3]
+	elements map[*etree.Element]string
+	// tokens holds the paths of text nodes, comments and processing instructions.
+	// If the parent contains several nodes matching the same node test, the 1-based position among them is appended, e.g. /beans/comment()[2]
+	tokens map[etree.Token]string
+}
+
+// newXmlPaths builds the paths of all nodes within the document
+func newXmlPaths(document *etree.Element) *xmlPaths {
+	paths := &xmlPaths{
+		elements: make(map[*etree.Element]string),
+		tokens:   make(map[etree.Token]string),
+	}
+	paths.addChildren(document, "", xmlDefaultPrefixes(document))
+
+	return paths
+}
+
+// addChildren adds the paths of the children of the element with the given path.
+// Elements of a default namespace are qualified using the prefix of the namespace within prefixes
+func (p *xmlPaths) addChildren(element *etree.Element, path string, prefixes map[string]string) {
+	type siblingKey struct {
+		tag       string
+		namespace string
+	}
+
+	// Count the siblings sharing a tag or node test first, so only those get a position
+	elementCounts := make(map[siblingKey]int)
+	tokenCounts := make(map[string]int)
+	for _, child := range element.Child {
+		if e, ok := child.(*etree.Element); ok {
+			elementCounts[siblingKey{e.Tag, e.NamespaceURI()}]++
+		} else if test := xmlNodeTest(child); test != "" {
+			tokenCounts[test]++
+		}
+	}
+
+	elementIndices := make(map[siblingKey]int)
+	tokenIndices := make(map[string]int)
+	for _, child := range element.Child {
+		if e, ok := child.(*etree.Element); ok {
+			namespace := e.NamespaceURI()
+			segment := e.FullTag()
+			if prefix, found := prefixes[namespace]; found && e.Space == "" {
+				segment = prefix + ":" + e.Tag
+			}
+
+			key := siblingKey{e.Tag, namespace}
+			elementIndices[key]++
+			if elementCounts[key] > 1 {
+				segment = fmt.Sprintf("%s[%d]", segment, elementIndices[key])
+			}
+
+			p.elements[e] = path + "/" + segment
+			p.addChildren(e, p.elements[e], prefixes)
+			continue
+		}
+
+		test := xmlNodeTest(child)
+		if test == "" {
+			continue
+		}
+
+		segment := test
+		tokenIndices[test]++
+		if tokenCounts[test] > 1 {
+			segment = fmt.Sprintf("%s[%d]", test, tokenIndices[test])
+		}
+
+		// Nodes outside of the root element belong to the document, which has no path of its own
+		p.tokens[child] = path + "/" + segment
+	}
+}
+
+// element returns the path of the element
+func (p *xmlPaths) element(element *etree.Element) string {
+	return p.elements[element]
+}
+
+// attribute returns the path of the attribute, e.g. /ns:beans/ns:bean[3]/@class
+func (p *xmlPaths) attribute(attribute *etree.Attr) string {
+	return fmt.Sprintf("%s/@%s", p.elements[attribute.Element()], attribute.FullKey())
+}
+
+// text returns the path of the text node of the element.
+// The path of the element is used if the element contains nothing but this text,
+// otherwise text() is appended including the 1-based position among the element's text nodes, e.g. /beans/bean/text()[2]
+func (p *xmlPaths) text(element *etree.Element, charData *etree.CharData) string {
+	if charData == nil {
+		return p.elements[element]
+	}
+
+	texts := 0
+	for _, child := range element.Child {
+		if c, ok := child.(*etree.CharData); ok && !c.IsWhitespace() {
+			texts++
+		}
+	}
+
+	if texts == 1 && len(element.ChildElements()) == 0 {
+		return p.elements[element]
+	}
+
+	return p.tokens[charData]
+}
+
+// token returns the path of a text node, comment or processing instruction
+func (p *xmlPaths) token(token etree.Token) string {
+	return p.tokens[token]
+}
+
+// xmlDefaultPrefixes returns the prefixes qualifying the elements of default namespaces within paths by namespace,
+// so their paths differ from the paths of elements without namespace, e.g. /ns:beans/ns:bean.
+// The default namespaces of the document get the prefixes ns, ns1, ns2 and so on in document order,
+// prefixes declared anywhere within the document are skipped
+func xmlDefaultPrefixes(document *etree.Element) map[string]string {
+	declared := make(map[string]bool)
+	namespaces := make([]string, 0)
+	var walk func(e *etree.Element)
+	walk = func(e *etree.Element) {
+		for _, attr := range e.Attr {
+			if attr.Space == "xmlns" {
+				declared[attr.Key] = true
+			}
+		}
+		if namespace := e.NamespaceURI(); e.Space == "" && e.Tag != "" && namespace != "" && !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+		for _, child := range e.ChildElements() {
+			walk(child)
+		}
+	}
+	walk(document)
+
+	prefixes := make(map[string]string, len(namespaces))
+	next := 0
+	for _, namespace := range namespaces {
+		for prefixes[namespace] == "" {
+			prefix := "ns"
+			if next > 0 {
+				prefix = fmt.Sprintf("ns%d", next)
+			}
+			next++
+			if !declared[prefix] {
+				prefixes[namespace] = prefix
+			}
+		}
+	}
+
+	return prefixes
+}
+
+// xmlNodeTest returns the node test matching the token within a path
+func xmlNodeTest(token etree.Token) string {
+	switch t := token.(type) {
+	case *etree.CharData:
+		return "text()"
+	case *etree.Comment:
+		return "comment()"
+	case *etree.ProcInst:
+		return fmt.Sprintf("processing-instruction(%s)", t.Target)
+	default:
+		return ""
+	}
+}
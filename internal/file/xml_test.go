@@ -7,7 +7,9 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/denglertai/gonfig/internal/general"
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/stretchr/testify/assert"
 )
@@ -38,7 +40,8 @@ func TestXmlProcessor(t *testing.T) {
 		assert.NotNil(t, entry)
 	}
 
-	assert.Equal(t, 17, count)
+	// Whitespace between elements doesn't result in entries
+	assert.Equal(t, 13, count)
 
 	output := new(bytes.Buffer)
 	handler.Write(output)
@@ -80,7 +83,8 @@ func TestXmlProcessorEdit(t *testing.T) {
 		assert.NotNil(t, entry)
 	}
 
-	assert.Equal(t, 17, count)
+	// Whitespace between elements doesn't result in entries
+	assert.Equal(t, 13, count)
 
 	output := new(bytes.Buffer)
 	handler.Write(output)
@@ -89,19 +93,19 @@ func TestXmlProcessorEdit(t *testing.T) {
 }
 
 func TestXmlCDataWithJsonNotParsed(t *testing.T) {
-	os.Setenv("SERVICE_NAME", "Service")
-	defer os.Unsetenv("SERVICE_NAME")
+	t.Setenv("SERVICE_NAME", "Service")
 
 	wd, err := os.Getwd()
 	assert.NoError(t, err)
 
 	file := path.Join(wd, "/testdata/xml/cdata.xml")
-	input, err := os.Open(file)
-	assert.NoError(t, err)
-	defer input.Close()
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor(file, general.Undefined, output)
+	assert.NoError(t, processor.Process())
 
 	handler := NewXmlConfigFileHandler()
-	err = handler.Read(input)
+	err = handler.Read(bytes.NewReader(output.Bytes()))
 	assert.NoError(t, err)
 
 	entries, err := handler.Process()
@@ -134,9 +138,6 @@ func TestXmlCDataWithJsonNotParsed(t *testing.T) {
 	}
 	assert.Equal(t, normalize(expected), normalize(cdataText), "CDATA JSON content should be present as text, not parsed")
 
-	output := new(bytes.Buffer)
-	handler.Write(output)
-
 	snaps.MatchSnapshot(t, output.String())
 }
 
@@ -172,3 +173,252 @@ func TestCDataJsonSyntaxValid(t *testing.T) {
 	err = json.Unmarshal([]byte(strings.TrimSpace(cdataJson)), &js)
 	assert.NoError(t, err, "CDATA content should be valid JSON")
 }
+
+func TestXmlPaths(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	input, err := os.Open(path.Join(wd, "/testdata/xml/beans.xml"))
+	assert.NoError(t, err)
+
+	defer input.Close()
+
+	handler := NewXmlConfigFileHandler()
+	assert.NoError(t, handler.Read(input))
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	paths := make([]string, 0)
+	for entry := range entries {
+		paths = append(paths, entry.Path())
+	}
+
+	assert.Subset(t, paths, []string{
+		"/ns1:beans/@xmlns:p",
+		"/ns1:beans/@xsi:schemaLocation",
+		"/ns1:beans/ns1:bean[1]/@p:url",
+		"/ns1:beans/ns1:bean[2]/@class",
+		"/ns1:beans/ns1:bean[2]/ns1:property[1]/@value",
+		"/ns1:beans/ns1:bean[2]/ns1:property[2]",
+		"/ns1:beans/ns:bean",
+		"/ns1:beans/ns:bean/@ns:class",
+	})
+}
+
+func TestXmlNamespacePaths(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		content  string
+		expected []string
+	}{
+		{
+			desc:     "Default namespace",
+			content:  `<beans xmlns="urn:beans"><bean class="a"/><bean class="b"/><bean class="c"/></beans>`,
+			expected: []string{"/ns:beans/ns:bean[1]/@class", "/ns:beans/ns:bean[3]/@class"},
+		},
+		{
+			desc:     "No namespace",
+			content:  `<beans><bean class="a"/></beans>`,
+			expected: []string{"/beans/bean/@class"},
+		},
+		{
+			desc:     "Several default namespaces",
+			content:  `<a xmlns="urn:a"><b xmlns="urn:b" c="d"/><b c="e"/></a>`,
+			expected: []string{"/ns:a/ns1:b/@c", "/ns:a/ns:b/@c"},
+		},
+		{
+			desc:     "Same name in different namespaces",
+			content:  `<a xmlns="urn:a" xmlns:x="urn:x"><b c="1"/><x:b c="2"/><b c="3"/></a>`,
+			expected: []string{"/ns:a/ns:b[1]/@c", "/ns:a/x:b/@c", "/ns:a/ns:b[2]/@c"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			handler := NewXmlConfigFileHandler()
+			assert.NoError(t, handler.Read(strings.NewReader(tC.content)))
+
+			entries, err := handler.Process()
+			assert.NoError(t, err)
+
+			paths := make([]string, 0)
+			for entry := range entries {
+				paths = append(paths, entry.Path())
+			}
+
+			assert.Subset(t, paths, tC.expected)
+		})
+	}
+}
+
+func TestXmlPathsLargeDocument(t *testing.T) {
+	const beans = 20000
+
+	var sb strings.Builder
+	sb.WriteString(`<beans xmlns="urn:beans">`)
+	for i := 0; i < beans; i++ {
+		sb.WriteString(`<bean class="${CLASS}"><!-- bean -->text</bean>`)
+	}
+	sb.WriteString(`</beans>`)
+
+	t.Setenv("CLASS", "a")
+
+	input := path.Join(t.TempDir(), "beans.xml")
+	assert.NoError(t, os.WriteFile(input, []byte(sb.String()), 0644))
+
+	// Building the path of every node on its own takes minutes for a document of this size
+	start := time.Now()
+
+	handler := NewXmlConfigFileHandler()
+	assert.NoError(t, handler.Read(strings.NewReader(sb.String())))
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	last := make([]string, 0)
+	for entry := range entries {
+		last = append(last, entry.Path())
+	}
+	assert.Equal(t, []string{"/ns:beans/ns:bean[20000]/@class", "/ns:beans/ns:bean[20000]/comment()", "/ns:beans/ns:bean[20000]"}, last[len(last)-3:])
+
+	processor := NewFileProcessor(input, general.Undefined, new(bytes.Buffer))
+	assert.NoError(t, processor.Process())
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestXmlPreservesNamespaces(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	file := path.Join(wd, "/testdata/xml/beans.xml")
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	t.Setenv("DB_URL", "jdbc:h2:mem:test")
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor(file, general.Undefined, output)
+	assert.NoError(t, processor.Process())
+
+	assert.Equal(t, strings.Replace(string(content), "${DB_URL}", "jdbc:h2:mem:test", 1), output.String())
+
+	output = new(bytes.Buffer)
+	processor = NewFileProcessor(file, general.Undefined, output)
+	assert.NoError(t, processor.Set("/ns1:beans/ns1:bean[2]/ns1:property[2]/@value", "120"))
+
+	assert.Equal(t, strings.Replace(string(content), `value="60"`, `value="120"`, 1), output.String())
+}
+
+func TestXmlMixedContent(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	file := path.Join(wd, "/testdata/xml/mixed.xml")
+
+	input, err := os.Open(file)
+	assert.NoError(t, err)
+
+	defer input.Close()
+
+	handler := NewXmlConfigFileHandler()
+	assert.NoError(t, handler.Read(input))
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	values := make(map[string]string)
+	for entry := range entries {
+		values[entry.Path()] = entry.GetValue()
+	}
+
+	assert.Equal(t, map[string]string{
+		"/comment()": " Generated for ${ENVIRONMENT} ",
+		"/processing-instruction(xml-stylesheet)": `type="text/xsl" href="${STYLESHEET}"`,
+		"/web-app/display-name":                   "${APP_NAME}",
+		"/web-app/description/text()[1]":          "\n    Deployed to ",
+		"/web-app/description/b":                  "${ENVIRONMENT}",
+		"/web-app/description/text()[2]":          " by ${USER_NAME}, built from ",
+		"/web-app/description/i":                  "${REVISION}",
+		"/web-app/comment()":                      " Session timeout in minutes: ${SESSION_TIMEOUT} ",
+		"/web-app/session-config/session-timeout": "${SESSION_TIMEOUT}",
+		"/web-app/welcome-file-list":              "",
+		"/web-app/script":                         `var env = "${ENVIRONMENT}";`,
+	}, values)
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	env := map[string]string{
+		"ENVIRONMENT":     "prod",
+		"STYLESHEET":      "web.xsl",
+		"APP_NAME":        "shop",
+		"USER_NAME":       "ci",
+		"REVISION":        "abc123",
+		"SESSION_TIMEOUT": "30",
+	}
+
+	expected := string(content)
+	for key, value := range env {
+		t.Setenv(key, value)
+		expected = strings.ReplaceAll(expected, "${"+key+"}", value)
+	}
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor(file, general.Undefined, output)
+	assert.NoError(t, processor.Process())
+	assert.Equal(t, expected, output.String())
+
+	// Setting text following a child element leaves the children intact
+	output = new(bytes.Buffer)
+	processor = NewFileProcessor(file, general.Undefined, output)
+	assert.NoError(t, processor.Set("/web-app/description/text()[2]", " by admin, built from "))
+	assert.Equal(t, strings.Replace(string(content), " by ${USER_NAME}, built from ", " by admin, built from ", 1), output.String())
+
+	// Empty elements can be filled
+	output = new(bytes.Buffer)
+	processor = NewFileProcessor(file, general.Undefined, output)
+	assert.NoError(t, processor.Set("/web-app/welcome-file-list", "index.html"))
+	assert.Contains(t, output.String(), "<welcome-file-list>index.html</welcome-file-list>")
+}
+
+func TestXmlKeys(t *testing.T) {
+	handler := NewXmlConfigFileHandler()
+	handler.SetKeys(true)
+	assert.NoError(t, handler.Read(strings.NewReader(`<config><datasource url="jdbc:h2:mem:test" user="sa"/></config>`)))
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	for entry := range entries {
+		if _, isKey := entry.(KeyConfigEntry); !isKey {
+			continue
+		}
+
+		switch entry.Path() {
+		case "/config/datasource":
+			entry.SetValue("acme:datasource")
+		case "/config/datasource/@url":
+			entry.SetValue("jdbc-url")
+		}
+	}
+
+	output := new(bytes.Buffer)
+	assert.NoError(t, handler.Write(output))
+	assert.Equal(t, `<config><acme:datasource jdbc-url="jdbc:h2:mem:test" user="sa"/></config>`, output.String())
+}
+
+func TestXmlKeyConflict(t *testing.T) {
+	handler := NewXmlConfigFileHandler()
+	handler.SetKeys(true)
+	assert.NoError(t, handler.Read(strings.NewReader(`<datasource url="first" jdbc-url="second"/>`)))
+
+	entries, err := handler.Process()
+	assert.NoError(t, err)
+
+	for entry := range entries {
+		if _, isKey := entry.(KeyConfigEntry); isKey && entry.Path() == "/datasource/@url" {
+			entry.SetValue("jdbc-url")
+		}
+	}
+
+	assert.ErrorIs(t, handler.Write(new(bytes.Buffer)), ErrKeyConflict)
+}
@@ -0,0 +1,1359 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/denglertai/gonfig/pkg/logging"
+)
+
+const (
+	// xsdNamespace is the namespace of XML Schema definitions
+	xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+	// xsiNamespace is the namespace of XML Schema instance attributes like xsi:schemaLocation
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// xsdName represents a namespace qualified name
+type xsdName struct {
+	space string
+	local string
+}
+
+func (n xsdName) String() string {
+	if n.space == "" {
+		return n.local
+	}
+
+	return fmt.Sprintf("{%s}%s", n.space, n.local)
+}
+
+// xsdDocument holds the settings of a single schema document
+type xsdDocument struct {
+	targetNamespace     string
+	qualifiedElements   bool
+	qualifiedAttributes bool
+	// chameleon is set for included documents without target namespace, which take the namespace of the including document
+	chameleon bool
+}
+
+// xsdDef represents a definition within a schema document
+type xsdDef struct {
+	el  *etree.Element
+	doc *xsdDocument
+}
+
+// XsdSchema represents a set of XML Schema documents used for validating XML files.
+// It supports the subset of XML Schema 1.0 used by configuration files like Tomcat's server.xml and the JBoss
+// subsystem schemas: element and attribute declarations, complex types with sequence, choice, all, group and any
+// particles, simple and complex content extensions and restrictions, simple types with facets, lists and unions,
+// substitution groups, xsi:type as well as the built-in types. Redefinitions and identity constraints are not supported.
+// The available Go validators bind libxml2, which would add a native library to the distroless and apko images
+type XsdSchema struct {
+	catalog         *XsdCatalog
+	loaded          map[string]bool
+	elements        map[xsdName]xsdDef
+	attributes      map[xsdName]xsdDef
+	complexTypes    map[xsdName]xsdDef
+	simpleTypes     map[xsdName]xsdDef
+	groups          map[xsdName]xsdDef
+	attributeGroups map[xsdName]xsdDef
+}
+
+// newXsdSchema creates an empty schema resolving referenced schema documents using the catalog, which may be nil
+func newXsdSchema(catalog *XsdCatalog) *XsdSchema {
+	return &XsdSchema{
+		catalog:         catalog,
+		loaded:          make(map[string]bool),
+		elements:        make(map[xsdName]xsdDef),
+		attributes:      make(map[xsdName]xsdDef),
+		complexTypes:    make(map[xsdName]xsdDef),
+		simpleTypes:     make(map[xsdName]xsdDef),
+		groups:          make(map[xsdName]xsdDef),
+		attributeGroups: make(map[xsdName]xsdDef),
+	}
+}
+
+// LoadXsd loads the schema document at the given location along with the documents it includes or imports.
+// The catalog is used for resolving documents which can't be found next to the referencing document and may be nil
+func LoadXsd(location string, catalog *XsdCatalog) (*XsdSchema, error) {
+	schema := newXsdSchema(catalog)
+	if err := schema.load(location, "", ""); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// load loads the schema document at the given location.
+// If includingNamespace is set, the document is included by a document with this target namespace
+func (s *XsdSchema) load(location string, namespace string, includingNamespace string) error {
+	path, err := s.resolveLocation(location, namespace)
+	if err != nil {
+		return err
+	}
+
+	key := path + "|" + includingNamespace
+	if s.loaded[key] {
+		return nil
+	}
+	s.loaded[key] = true
+
+	logging.Debug("Loading XML schema", "location", path)
+
+	document := etree.NewDocument()
+	if err := document.ReadFromFile(path); err != nil {
+		return fmt.Errorf("failed to read schema %s: %w", path, err)
+	}
+
+	root := document.Root()
+	if root == nil || !isXsdElement(root, "schema") {
+		return fmt.Errorf("%s is not an XML schema", path)
+	}
+
+	doc := &xsdDocument{
+		targetNamespace:     root.SelectAttrValue("targetNamespace", ""),
+		qualifiedElements:   root.SelectAttrValue("elementFormDefault", "") == "qualified",
+		qualifiedAttributes: root.SelectAttrValue("attributeFormDefault", "") == "qualified",
+	}
+	if doc.targetNamespace == "" && includingNamespace != "" {
+		doc.targetNamespace = includingNamespace
+		doc.chameleon = true
+	}
+
+	for _, child := range root.ChildElements() {
+		if child.NamespaceURI() != xsdNamespace {
+			continue
+		}
+
+		def := xsdDef{el: child, doc: doc}
+		name := xsdName{space: doc.targetNamespace, local: child.SelectAttrValue("name", "")}
+
+		switch child.Tag {
+		case "include":
+			if err := s.load(relativeLocation(path, child.SelectAttrValue("schemaLocation", "")), doc.targetNamespace, doc.targetNamespace); err != nil {
+				return err
+			}
+		case "redefine", "override":
+			return fmt.Errorf("failed to load schema %s: %s is not supported", path, child.Tag)
+		case "import":
+			importedNamespace := child.SelectAttrValue("namespace", "")
+			importLocation := child.SelectAttrValue("schemaLocation", "")
+			if importedNamespace == xsiNamespace || importedNamespace == "http://www.w3.org/XML/1998/namespace" {
+				continue
+			}
+			if importLocation == "" && (s.catalog == nil || s.catalog.namespaces[importedNamespace] == "") {
+				logging.Debug("Skipping import without location", "namespace", importedNamespace)
+				continue
+			}
+			if importLocation != "" {
+				importLocation = relativeLocation(path, importLocation)
+			}
+			if err := s.load(importLocation, importedNamespace, ""); err != nil {
+				return err
+			}
+		case "element":
+			s.elements[name] = def
+		case "attribute":
+			s.attributes[name] = def
+		case "complexType":
+			s.complexTypes[name] = def
+		case "simpleType":
+			s.simpleTypes[name] = def
+		case "group":
+			s.groups[name] = def
+		case "attributeGroup":
+			s.attributeGroups[name] = def
+		}
+	}
+
+	return nil
+}
+
+// resolveLocation resolves the location of a schema document to a local file.
+// Remote locations are never fetched, they have to be available within the catalog
+func (s *XsdSchema) resolveLocation(location string, namespace string) (string, error) {
+	if location != "" && !strings.Contains(location, "://") {
+		if _, err := os.Stat(location); err == nil {
+			return location, nil
+		}
+	}
+
+	if s.catalog != nil {
+		if path, found := s.catalog.resolve(location, namespace); found {
+			return path, nil
+		}
+	}
+
+	if location == "" {
+		return "", fmt.Errorf("no schema found for namespace %s", namespace)
+	}
+
+	return "", fmt.Errorf("schema %s not found", location)
+}
+
+// relativeLocation resolves the location relative to the referencing document
+func relativeLocation(referencing string, location string) string {
+	if location == "" || strings.Contains(location, "://") || filepath.IsAbs(location) {
+		return location
+	}
+
+	return filepath.Join(filepath.Dir(referencing), location)
+}
+
+// XsdCatalog represents a local directory containing schema documents.
+// Documents are looked up by their file name or by their target namespace
+type XsdCatalog struct {
+	files      map[string]string
+	namespaces map[string]string
+}
+
+// LoadXsdCatalog scans the directory for schema documents
+func LoadXsdCatalog(dir string) (*XsdCatalog, error) {
+	catalog := &XsdCatalog{
+		files:      make(map[string]string),
+		namespaces: make(map[string]string),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".xsd") {
+			return nil
+		}
+
+		if _, found := catalog.files[d.Name()]; !found {
+			catalog.files[d.Name()] = path
+		}
+
+		document := etree.NewDocument()
+		if err := document.ReadFromFile(path); err != nil {
+			logging.Warn("Skipping unreadable schema", "file", path, "err", err)
+			return nil
+		}
+
+		if root := document.Root(); root != nil {
+			namespace := root.SelectAttrValue("targetNamespace", "")
+			if _, found := catalog.namespaces[namespace]; !found && namespace != "" {
+				catalog.namespaces[namespace] = path
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema catalog %s: %w", dir, err)
+	}
+
+	return catalog, nil
+}
+
+// resolve looks up a schema document by the file name of its location or its namespace
+func (c *XsdCatalog) resolve(location string, namespace string) (string, bool) {
+	if location != "" {
+		name := location[strings.LastIndexAny(location, "/\\")+1:]
+		if path, found := c.files[name]; found {
+			return path, true
+		}
+	}
+
+	path, found := c.namespaces[namespace]
+	return path, found
+}
+
+// LoadXsdFromDocument loads the schema documents referenced by xsi:schemaLocation and xsi:noNamespaceSchemaLocation of the
+// document's root element using the catalog. It returns nil if the document doesn't reference any schema
+func LoadXsdFromDocument(document *etree.Document, catalog *XsdCatalog) (*XsdSchema, error) {
+	root := document.Root()
+	if root == nil {
+		return nil, nil
+	}
+
+	schema := newXsdSchema(catalog)
+	found := false
+	for _, attr := range root.Attr {
+		if attr.NamespaceURI() != xsiNamespace {
+			continue
+		}
+
+		switch attr.Key {
+		case "schemaLocation":
+			pairs := strings.Fields(attr.Value)
+			for i := 0; i+1 < len(pairs); i += 2 {
+				if err := schema.load(pairs[i+1], pairs[i], ""); err != nil {
+					return nil, err
+				}
+				found = true
+			}
+		case "noNamespaceSchemaLocation":
+			if err := schema.load(attr.Value, "", ""); err != nil {
+				return nil, err
+			}
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return schema, nil
+}
+
+// XsdViolation represents a single violation of the schema
+type XsdViolation struct {
+	// Path is the path of the offending element or attribute
+	Path string
+	// Message describes the violation
+	Message string
+}
+
+func (v XsdViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// XsdValidationError is returned if a document doesn't match the schema
+type XsdValidationError struct {
+	// Violations are the individual violations of the schema
+	Violations []XsdViolation
+}
+
+func (e *XsdValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("the document doesn't match the XML schema:")
+	for _, violation := range e.Violations {
+		sb.WriteString("\n  ")
+		sb.WriteString(violation.String())
+	}
+
+	return sb.String()
+}
+
+// Validate validates the document against the schema
+func (s *XsdSchema) Validate(document *etree.Document) error {
+	root := document.Root()
+	if root == nil {
+		return fmt.Errorf("no root element in file found")
+	}
+
+	v := &xsdValidator{schema: s, paths: newXmlPaths(&document.Element)}
+
+	decl, found := s.elements[elementName(root)]
+	if !found {
+		v.addViolation(v.paths.element(root), "element %s is not declared in the schema", elementName(root))
+	} else {
+		v.validateElement(root, decl)
+	}
+
+	if len(v.violations) > 0 {
+		return &XsdValidationError{Violations: v.violations}
+	}
+
+	return nil
+}
+
+// xsdValidator validates the elements of a document and collects the violations
+type xsdValidator struct {
+	schema *XsdSchema
+	// paths holds the paths of the nodes of the validated document
+	paths      *xmlPaths
+	violations []XsdViolation
+}
+
+func (v *xsdValidator) addViolation(path string, format string, args ...any) {
+	v.violations = append(v.violations, XsdViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// xsdParticleKind identifies the kind of a particle within a content model
+type xsdParticleKind int
+
+const (
+	particleElement xsdParticleKind = iota
+	particleSequence
+	particleChoice
+	particleAll
+	particleAny
+)
+
+// xsdParticle represents a part of the content model of a complex type
+type xsdParticle struct {
+	kind xsdParticleKind
+	// name is the name of matching elements for element particles
+	name xsdName
+	// def is the element declaration for element particles and the wildcard for any particles
+	def      xsdDef
+	children []*xsdParticle
+	min      int
+	// max is the maximum number of occurrences, -1 means unbounded
+	max int
+}
+
+// xsdAttributeUse represents an attribute allowed by a complex type
+type xsdAttributeUse struct {
+	name     xsdName
+	def      xsdDef
+	required bool
+}
+
+// xsdContentModel represents the resolved content of a complex type
+type xsdContentModel struct {
+	particle     *xsdParticle
+	attributes   map[xsdName]xsdAttributeUse
+	anyAttribute bool
+	mixed        bool
+	// simpleType is set for complex types with simple content
+	simpleType *xsdDef
+}
+
+// elementName returns the namespace qualified name of the element
+func elementName(element *etree.Element) xsdName {
+	return xsdName{space: element.NamespaceURI(), local: element.Tag}
+}
+
+// isXsdElement checks if the element is the schema element with the given local name
+func isXsdElement(element *etree.Element, local string) bool {
+	return element.Tag == local && element.NamespaceURI() == xsdNamespace
+}
+
+// resolveQName resolves a qualified name used as attribute value within the definition
+func resolveQName(def xsdDef, qname string) xsdName {
+	prefix, local, found := strings.Cut(strings.TrimSpace(qname), ":")
+	if !found {
+		local = prefix
+		prefix = ""
+	}
+
+	namespace := lookupNamespace(def.el, prefix)
+	if namespace == "" && prefix == "" && def.doc.chameleon {
+		namespace = def.doc.targetNamespace
+	}
+
+	return xsdName{space: namespace, local: local}
+}
+
+// lookupNamespace returns the namespace bound to the prefix in scope of the element
+func lookupNamespace(element *etree.Element, prefix string) string {
+	for e := element; e != nil; e = e.Parent() {
+		for _, attr := range e.Attr {
+			if prefix == "" && attr.Space == "" && attr.Key == "xmlns" {
+				return attr.Value
+			}
+			if prefix != "" && attr.Space == "xmlns" && attr.Key == prefix {
+				return attr.Value
+			}
+		}
+	}
+
+	if prefix == "xml" {
+		return "http://www.w3.org/XML/1998/namespace"
+	}
+
+	return ""
+}
+
+// occurrences returns the minOccurs and maxOccurs of the particle definition
+func occurrences(el *etree.Element) (int, int) {
+	minOccurs, err := strconv.Atoi(el.SelectAttrValue("minOccurs", "1"))
+	if err != nil {
+		minOccurs = 1
+	}
+
+	maxValue := el.SelectAttrValue("maxOccurs", "1")
+	if maxValue == "unbounded" {
+		return minOccurs, -1
+	}
+
+	maxOccurs, err := strconv.Atoi(maxValue)
+	if err != nil {
+		maxOccurs = 1
+	}
+
+	return minOccurs, maxOccurs
+}
+
+// validateElement validates the element against its declaration
+func (v *xsdValidator) validateElement(element *etree.Element, decl xsdDef) {
+	path := v.paths.element(element)
+
+	if decl.el.SelectAttrValue("abstract", "false") == "true" {
+		v.addViolation(path, "element %s is abstract", elementName(element))
+		return
+	}
+
+	if fixed := decl.el.SelectAttr("fixed"); fixed != nil && len(element.ChildElements()) == 0 && element.Text() != fixed.Value {
+		v.addViolation(path, "value '%s' doesn't match the fixed value '%s'", element.Text(), fixed.Value)
+	}
+
+	if xsiAttr(element, "nil") == "true" && decl.el.SelectAttrValue("nillable", "false") == "true" {
+		return
+	}
+
+	// A type given using xsi:type replaces the declared type
+	if xsiType := xsiAttr(element, "type"); xsiType != "" {
+		name := resolveQNameInDocument(element, xsiType)
+		if def, found := v.schema.complexTypes[name]; found {
+			v.validateComplexType(element, def)
+			return
+		}
+		if name.space == xsdNamespace {
+			v.validateSimpleElement(element, nil, name)
+			return
+		}
+		if def, found := v.schema.simpleTypes[name]; found {
+			v.validateSimpleElement(element, &def, name)
+			return
+		}
+		v.addViolation(path, "type %s given by xsi:type is not declared in the schema", name)
+		return
+	}
+
+	if typeName := decl.el.SelectAttrValue("type", ""); typeName != "" {
+		name := resolveQName(decl, typeName)
+		if def, found := v.schema.complexTypes[name]; found {
+			v.validateComplexType(element, def)
+			return
+		}
+		if name.space == xsdNamespace {
+			if name.local == "anyType" {
+				return
+			}
+			v.validateSimpleElement(element, nil, name)
+			return
+		}
+		if def, found := v.schema.simpleTypes[name]; found {
+			v.validateSimpleElement(element, &def, name)
+			return
+		}
+		v.addViolation(path, "type %s is not declared in the schema", name)
+		return
+	}
+
+	for _, child := range decl.el.ChildElements() {
+		if isXsdElement(child, "complexType") {
+			v.validateComplexType(element, xsdDef{el: child, doc: decl.doc})
+			return
+		}
+		if isXsdElement(child, "simpleType") {
+			v.validateSimpleElement(element, &xsdDef{el: child, doc: decl.doc}, xsdName{})
+			return
+		}
+	}
+
+	// Elements without type are of anyType and may contain anything
+}
+
+// xsiAttr returns the value of the xsi attribute with the given name
+func xsiAttr(element *etree.Element, key string) string {
+	for _, attr := range element.Attr {
+		if attr.Key == key && attr.NamespaceURI() == xsiNamespace {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// resolveQNameInDocument resolves a qualified name used within the validated document
+func resolveQNameInDocument(element *etree.Element, qname string) xsdName {
+	prefix, local, found := strings.Cut(strings.TrimSpace(qname), ":")
+	if !found {
+		local = prefix
+		prefix = ""
+	}
+
+	return xsdName{space: lookupNamespace(element, prefix), local: local}
+}
+
+// validateSimpleElement validates an element of a simple type, which must not contain elements or attributes
+func (v *xsdValidator) validateSimpleElement(element *etree.Element, def *xsdDef, builtin xsdName) {
+	path := v.paths.element(element)
+
+	for _, attr := range element.Attr {
+		if isNamespaceOrXsiAttr(attr) {
+			continue
+		}
+		v.addViolation(v.paths.attribute(&attr), "attribute %s is not allowed", attr.FullKey())
+	}
+
+	if len(element.ChildElements()) > 0 {
+		v.addViolation(path, "element %s must not contain elements", elementName(element))
+		return
+	}
+
+	var err error
+	if def != nil {
+		err = v.schema.validateSimpleType(element.Text(), *def)
+	} else {
+		err = validateBuiltinType(element.Text(), builtin.local)
+	}
+	if err != nil {
+		v.addViolation(path, "%v", err)
+	}
+}
+
+// isNamespaceOrXsiAttr checks if the attribute declares a namespace or is an XML schema instance attribute
+func isNamespaceOrXsiAttr(attr etree.Attr) bool {
+	return attr.Space == "xmlns" || (attr.Space == "" && attr.Key == "xmlns") || attr.NamespaceURI() == xsiNamespace
+}
+
+// validateComplexType validates the attributes and content of the element against the complex type
+func (v *xsdValidator) validateComplexType(element *etree.Element, def xsdDef) {
+	path := v.paths.element(element)
+
+	model, err := v.schema.contentModel(def)
+	if err != nil {
+		v.addViolation(path, "%v", err)
+		return
+	}
+
+	v.validateAttributes(element, model)
+
+	children := element.ChildElements()
+
+	if model.simpleType != nil {
+		if len(children) > 0 {
+			v.addViolation(path, "element %s must not contain elements", elementName(element))
+			return
+		}
+
+		if err := v.schema.validateTypeReference(element.Text(), *model.simpleType); err != nil {
+			v.addViolation(path, "%v", err)
+		}
+		return
+	}
+
+	if !model.mixed && hasText(element) {
+		v.addViolation(path, "element %s must not contain text", elementName(element))
+	}
+
+	if model.particle == nil {
+		if len(children) > 0 {
+			v.addViolation(v.paths.element(children[0]), "element %s is not expected, %s must be empty", elementName(children[0]), elementName(element))
+		}
+		return
+	}
+
+	best := bestMatch(v.schema.match(model.particle, children, 0), len(children))
+
+	switch {
+	case best.complete && best.pos == len(children):
+	case best.pos < len(children):
+		next := children[best.pos]
+		if len(best.expected) > 0 {
+			v.addViolation(v.paths.element(next), "element %s is not expected here, expected %s", elementName(next), strings.Join(best.expected, " or "))
+		} else {
+			v.addViolation(v.paths.element(next), "element %s is not expected here", elementName(next))
+		}
+	default:
+		v.addViolation(path, "content of element %s is incomplete, expected %s", elementName(element), strings.Join(best.expected, " or "))
+	}
+
+	for _, assignment := range best.assignments.all() {
+		if assignment.undeclared {
+			v.addViolation(v.paths.element(children[assignment.index]), "element %s is not declared in the schema", elementName(children[assignment.index]))
+			continue
+		}
+		if assignment.decl == nil {
+			continue
+		}
+		v.validateElement(children[assignment.index], *assignment.decl)
+	}
+}
+
+// bestMatch returns a complete match of all children if possible, otherwise the match getting furthest
+func bestMatch(results []xsdMatch, count int) xsdMatch {
+	best := xsdMatch{pos: 0, complete: false}
+	for _, result := range results {
+		if result.complete && result.pos == count {
+			return result
+		}
+		// Prefer incomplete matches on ties since they know about the expected elements
+		if result.pos > best.pos || (result.pos == best.pos && !result.complete && len(result.expected) > 0) {
+			best = result
+		}
+	}
+
+	return best
+}
+
+// hasText checks if the element contains text besides whitespace
+func hasText(element *etree.Element) bool {
+	for _, child := range element.Child {
+		if data, ok := child.(*etree.CharData); ok && strings.TrimSpace(data.Data) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateAttributes validates the attributes of the element against the attribute uses of the content model
+func (v *xsdValidator) validateAttributes(element *etree.Element, model *xsdContentModel) {
+	present := make(map[xsdName]bool)
+
+	for _, attr := range element.Attr {
+		if isNamespaceOrXsiAttr(attr) {
+			continue
+		}
+
+		name := xsdName{space: attr.NamespaceURI(), local: attr.Key}
+		present[name] = true
+
+		use, found := model.attributes[name]
+		if !found {
+			if !model.anyAttribute {
+				v.addViolation(v.paths.attribute(&attr), "attribute %s is not allowed", attr.FullKey())
+			}
+			continue
+		}
+
+		if fixed := use.def.el.SelectAttr("fixed"); fixed != nil && attr.Value != fixed.Value {
+			v.addViolation(v.paths.attribute(&attr), "value '%s' doesn't match the fixed value '%s'", attr.Value, fixed.Value)
+			continue
+		}
+
+		if err := v.schema.validateAttributeValue(attr.Value, use.def); err != nil {
+			v.addViolation(v.paths.attribute(&attr), "%v", err)
+		}
+	}
+
+	for name, use := range model.attributes {
+		if use.required && !present[name] {
+			v.addViolation(v.paths.element(element), "missing required attribute %s", name)
+		}
+	}
+}
+
+// validateAttributeValue validates the value against the type of the attribute declaration
+func (s *XsdSchema) validateAttributeValue(value string, decl xsdDef) error {
+	if typeName := decl.el.SelectAttrValue("type", ""); typeName != "" {
+		return s.validateTypeReference(value, xsdDef{el: decl.el, doc: decl.doc})
+	}
+
+	for _, child := range decl.el.ChildElements() {
+		if isXsdElement(child, "simpleType") {
+			return s.validateSimpleType(value, xsdDef{el: child, doc: decl.doc})
+		}
+	}
+
+	return nil
+}
+
+// contentModel resolves the attributes and the particle of the complex type
+func (s *XsdSchema) contentModel(def xsdDef) (*xsdContentModel, error) {
+	model := &xsdContentModel{
+		attributes: make(map[xsdName]xsdAttributeUse),
+		mixed:      def.el.SelectAttrValue("mixed", "false") == "true",
+	}
+
+	for _, child := range def.el.ChildElements() {
+		if child.NamespaceURI() != xsdNamespace {
+			continue
+		}
+
+		switch child.Tag {
+		case "simpleContent":
+			if err := s.simpleContent(xsdDef{el: child, doc: def.doc}, model); err != nil {
+				return nil, err
+			}
+		case "complexContent":
+			if child.SelectAttrValue("mixed", "") == "true" {
+				model.mixed = true
+			}
+			if err := s.complexContent(xsdDef{el: child, doc: def.doc}, model); err != nil {
+				return nil, err
+			}
+		default:
+			if err := s.addContent(xsdDef{el: child, doc: def.doc}, model); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return model, nil
+}
+
+// addContent adds a particle or attribute definition to the content model
+func (s *XsdSchema) addContent(def xsdDef, model *xsdContentModel) error {
+	switch def.el.Tag {
+	case "sequence", "choice", "all", "group":
+		particle, err := s.particle(def)
+		if err != nil {
+			return err
+		}
+		if particle == nil {
+			return nil
+		}
+		if model.particle == nil {
+			model.particle = particle
+		} else {
+			// Extensions append their particle to the one of the base type
+			model.particle = &xsdParticle{kind: particleSequence, children: []*xsdParticle{model.particle, particle}, min: 1, max: 1}
+		}
+	case "attribute", "attributeGroup", "anyAttribute":
+		return s.addAttributes(def, model)
+	}
+
+	return nil
+}
+
+// simpleContent resolves a complex type with simple content
+func (s *XsdSchema) simpleContent(def xsdDef, model *xsdContentModel) error {
+	for _, derivation := range def.el.ChildElements() {
+		if !isXsdElement(derivation, "extension") && !isXsdElement(derivation, "restriction") {
+			continue
+		}
+
+		derivationDef := xsdDef{el: derivation, doc: def.doc}
+		base := resolveQName(derivationDef, derivation.SelectAttrValue("base", ""))
+		if baseDef, found := s.complexTypes[base]; found {
+			baseModel, err := s.contentModel(baseDef)
+			if err != nil {
+				return err
+			}
+			model.attributes = baseModel.attributes
+			model.anyAttribute = baseModel.anyAttribute
+			model.simpleType = baseModel.simpleType
+		} else {
+			model.simpleType = &derivationDef
+		}
+
+		// Restrictions may add facets to the simple type of the base
+		if isXsdElement(derivation, "restriction") && hasFacets(derivation) {
+			model.simpleType = &derivationDef
+		}
+
+		for _, child := range derivation.ChildElements() {
+			if err := s.addAttributes(xsdDef{el: child, doc: def.doc}, model); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// complexContent resolves a complex type derived from another complex type
+func (s *XsdSchema) complexContent(def xsdDef, model *xsdContentModel) error {
+	for _, derivation := range def.el.ChildElements() {
+		if !isXsdElement(derivation, "extension") && !isXsdElement(derivation, "restriction") {
+			continue
+		}
+
+		derivationDef := xsdDef{el: derivation, doc: def.doc}
+		base := resolveQName(derivationDef, derivation.SelectAttrValue("base", ""))
+		if baseDef, found := s.complexTypes[base]; found {
+			baseModel, err := s.contentModel(baseDef)
+			if err != nil {
+				return err
+			}
+			model.attributes = baseModel.attributes
+			model.anyAttribute = baseModel.anyAttribute
+			model.mixed = model.mixed || baseModel.mixed
+			// Restrictions restate the content model of the base
+			if isXsdElement(derivation, "extension") {
+				model.particle = baseModel.particle
+			}
+		} else if base.space != xsdNamespace || base.local != "anyType" {
+			return fmt.Errorf("type %s is not declared in the schema", base)
+		}
+
+		for _, child := range derivation.ChildElements() {
+			if child.NamespaceURI() != xsdNamespace {
+				continue
+			}
+			if err := s.addContent(xsdDef{el: child, doc: def.doc}, model); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addAttributes adds the attribute uses of an attribute, attributeGroup or anyAttribute definition
+func (s *XsdSchema) addAttributes(def xsdDef, model *xsdContentModel) error {
+	if def.el.NamespaceURI() != xsdNamespace {
+		return nil
+	}
+
+	switch def.el.Tag {
+	case "anyAttribute":
+		model.anyAttribute = true
+	case "attributeGroup":
+		if ref := def.el.SelectAttrValue("ref", ""); ref != "" {
+			group, found := s.attributeGroups[resolveQName(def, ref)]
+			if !found {
+				return fmt.Errorf("attribute group %s is not declared in the schema", resolveQName(def, ref))
+			}
+			def = group
+		}
+		for _, child := range def.el.ChildElements() {
+			if err := s.addAttributes(xsdDef{el: child, doc: def.doc}, model); err != nil {
+				return err
+			}
+		}
+	case "attribute":
+		use := xsdAttributeUse{
+			def:      def,
+			required: def.el.SelectAttrValue("use", "") == "required",
+		}
+
+		if ref := def.el.SelectAttrValue("ref", ""); ref != "" {
+			use.name = resolveQName(def, ref)
+			global, found := s.attributes[use.name]
+			if !found {
+				if use.name.space == "http://www.w3.org/XML/1998/namespace" {
+					// Attributes like xml:lang are allowed without validating them
+					global = xsdDef{el: etree.NewElement("attribute"), doc: def.doc}
+				} else {
+					return fmt.Errorf("attribute %s is not declared in the schema", use.name)
+				}
+			}
+			use.def = global
+		} else {
+			use.name = xsdName{local: def.el.SelectAttrValue("name", "")}
+			form := def.el.SelectAttrValue("form", "")
+			if form == "qualified" || (form == "" && def.doc.qualifiedAttributes) {
+				use.name.space = def.doc.targetNamespace
+			}
+		}
+
+		if def.el.SelectAttrValue("use", "") == "prohibited" {
+			delete(model.attributes, use.name)
+			return nil
+		}
+
+		model.attributes[use.name] = use
+	}
+
+	return nil
+}
+
+// particle creates the particle for a sequence, choice, all, group, element or any definition
+func (s *XsdSchema) particle(def xsdDef) (*xsdParticle, error) {
+	minOccurs, maxOccurs := occurrences(def.el)
+	particle := &xsdParticle{def: def, min: minOccurs, max: maxOccurs}
+
+	switch def.el.Tag {
+	case "element":
+		particle.kind = particleElement
+		if ref := def.el.SelectAttrValue("ref", ""); ref != "" {
+			particle.name = resolveQName(def, ref)
+			global, found := s.elements[particle.name]
+			if !found {
+				return nil, fmt.Errorf("element %s is not declared in the schema", particle.name)
+			}
+			particle.def = global
+		} else {
+			particle.name = xsdName{local: def.el.SelectAttrValue("name", "")}
+			form := def.el.SelectAttrValue("form", "")
+			if form == "qualified" || (form == "" && def.doc.qualifiedElements) {
+				particle.name.space = def.doc.targetNamespace
+			}
+		}
+	case "any":
+		particle.kind = particleAny
+	case "group":
+		ref := def.el.SelectAttrValue("ref", "")
+		group, found := s.groups[resolveQName(def, ref)]
+		if !found {
+			return nil, fmt.Errorf("group %s is not declared in the schema", resolveQName(def, ref))
+		}
+		for _, child := range group.el.ChildElements() {
+			if child.NamespaceURI() != xsdNamespace || child.Tag == "annotation" {
+				continue
+			}
+			inner, err := s.particle(xsdDef{el: child, doc: group.doc})
+			if err != nil {
+				return nil, err
+			}
+			inner.min, inner.max = minOccurs, maxOccurs
+			return inner, nil
+		}
+		return nil, nil
+	case "sequence", "choice", "all":
+		switch def.el.Tag {
+		case "sequence":
+			particle.kind = particleSequence
+		case "choice":
+			particle.kind = particleChoice
+		default:
+			particle.kind = particleAll
+		}
+		for _, child := range def.el.ChildElements() {
+			if child.NamespaceURI() != xsdNamespace || child.Tag == "annotation" {
+				continue
+			}
+			inner, err := s.particle(xsdDef{el: child, doc: def.doc})
+			if err != nil {
+				return nil, err
+			}
+			if inner != nil {
+				particle.children = append(particle.children, inner)
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	return particle, nil
+}
+
+// xsdAssignment assigns an element declaration to a child element
+type xsdAssignment struct {
+	index int
+	// decl is nil for elements which are not validated, e.g. matched by a lax wildcard
+	decl *xsdDef
+	// undeclared is set for elements matched by a strict wildcard without a global declaration
+	undeclared bool
+}
+
+// xsdAssignments is an immutable list of assignments. Lists are concatenated by referencing them rather than copying them,
+// so matching a repeated particle takes linear time in the number of repetitions
+type xsdAssignments struct {
+	// head and tail are the concatenated lists, unless the list consists of a single assignment
+	head, tail *xsdAssignments
+	assignment xsdAssignment
+}
+
+// singleAssignment returns the list consisting of the given assignment
+func singleAssignment(assignment xsdAssignment) *xsdAssignments {
+	return &xsdAssignments{assignment: assignment}
+}
+
+// concatAssignments returns the concatenation of the lists, either of which may be nil
+func concatAssignments(head, tail *xsdAssignments) *xsdAssignments {
+	if head == nil {
+		return tail
+	}
+	if tail == nil {
+		return head
+	}
+
+	return &xsdAssignments{head: head, tail: tail}
+}
+
+// all returns the assignments of the list in order
+func (a *xsdAssignments) all() []xsdAssignment {
+	result := make([]xsdAssignment, 0)
+	// The lists are as deep as the number of repetitions, they are traversed without recursion
+	stack := []*xsdAssignments{a}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch {
+		case node == nil:
+		case node.head == nil:
+			result = append(result, node.assignment)
+		default:
+			stack = append(stack, node.tail, node.head)
+		}
+	}
+
+	return result
+}
+
+// xsdMatch represents a possible way of matching child elements against a particle
+type xsdMatch struct {
+	// pos is the index of the first child element not matched
+	pos         int
+	assignments *xsdAssignments
+	// complete is false if the particle requires further elements
+	complete bool
+	// expected names the elements which would have been required to complete the match
+	expected []string
+}
+
+// match matches the child elements starting at pos against the particle and its occurrences.
+// It returns the possible matches, at most one per end position
+func (s *XsdSchema) match(particle *xsdParticle, children []*etree.Element, pos int) []xsdMatch {
+	results := make([]xsdMatch, 0)
+	current := []xsdMatch{{pos: pos, complete: true}}
+	seen := map[int]bool{}
+
+	for count := 0; len(current) > 0; count++ {
+		next := make([]xsdMatch, 0)
+		nextSeen := map[int]bool{}
+
+		for _, m := range current {
+			if particle.max < 0 || count < particle.max {
+				for _, r := range s.matchOnce(particle, children, m.pos) {
+					// Only repetitions consuming elements are considered to prevent endless loops
+					if r.pos <= m.pos && count >= particle.min {
+						// The particle could have been repeated, which is worth mentioning if the following elements don't match
+						if !r.complete {
+							m.expected = append(append([]string{}, m.expected...), r.expected...)
+						}
+						continue
+					}
+
+					combined := combineMatches(m, r)
+					if !r.complete {
+						// Keep incomplete matches for reporting
+						results = append(results, combined)
+						continue
+					}
+					if !nextSeen[r.pos] {
+						nextSeen[r.pos] = true
+						next = append(next, combined)
+					}
+				}
+			}
+
+			if count >= particle.min && !seen[m.pos] {
+				seen[m.pos] = true
+				results = append(results, m)
+			}
+		}
+
+		current = next
+	}
+
+	return results
+}
+
+// combineMatches appends the match r to the match m
+func combineMatches(m xsdMatch, r xsdMatch) xsdMatch {
+	expected := r.expected
+	if r.pos == m.pos {
+		expected = append(append([]string{}, m.expected...), r.expected...)
+	}
+
+	return xsdMatch{
+		pos:         r.pos,
+		assignments: concatAssignments(m.assignments, r.assignments),
+		complete:    r.complete,
+		expected:    expected,
+	}
+}
+
+// matchOnce matches a single occurrence of the particle
+func (s *XsdSchema) matchOnce(particle *xsdParticle, children []*etree.Element, pos int) []xsdMatch {
+	switch particle.kind {
+	case particleElement:
+		if pos < len(children) && elementName(children[pos]) == particle.name {
+			decl := particle.def
+			return []xsdMatch{{pos: pos + 1, assignments: singleAssignment(xsdAssignment{index: pos, decl: &decl}), complete: true}}
+		}
+		if pos < len(children) && isXsdElement(particle.def.el.Parent(), "schema") {
+			if decl, found := s.substitute(elementName(children[pos]), particle.name); found {
+				return []xsdMatch{{pos: pos + 1, assignments: singleAssignment(xsdAssignment{index: pos, decl: &decl}), complete: true}}
+			}
+		}
+		return []xsdMatch{{pos: pos, complete: false, expected: []string{particle.name.String()}}}
+	case particleAny:
+		if pos < len(children) && s.wildcardAllows(particle.def, elementName(children[pos])) {
+			return []xsdMatch{{pos: pos + 1, assignments: singleAssignment(s.wildcardAssignment(particle.def, children[pos], pos)), complete: true}}
+		}
+		return []xsdMatch{{pos: pos, complete: false, expected: []string{"any element"}}}
+	case particleSequence:
+		current := []xsdMatch{{pos: pos, complete: true}}
+		incomplete := make([]xsdMatch, 0)
+		for _, child := range particle.children {
+			next := make([]xsdMatch, 0)
+			seen := map[int]bool{}
+			for _, m := range current {
+				for _, r := range s.match(child, children, m.pos) {
+					combined := combineMatches(m, r)
+					if !r.complete {
+						incomplete = append(incomplete, combined)
+						continue
+					}
+					if !seen[r.pos] {
+						seen[r.pos] = true
+						next = append(next, combined)
+					}
+				}
+			}
+			current = next
+		}
+		return append(current, incomplete...)
+	case particleChoice:
+		results := make([]xsdMatch, 0)
+		expected := make([]string, 0)
+		for _, child := range particle.children {
+			for _, r := range s.match(child, children, pos) {
+				if !r.complete && r.pos == pos {
+					expected = append(expected, r.expected...)
+					continue
+				}
+				results = append(results, r)
+			}
+		}
+		if len(results) == 0 {
+			results = append(results, xsdMatch{pos: pos, complete: false, expected: expected})
+		}
+		return results
+	case particleAll:
+		return s.matchAll(particle, children, pos)
+	}
+
+	return nil
+}
+
+// substitute returns the declaration of the global element with the given name, if it is a member of the substitution group
+// of the head element, directly or through other members. Heads blocking substitutions can't be substituted
+func (s *XsdSchema) substitute(name xsdName, head xsdName) (xsdDef, bool) {
+	headDecl, found := s.elements[head]
+	if !found || blocksSubstitution(headDecl) {
+		return xsdDef{}, false
+	}
+
+	decl, found := s.elements[name]
+	if !found {
+		return xsdDef{}, false
+	}
+
+	seen := map[xsdName]bool{name: true}
+	for member := decl; ; {
+		group := member.el.SelectAttrValue("substitutionGroup", "")
+		if group == "" {
+			return xsdDef{}, false
+		}
+
+		groupName := resolveQName(member, group)
+		if groupName == head {
+			return decl, true
+		}
+		if seen[groupName] {
+			return xsdDef{}, false
+		}
+		seen[groupName] = true
+
+		if member, found = s.elements[groupName]; !found {
+			return xsdDef{}, false
+		}
+	}
+}
+
+// blocksSubstitution checks if the element declaration blocks substitutions, either by itself or by the default of its document
+func blocksSubstitution(decl xsdDef) bool {
+	block := decl.el.SelectAttr("block")
+	value := ""
+	switch {
+	case block != nil:
+		value = block.Value
+	case decl.el.Parent() != nil:
+		value = decl.el.Parent().SelectAttrValue("blockDefault", "")
+	}
+
+	for _, item := range strings.Fields(value) {
+		if item == "#all" || item == "substitution" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchAll matches the particles of an all group in any order
+func (s *XsdSchema) matchAll(particle *xsdParticle, children []*etree.Element, pos int) []xsdMatch {
+	used := make([]bool, len(particle.children))
+	result := xsdMatch{pos: pos, complete: true}
+
+	for result.pos < len(children) {
+		matched := false
+		for i, child := range particle.children {
+			if used[i] {
+				continue
+			}
+			for _, r := range s.matchOnce(child, children, result.pos) {
+				if r.complete && r.pos > result.pos {
+					used[i] = true
+					result.pos = r.pos
+					result.assignments = concatAssignments(result.assignments, r.assignments)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			break
+		}
+	}
+
+	for i, child := range particle.children {
+		if !used[i] && child.min > 0 {
+			result.complete = false
+			result.expected = append(result.expected, firstElements(child)...)
+		}
+	}
+
+	return []xsdMatch{result}
+}
+
+// firstElements returns the names of the elements a particle may start with
+func firstElements(particle *xsdParticle) []string {
+	switch particle.kind {
+	case particleElement:
+		return []string{particle.name.String()}
+	case particleAny:
+		return []string{"any element"}
+	case particleSequence:
+		for _, child := range particle.children {
+			if child.min > 0 {
+				return firstElements(child)
+			}
+		}
+		return nil
+	default:
+		result := make([]string, 0)
+		for _, child := range particle.children {
+			result = append(result, firstElements(child)...)
+		}
+		return result
+	}
+}
+
+// wildcardAllows checks if the wildcard allows elements with the given name
+func (s *XsdSchema) wildcardAllows(wildcard xsdDef, name xsdName) bool {
+	namespaces := strings.Fields(wildcard.el.SelectAttrValue("namespace", "##any"))
+	for _, namespace := range namespaces {
+		switch namespace {
+		case "##any":
+			return true
+		case "##other":
+			if name.space != wildcard.doc.targetNamespace && name.space != "" {
+				return true
+			}
+		case "##local":
+			if name.space == "" {
+				return true
+			}
+		case "##targetNamespace":
+			if name.space == wildcard.doc.targetNamespace {
+				return true
+			}
+		default:
+			if name.space == namespace {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// wildcardAssignment returns the declaration used for validating an element matched by a wildcard
+func (s *XsdSchema) wildcardAssignment(wildcard xsdDef, element *etree.Element, index int) xsdAssignment {
+	if wildcard.el.SelectAttrValue("processContents", "strict") == "skip" {
+		return xsdAssignment{index: index}
+	}
+
+	if decl, found := s.elements[elementName(element)]; found {
+		return xsdAssignment{index: index, decl: &decl}
+	}
+
+	return xsdAssignment{index: index, undeclared: wildcard.el.SelectAttrValue("processContents", "strict") == "strict"}
+}
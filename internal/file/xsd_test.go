@@ -0,0 +1,436 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/denglertai/gonfig/internal/general"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXsdValidation(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	server, err := LoadXsd(path.Join(wd, "testdata/xsd/server.xsd"), nil)
+	assert.NoError(t, err)
+
+	catalog, err := LoadXsdCatalog(path.Join(wd, "testdata/xsd/catalog"))
+	assert.NoError(t, err)
+
+	logging, err := LoadXsd(path.Join(wd, "testdata/xsd/catalog/jboss-domain-logging.xsd"), catalog)
+	assert.NoError(t, err)
+
+	shapes, err := LoadXsd(path.Join(wd, "testdata/xsd/shapes.xsd"), nil)
+	assert.NoError(t, err)
+
+	const service = `<Service name="Catalina"><Connector port="8080"/><Engine name="Catalina"/></Service>`
+	const logging10 = `xmlns="urn:jboss:domain:logging:1.0"`
+
+	testCases := []struct {
+		desc       string
+		schema     *XsdSchema
+		content    string
+		violations []XsdViolation
+	}{
+		{
+			desc:    "Valid",
+			schema:  server,
+			content: `<Server port="8005"><Listener className="a"/>` + service + `</Server>`,
+		},
+		{
+			desc:    "Invalid attribute values",
+			schema:  server,
+			content: `<Server port="abc"><Service name="Catalina"><Connector port="0" protocol="HTTP/2" secure="yes"/><Engine name="Catalina"/></Service></Server>`,
+			violations: []XsdViolation{
+				{Path: "/Server/@port", Message: "value 'abc' is not a valid int"},
+				{Path: "/Server/Service/Connector/@port", Message: "value '0' violates minInclusive 1"},
+				{Path: "/Server/Service/Connector/@protocol", Message: "value 'HTTP/2' is not one of [HTTP/1.1, AJP/1.3]"},
+				{Path: "/Server/Service/Connector/@secure", Message: "value 'yes' is not a valid boolean"},
+			},
+		},
+		{
+			desc:    "Missing and unknown attributes",
+			schema:  server,
+			content: `<Server shutdown="x" unknown="y">` + service + `</Server>`,
+			violations: []XsdViolation{
+				{Path: "/Server/@unknown", Message: "attribute unknown is not allowed"},
+				{Path: "/Server", Message: "missing required attribute port"},
+			},
+		},
+		{
+			desc:    "Unexpected element",
+			schema:  server,
+			content: `<Server port="8005">` + service + `<Listener className="a"/></Server>`,
+			violations: []XsdViolation{
+				{Path: "/Server/Listener", Message: "element Listener is not expected here, expected Service"},
+			},
+		},
+		{
+			desc:    "Incomplete content",
+			schema:  server,
+			content: `<Server port="8005"><Service name="Catalina"><Connector port="8080"/></Service></Server>`,
+			violations: []XsdViolation{
+				{Path: "/Server/Service", Message: "content of element Service is incomplete, expected Connector or Engine"},
+			},
+		},
+		{
+			desc:    "Choice and simple content",
+			schema:  server,
+			content: `<Server port="8005"><Service name="Catalina"><Connector port="8080"/><Engine name="Catalina"><Host>a</Host><Realm className="b">c</Realm><Host><x/></Host></Engine></Service></Server>`,
+			violations: []XsdViolation{
+				{Path: "/Server/Service/Engine/Host[2]", Message: "element Host must not contain elements"},
+			},
+		},
+		{
+			desc:    "Undeclared root",
+			schema:  server,
+			content: `<Client/>`,
+			violations: []XsdViolation{
+				{Path: "/Client", Message: "element Client is not declared in the schema"},
+			},
+		},
+		{
+			desc:    "Namespaces, includes and all groups",
+			schema:  logging,
+			content: `<subsystem ` + logging10 + `><console-handler name="CONSOLE"><level name="INFO"/></console-handler><root-logger><handlers><handler name="CONSOLE" autoflush="true"/></handlers><level name="DEBUG"/></root-logger></subsystem>`,
+		},
+		{
+			desc:    "Invalid namespaced document",
+			schema:  logging,
+			content: `<subsystem ` + logging10 + `><console-handler name="1"><level name="VERBOSE"/></console-handler><root-logger><handlers/></root-logger></subsystem>`,
+			violations: []XsdViolation{
+				{Path: "/ns:subsystem/ns:console-handler/@name", Message: "value '1' is not a valid ID"},
+				{Path: "/ns:subsystem/ns:console-handler/ns:level/@name", Message: "value 'VERBOSE' is not one of [TRACE, DEBUG, INFO, WARN, ERROR]"},
+				{Path: "/ns:subsystem/ns:root-logger", Message: "content of element {urn:jboss:domain:logging:1.0}root-logger is incomplete, expected {urn:jboss:domain:logging:1.0}level"},
+				{Path: "/ns:subsystem/ns:root-logger/ns:handlers", Message: "content of element {urn:jboss:domain:logging:1.0}handlers is incomplete, expected {urn:jboss:domain:logging:1.0}handler"},
+			},
+		},
+		{
+			desc:    "Wrong namespace",
+			schema:  logging,
+			content: `<subsystem xmlns="urn:jboss:domain:logging:2.0"/>`,
+			violations: []XsdViolation{
+				{Path: "/ns:subsystem", Message: "element {urn:jboss:domain:logging:2.0}subsystem is not declared in the schema"},
+			},
+		},
+		{
+			desc:    "Substitution groups",
+			schema:  shapes,
+			content: `<drawing><label>a</label><circle radius="2" color="red"/><square/><roundedSquare/></drawing>`,
+		},
+		{
+			desc:    "Invalid substitution group members",
+			schema:  shapes,
+			content: `<drawing><circle radius="0"/><shape/></drawing>`,
+			violations: []XsdViolation{
+				{Path: "/drawing/circle/@radius", Message: "value '0' is out of range for positiveInteger"},
+				{Path: "/drawing/shape", Message: "element shape is abstract"},
+			},
+		},
+		{
+			desc:    "Elements outside of substitution groups",
+			schema:  shapes,
+			content: `<drawing><title>a</title><circle radius="1"/></drawing>`,
+			violations: []XsdViolation{
+				{Path: "/drawing/title", Message: "element title is not expected here, expected label or shape"},
+			},
+		},
+		{
+			desc:    "Undeclared substitute",
+			schema:  shapes,
+			content: `<drawing><circle radius="1"/><triangle/></drawing>`,
+			violations: []XsdViolation{
+				{Path: "/drawing/triangle", Message: "element triangle is not expected here, expected shape"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			document := etree.NewDocument()
+			assert.NoError(t, document.ReadFromString(tC.content))
+
+			err := tC.schema.Validate(document)
+			if tC.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationError *XsdValidationError
+			assert.True(t, errors.As(err, &validationError))
+			assert.ElementsMatch(t, tC.violations, validationError.Violations)
+		})
+	}
+}
+
+func TestXsdConstructs(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	schema, err := LoadXsd(path.Join(wd, "testdata/xsd/constructs.xsd"), nil)
+	assert.NoError(t, err)
+
+	const ext = `xmlns:ext="urn:gonfig:ext" xmlns:o="urn:other"`
+	const xsi = `xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`
+
+	testCases := []struct {
+		desc       string
+		content    string
+		violations []XsdViolation
+	}{
+		{
+			desc:    "Group references",
+			content: `<config><host>a</host><port>80</port></config>`,
+		},
+		{
+			desc:    "Invalid group references",
+			content: `<config><port>80</port></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/port", Message: "element port is not expected here, expected host"},
+			},
+		},
+		{
+			desc:    "Attribute groups and fixed values",
+			content: `<config version="2"><host>a</host></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/@version", Message: "value '2' doesn't match the fixed value '1'"},
+			},
+		},
+		{
+			desc:    "Complex content extensions and restrictions",
+			content: `<config ` + xsi + `><host>a</host><item id="a" note="b"/><item id="b" name="c" xsi:type="namedItem"/><item id="c" xsi:type="namedItem"/><strict id="d" note="e"/></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/item[3]", Message: "missing required attribute name"},
+				{Path: "/config/strict/@note", Message: "attribute note is not allowed"},
+			},
+		},
+		{
+			desc:    "Undeclared xsi:type",
+			content: `<config ` + xsi + `><host>a</host><item id="a" xsi:type="unknown"/></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/item", Message: "type unknown given by xsi:type is not declared in the schema"},
+			},
+		},
+		{
+			desc:    "Simple content extensions and restrictions",
+			content: `<config><host>a</host><title lang="en">Hello, World</title></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/title", Message: "value 'Hello, World' violates maxLength 5"},
+			},
+		},
+		{
+			desc:    "Mixed content",
+			content: `<config><host>a<b/></host><description>a <b>b</b> c</description></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/host", Message: "element host must not contain elements"},
+			},
+		},
+		{
+			desc:    "Lists and unions",
+			content: `<config><host>a</host><ports>80 443 70000</ports><threads>none</threads></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/ports", Message: "value '70000' is out of range for unsignedShort"},
+				{Path: "/config/threads", Message: "value 'none' doesn't match any of the member types"},
+			},
+		},
+		{
+			desc:    "Valid lists and unions",
+			content: `<config><host>a</host><ports>80 443</ports><threads>auto</threads><version>1.0</version></config>`,
+		},
+		{
+			desc:    "Patterns and length facets",
+			content: `<config><host>a</host><version>1.0.0</version></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/version", Message: "value '1.0.0' doesn't match the pattern '[0-9]+\\.[0-9]+'"},
+			},
+		},
+		{
+			desc:    "Imports",
+			content: `<config ` + ext + `><host>a</host><ext:extra>maybe</ext:extra></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/ext:extra", Message: "value 'maybe' is not a valid boolean"},
+			},
+		},
+		{
+			desc:    "Lax wildcards and any attributes",
+			content: `<config ` + ext + `><host>a</host><extensions a="b"><o:anything><o:x/></o:anything><ext:extra>no</ext:extra></extensions></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/extensions/ext:extra", Message: "value 'no' is not a valid boolean"},
+			},
+		},
+		{
+			desc:    "Wildcard namespaces",
+			content: `<config ` + ext + `><host>a</host><extensions><local/></extensions></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/extensions/local", Message: "element local is not expected here"},
+			},
+		},
+		{
+			desc:    "Strict wildcards",
+			content: `<config ` + ext + `><host>a</host><plugins><ext:extra>true</ext:extra><ext:unknown/></plugins></config>`,
+			violations: []XsdViolation{
+				{Path: "/config/plugins/ext:unknown", Message: "element {urn:gonfig:ext}unknown is not declared in the schema"},
+			},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			document := etree.NewDocument()
+			assert.NoError(t, document.ReadFromString(tC.content))
+
+			err := schema.Validate(document)
+			if tC.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validationError *XsdValidationError
+			assert.True(t, errors.As(err, &validationError))
+			if validationError != nil {
+				assert.ElementsMatch(t, tC.violations, validationError.Violations)
+			}
+		})
+	}
+}
+
+func TestXsdRedefineUnsupported(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	_, err = LoadXsd(path.Join(wd, "testdata/xsd/redefine.xsd"), nil)
+	assert.ErrorContains(t, err, "redefine is not supported")
+}
+
+func TestXsdValidationLargeDocument(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	schema, err := LoadXsd(path.Join(wd, "testdata/xsd/server.xsd"), nil)
+	assert.NoError(t, err)
+
+	const count = 20000
+
+	content := new(strings.Builder)
+	content.WriteString(`<Server port="8005">`)
+	for i := 0; i < count; i++ {
+		content.WriteString(`<Listener className="a"/>`)
+	}
+	content.WriteString(`<Service name="Catalina">`)
+	for i := 0; i < count; i++ {
+		content.WriteString(`<Connector port="8080"/>`)
+	}
+	content.WriteString(`<Engine name="Catalina">`)
+	for i := 0; i < count; i++ {
+		content.WriteString(`<Host>a</Host>`)
+	}
+	content.WriteString(`<Host><x/></Host></Engine></Service></Server>`)
+
+	document := etree.NewDocument()
+	assert.NoError(t, document.ReadFromString(content.String()))
+
+	// Matching repeated particles and building the paths of the violations has to take linear time
+	start := time.Now()
+	err = schema.Validate(document)
+	assert.Less(t, time.Since(start), 10*time.Second)
+
+	var validationError *XsdValidationError
+	assert.True(t, errors.As(err, &validationError))
+	if validationError != nil {
+		assert.Equal(t, []XsdViolation{{Path: fmt.Sprintf("/Server/Service/Engine/Host[%d]", count+1), Message: "element Host must not contain elements"}}, validationError.Violations)
+	}
+}
+
+func TestXsdBuiltinTypes(t *testing.T) {
+	testCases := []struct {
+		typeName string
+		valid    []string
+		invalid  []string
+	}{
+		{typeName: "int", valid: []string{"1", "-2147483648", " 42 "}, invalid: []string{"2147483648", "1.5", "a"}},
+		{typeName: "unsignedShort", valid: []string{"0", "65535"}, invalid: []string{"-1", "65536"}},
+		{typeName: "positiveInteger", valid: []string{"1", "+99999999999999999999"}, invalid: []string{"0"}},
+		{typeName: "decimal", valid: []string{"1.5", "-.5", "3."}, invalid: []string{"1e3", "."}},
+		{typeName: "double", valid: []string{"1e3", "INF", "NaN", "-0.5"}, invalid: []string{"inf", "0x10", "a"}},
+		{typeName: "boolean", valid: []string{"true", "0"}, invalid: []string{"yes", "TRUE"}},
+		{typeName: "date", valid: []string{"2024-01-31", "2024-01-31Z"}, invalid: []string{"2024-1-31", "31.01.2024"}},
+		{typeName: "dateTime", valid: []string{"2024-01-31T10:00:00", "2024-01-31T10:00:00.5+01:00"}, invalid: []string{"2024-01-31 10:00:00"}},
+		{typeName: "duration", valid: []string{"P1D", "PT5M", "-P1Y2M3DT4H5M6.5S"}, invalid: []string{"P", "PT", "5M"}},
+		{typeName: "NCName", valid: []string{"abc", "_a-b.c"}, invalid: []string{"1a", "a:b"}},
+		{typeName: "QName", valid: []string{"a:b", "b"}, invalid: []string{"a:", ":b"}},
+		{typeName: "token", valid: []string{"a b"}, invalid: []string{" a", "a  b"}},
+		{typeName: "hexBinary", valid: []string{"0fA1"}, invalid: []string{"0fA"}},
+		{typeName: "base64Binary", valid: []string{"aGk="}, invalid: []string{"aGk"}},
+		{typeName: "string", valid: []string{"", " anything "}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.typeName, func(t *testing.T) {
+			for _, value := range tC.valid {
+				assert.NoError(t, validateBuiltinType(value, tC.typeName), value)
+			}
+			for _, value := range tC.invalid {
+				assert.Error(t, validateBuiltinType(value, tC.typeName), value)
+			}
+		})
+	}
+}
+
+func TestXmlProcessorXsd(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	catalog, err := LoadXsdCatalog(path.Join(wd, "testdata/xsd/catalog"))
+	assert.NoError(t, err)
+
+	t.Setenv("LEVEL", "INFO")
+
+	output := new(bytes.Buffer)
+	processor := NewFileProcessor(path.Join(wd, "testdata/xsd/logging.xml"), general.Undefined, output)
+	processor.XsdCatalog = catalog
+	assert.NoError(t, processor.Process())
+	assert.Contains(t, output.String(), `<level name="INFO"/>`)
+	assert.Contains(t, output.String(), `xsi:schemaLocation="urn:jboss:domain:logging:1.0`)
+
+	t.Setenv("LEVEL", "VERBOSE")
+
+	output = new(bytes.Buffer)
+	processor = NewFileProcessor(path.Join(wd, "testdata/xsd/logging.xml"), general.Undefined, output)
+	processor.XsdCatalog = catalog
+	err = processor.Process()
+	assert.ErrorContains(t, err, "/ns:subsystem/ns:console-handler/ns:level/@name: value 'VERBOSE' is not one of")
+	assert.Empty(t, output.String())
+
+	// Without catalog, the schema location is ignored
+	output = new(bytes.Buffer)
+	processor = NewFileProcessor(path.Join(wd, "testdata/xsd/logging.xml"), general.Undefined, output)
+	assert.NoError(t, processor.Process())
+
+	// XSD validation is only supported for XML files
+	processor = NewFileProcessor(path.Join(wd, "testdata/yaml/deployment.yaml"), general.Undefined, new(bytes.Buffer))
+	processor.XsdCatalog = catalog
+	assert.Error(t, processor.Process())
+}
+
+func TestXsdCatalogMissingSchema(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	catalog, err := LoadXsdCatalog(t.TempDir())
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(path.Join(wd, "testdata/xsd/logging.xml"))
+	assert.NoError(t, err)
+
+	document := etree.NewDocument()
+	assert.NoError(t, document.ReadFromBytes(content))
+
+	_, err = LoadXsdFromDocument(document, catalog)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "jboss-domain-logging.xsd"))
+}
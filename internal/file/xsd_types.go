@@ -0,0 +1,324 @@
+package file
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/beevik/etree"
+	"github.com/denglertai/gonfig/pkg/logging"
+)
+
+var (
+	xsdDecimalRe    = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	xsdIntegerRe    = regexp.MustCompile(`^[+-]?\d+$`)
+	xsdNCNameRe     = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}._\-\x{B7}]*$`)
+	xsdNameRe       = regexp.MustCompile(`^[\p{L}_:][\p{L}\p{N}._:\-\x{B7}]*$`)
+	xsdNMTokenRe    = regexp.MustCompile(`^[\p{L}\p{N}._:\-\x{B7}]+$`)
+	xsdLanguageRe   = regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)
+	xsdDateRe       = regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+	xsdTimeRe       = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	xsdDateTimeRe   = regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	xsdDurationRe   = regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+	xsdGYearRe      = regexp.MustCompile(`^-?\d{4,}(Z|[+-]\d{2}:\d{2})?$`)
+	xsdGYearMonthRe = regexp.MustCompile(`^-?\d{4,}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+	xsdGMonthRe     = regexp.MustCompile(`^--\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+	xsdGMonthDayRe  = regexp.MustCompile(`^--\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+	xsdGDayRe       = regexp.MustCompile(`^---\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+)
+
+// xsdIntegerRanges holds the bounds of the built-in integer types, nil means unbounded
+var xsdIntegerRanges = map[string][2]*big.Int{
+	"integer":            {nil, nil},
+	"long":               {big.NewInt(-1 << 63), big.NewInt(1<<63 - 1)},
+	"int":                {big.NewInt(-1 << 31), big.NewInt(1<<31 - 1)},
+	"short":              {big.NewInt(-1 << 15), big.NewInt(1<<15 - 1)},
+	"byte":               {big.NewInt(-1 << 7), big.NewInt(1<<7 - 1)},
+	"nonNegativeInteger": {big.NewInt(0), nil},
+	"positiveInteger":    {big.NewInt(1), nil},
+	"nonPositiveInteger": {nil, big.NewInt(0)},
+	"negativeInteger":    {nil, big.NewInt(-1)},
+	"unsignedLong":       {big.NewInt(0), new(big.Int).SetUint64(1<<64 - 1)},
+	"unsignedInt":        {big.NewInt(0), big.NewInt(1<<32 - 1)},
+	"unsignedShort":      {big.NewInt(0), big.NewInt(1<<16 - 1)},
+	"unsignedByte":       {big.NewInt(0), big.NewInt(1<<8 - 1)},
+}
+
+// validateBuiltinType validates the value against the built-in type with the given local name.
+// Unknown types are accepted
+func validateBuiltinType(value string, typeName string) error {
+	// All types but string and normalizedString collapse whitespace
+	collapsed := strings.Join(strings.Fields(value), " ")
+
+	valid := true
+	switch typeName {
+	case "string", "normalizedString", "anySimpleType", "anyType", "anyURI":
+	case "token":
+		valid = collapsed == value
+	case "boolean":
+		valid = slices.Contains([]string{"true", "false", "1", "0"}, collapsed)
+	case "decimal":
+		valid = xsdDecimalRe.MatchString(collapsed)
+	case "float", "double":
+		if collapsed != "INF" && collapsed != "-INF" && collapsed != "NaN" {
+			_, err := strconv.ParseFloat(collapsed, 64)
+			valid = err == nil && !strings.ContainsAny(collapsed, "xXpP_") && !strings.EqualFold(collapsed, "inf") && !strings.EqualFold(collapsed, "nan")
+		}
+	case "NCName", "ID", "IDREF", "ENTITY":
+		valid = xsdNCNameRe.MatchString(collapsed)
+	case "Name":
+		valid = xsdNameRe.MatchString(collapsed)
+	case "NMTOKEN":
+		valid = xsdNMTokenRe.MatchString(collapsed)
+	case "IDREFS", "ENTITIES", "NMTOKENS":
+		items := strings.Fields(collapsed)
+		valid = len(items) > 0
+		for _, item := range items {
+			if !xsdNMTokenRe.MatchString(item) {
+				valid = false
+			}
+		}
+	case "QName", "NOTATION":
+		prefix, local, found := strings.Cut(collapsed, ":")
+		valid = xsdNCNameRe.MatchString(prefix) && (!found || xsdNCNameRe.MatchString(local))
+	case "language":
+		valid = xsdLanguageRe.MatchString(collapsed)
+	case "date":
+		valid = xsdDateRe.MatchString(collapsed)
+	case "time":
+		valid = xsdTimeRe.MatchString(collapsed)
+	case "dateTime":
+		valid = xsdDateTimeRe.MatchString(collapsed)
+	case "duration":
+		valid = xsdDurationRe.MatchString(collapsed) && !strings.HasSuffix(collapsed, "P") && !strings.HasSuffix(collapsed, "T")
+	case "gYear":
+		valid = xsdGYearRe.MatchString(collapsed)
+	case "gYearMonth":
+		valid = xsdGYearMonthRe.MatchString(collapsed)
+	case "gMonth":
+		valid = xsdGMonthRe.MatchString(collapsed)
+	case "gMonthDay":
+		valid = xsdGMonthDayRe.MatchString(collapsed)
+	case "gDay":
+		valid = xsdGDayRe.MatchString(collapsed)
+	case "base64Binary":
+		_, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		valid = err == nil
+	case "hexBinary":
+		_, err := hex.DecodeString(collapsed)
+		valid = err == nil
+	default:
+		bounds, isInteger := xsdIntegerRanges[typeName]
+		if !isInteger {
+			logging.Debug("Unsupported built-in type, accepting the value", "type", typeName)
+			return nil
+		}
+
+		if !xsdIntegerRe.MatchString(collapsed) {
+			valid = false
+			break
+		}
+
+		i, _ := new(big.Int).SetString(strings.TrimPrefix(collapsed, "+"), 10)
+		if (bounds[0] != nil && i.Cmp(bounds[0]) < 0) || (bounds[1] != nil && i.Cmp(bounds[1]) > 0) {
+			return fmt.Errorf("value '%s' is out of range for %s", value, typeName)
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("value '%s' is not a valid %s", value, typeName)
+	}
+
+	return nil
+}
+
+// validateTypeReference validates the value against the type referenced by the type attribute of a declaration
+// or the base attribute of a derivation
+func (s *XsdSchema) validateTypeReference(value string, def xsdDef) error {
+	if isXsdElement(def.el, "restriction") || isXsdElement(def.el, "extension") {
+		return s.validateRestriction(value, def)
+	}
+
+	typeName := def.el.SelectAttrValue("type", "")
+	if typeName == "" {
+		return nil
+	}
+
+	return s.validateNamedType(value, resolveQName(def, typeName))
+}
+
+// validateNamedType validates the value against the simple type with the given name
+func (s *XsdSchema) validateNamedType(value string, name xsdName) error {
+	if name.space == xsdNamespace {
+		return validateBuiltinType(value, name.local)
+	}
+
+	if def, found := s.simpleTypes[name]; found {
+		return s.validateSimpleType(value, def)
+	}
+
+	if def, found := s.complexTypes[name]; found {
+		model, err := s.contentModel(def)
+		if err != nil {
+			return err
+		}
+		if model.simpleType != nil {
+			return s.validateTypeReference(value, *model.simpleType)
+		}
+	}
+
+	return fmt.Errorf("type %s is not declared in the schema", name)
+}
+
+// validateSimpleType validates the value against a simpleType definition
+func (s *XsdSchema) validateSimpleType(value string, def xsdDef) error {
+	for _, child := range def.el.ChildElements() {
+		if child.NamespaceURI() != xsdNamespace {
+			continue
+		}
+
+		childDef := xsdDef{el: child, doc: def.doc}
+		switch child.Tag {
+		case "restriction":
+			return s.validateRestriction(value, childDef)
+		case "list":
+			for _, item := range strings.Fields(value) {
+				if err := s.validateItemType(item, childDef, "itemType"); err != nil {
+					return err
+				}
+			}
+			return nil
+		case "union":
+			return s.validateUnion(value, childDef)
+		}
+	}
+
+	return nil
+}
+
+// validateItemType validates the value against the type given by the attribute or the inline simpleType of the definition
+func (s *XsdSchema) validateItemType(value string, def xsdDef, attribute string) error {
+	if typeName := def.el.SelectAttrValue(attribute, ""); typeName != "" {
+		return s.validateNamedType(value, resolveQName(def, typeName))
+	}
+
+	for _, child := range def.el.ChildElements() {
+		if isXsdElement(child, "simpleType") {
+			return s.validateSimpleType(value, xsdDef{el: child, doc: def.doc})
+		}
+	}
+
+	return nil
+}
+
+// validateUnion validates the value against the member types of a union
+func (s *XsdSchema) validateUnion(value string, def xsdDef) error {
+	for _, member := range strings.Fields(def.el.SelectAttrValue("memberTypes", "")) {
+		if s.validateNamedType(value, resolveQName(def, member)) == nil {
+			return nil
+		}
+	}
+
+	for _, child := range def.el.ChildElements() {
+		if isXsdElement(child, "simpleType") && s.validateSimpleType(value, xsdDef{el: child, doc: def.doc}) == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("value '%s' doesn't match any of the member types", value)
+}
+
+// hasFacets checks if the restriction contains facets
+func hasFacets(restriction *etree.Element) bool {
+	for _, child := range restriction.ChildElements() {
+		if child.NamespaceURI() == xsdNamespace && child.Tag != "annotation" && child.Tag != "simpleType" &&
+			child.Tag != "attribute" && child.Tag != "attributeGroup" && child.Tag != "anyAttribute" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateRestriction validates the value against the base type and the facets of the restriction
+func (s *XsdSchema) validateRestriction(value string, def xsdDef) error {
+	if err := s.validateItemType(value, def, "base"); err != nil {
+		return err
+	}
+
+	if isXsdElement(def.el, "extension") {
+		return nil
+	}
+
+	collapsed := strings.Join(strings.Fields(value), " ")
+	enumeration := make([]string, 0)
+	patterns := make([]string, 0)
+
+	for _, facet := range def.el.ChildElements() {
+		if facet.NamespaceURI() != xsdNamespace {
+			continue
+		}
+
+		facetValue := facet.SelectAttrValue("value", "")
+		switch facet.Tag {
+		case "enumeration":
+			enumeration = append(enumeration, facetValue)
+		case "pattern":
+			patterns = append(patterns, facetValue)
+		case "length", "minLength", "maxLength":
+			limit, err := strconv.Atoi(facetValue)
+			if err != nil {
+				continue
+			}
+			length := utf8.RuneCountInString(value)
+			if (facet.Tag == "length" && length != limit) || (facet.Tag == "minLength" && length < limit) || (facet.Tag == "maxLength" && length > limit) {
+				return fmt.Errorf("value '%s' violates %s %d", value, facet.Tag, limit)
+			}
+		case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+			number, err := strconv.ParseFloat(collapsed, 64)
+			if err != nil {
+				continue
+			}
+			limit, err := strconv.ParseFloat(facetValue, 64)
+			if err != nil {
+				continue
+			}
+			if (facet.Tag == "minInclusive" && number < limit) || (facet.Tag == "maxInclusive" && number > limit) ||
+				(facet.Tag == "minExclusive" && number <= limit) || (facet.Tag == "maxExclusive" && number >= limit) {
+				return fmt.Errorf("value '%s' violates %s %s", value, facet.Tag, facetValue)
+			}
+		}
+	}
+
+	if len(enumeration) > 0 && !slices.Contains(enumeration, value) && !slices.Contains(enumeration, collapsed) {
+		return fmt.Errorf("value '%s' is not one of [%s]", value, strings.Join(enumeration, ", "))
+	}
+
+	// Multiple patterns of the same restriction are alternatives
+	if len(patterns) > 0 {
+		matched := false
+		for _, pattern := range patterns {
+			re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+			if err != nil {
+				logging.Debug("Unsupported pattern, ignoring it", "pattern", pattern, "err", err)
+				matched = true
+				break
+			}
+			if re.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("value '%s' doesn't match the pattern '%s'", value, strings.Join(patterns, "|"))
+		}
+	}
+
+	return nil
+}
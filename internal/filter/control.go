@@ -0,0 +1,219 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// entryFunc transforms a value using the parameters of the filter and the entry the value belongs to
+type entryFunc func(value any, params map[string]string, entry Entry) (any, error)
+
+// newEntryFilter returns the constructor of a filter depending on the entry the value belongs to.
+// The parameters are validated against the accepted ones before the function is called
+func newEntryFilter(name string, fn entryFunc, accepted ...string) func(string) Filter {
+	return func(token string) Filter {
+		f := &FuncFilter{}
+		f.fn = func(value any, params map[string]string) (any, error) {
+			params, err := validateParams(params, accepted...)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", name, err)
+			}
+
+			result, err := fn(value, params, f.entry)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", name, err)
+			}
+
+			return result, nil
+		}
+
+		return f
+	}
+}
+
+// isEmpty reports whether the value is missing, an empty or blank string or an empty list
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []string:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// listMarker starts parameters encoded by EncodeList, listSeparator separates their items.
+// Both are control characters, which can't be part of placeholders written by hand
+const (
+	listMarker    = "\x1e"
+	listSeparator = "\x1f"
+)
+
+// EncodeList encodes the items of a list parameter, so listParam returns them unchanged, even if they contain ;
+func EncodeList(items []string) string {
+	return listMarker + strings.Join(items, listSeparator)
+}
+
+// listParam returns the items of a list parameter. Parameters which aren't encoded by EncodeList are split at ; and trimmed
+func listParam(params map[string]string, key string) ([]string, error) {
+	value, err := requiredParam(params, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if encoded, found := strings.CutPrefix(value, listMarker); found {
+		if encoded == "" {
+			return []string{}, nil
+		}
+		return strings.Split(encoded, listSeparator), nil
+	}
+
+	items := strings.Split(value, ";")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+
+	return items, nil
+}
+
+// defaultFilter returns v if the variable is not set. Without a variable, e.g. if the filter is used on its own,
+// empty strings are replaced
+func defaultFilter(value any, params map[string]string, entry Entry) (any, error) {
+	v, err := requiredParam(params, "v")
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Variable != "" {
+		if _, found := os.LookupEnv(entry.Variable); found && value != nil {
+			return value, nil
+		}
+		return v, nil
+	}
+
+	if value == nil || value == "" {
+		return v, nil
+	}
+	return value, nil
+}
+
+// ifEmpty returns v if the value is empty or blank
+func ifEmpty(value any, params map[string]string) (any, error) {
+	v, err := requiredParam(params, "v")
+	if err != nil {
+		return nil, err
+	}
+
+	if isEmpty(value) {
+		return v, nil
+	}
+	return value, nil
+}
+
+// required fails if the value is empty or blank, using msg as error message if given
+func required(value any, params map[string]string, entry Entry) (any, error) {
+	if !isEmpty(value) {
+		return value, nil
+	}
+
+	if msg, found := params["msg"]; found {
+		return nil, fmt.Errorf("%s", msg)
+	}
+	if entry.Variable != "" {
+		return nil, fmt.Errorf("variable %s is required but not set or empty", entry.Variable)
+	}
+	return nil, fmt.Errorf("value is required but empty")
+}
+
+// eq compares the value with v. It returns then or else if given, otherwise the result of the comparison.
+// If only then is given, values not being equal are returned as is
+func eq(value any, params map[string]string) (any, error) {
+	v, err := requiredParam(params, "v")
+	if err != nil {
+		return nil, err
+	}
+
+	then, hasThen := params["then"]
+	otherwise, hasElse := params["else"]
+	equal := fmt.Sprint(value) == v
+
+	switch {
+	case equal && hasThen:
+		return then, nil
+	case !equal && hasElse:
+		return otherwise, nil
+	case hasThen || hasElse:
+		return value, nil
+	default:
+		return equal, nil
+	}
+}
+
+// coalesce returns the value of the first variable being set and not empty, if the value is empty
+func coalesce(value any, params map[string]string) (any, error) {
+	variables, err := listParam(params, "vars")
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEmpty(value) {
+		return value, nil
+	}
+
+	for _, variable := range variables {
+		if v := os.Getenv(variable); strings.TrimSpace(v) != "" {
+			return v, nil
+		}
+	}
+
+	return value, nil
+}
+
+// mapFilter looks the value up in the parameters, values without mapping use the parameter default if given
+func mapFilter(value any, params map[string]string) (any, error) {
+	if len(params) == 0 {
+		return nil, fmt.Errorf("missing mapping, e.g. map(dev=debug,prod=info)")
+	}
+
+	key := fmt.Sprint(value)
+	if mapped, found := params[key]; found {
+		return mapped, nil
+	}
+	if fallback, found := params["default"]; found {
+		return fallback, nil
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return nil, fmt.Errorf("no mapping for %q, expected one of %s", key, strings.Join(keys, ", "))
+}
+
+// init registers the conditional and fallback filters
+func init() {
+	filterMap["default"] = newEntryFilter("default", defaultFilter, "v")
+	filterMap["if_empty"] = newValueFilter("if_empty", ifEmpty, "v")
+	filterMap["required"] = newEntryFilter("required", required, "msg")
+	filterMap["eq"] = newValueFilter("eq", eq, "v", "then", "else")
+	filterMap["coalesce"] = newValueFilter("coalesce", coalesce, "vars")
+	// The parameters of map are the lookup table, so they aren't validated
+	filterMap["map"] = func(token string) Filter {
+		return &FuncFilter{
+			fn: func(value any, params map[string]string) (any, error) {
+				result, err := mapFilter(value, params)
+				if err != nil {
+					return nil, fmt.Errorf("filter map: %w", err)
+				}
+				return result, nil
+			},
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestControlFilters(t *testing.T) {
+	t.Setenv("GONFIG_TEST_SET", "set")
+	t.Setenv("GONFIG_TEST_EMPTY", "")
+	t.Setenv("GONFIG_TEST_FALLBACK", "fallback")
+
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		entry    Entry
+		input    any
+		expected any
+		wantErr  string
+	}{
+		{desc: "default", filter: "default", params: map[string]string{"v": "8080"}, input: "", expected: "8080"},
+		{desc: "default keeps value", filter: "default", params: map[string]string{"v": "8080"}, input: "9090", expected: "9090"},
+		{desc: "default unset variable", filter: "default", params: map[string]string{"v": "8080"}, entry: Entry{Variable: "GONFIG_TEST_UNSET"}, input: "", expected: "8080"},
+		{desc: "default empty variable", filter: "default", params: map[string]string{"v": "8080"}, entry: Entry{Variable: "GONFIG_TEST_EMPTY"}, input: "", expected: ""},
+		{desc: "default without v", filter: "default", input: "", wantErr: "filter default: missing parameter v"},
+		{desc: "if_empty", filter: "if_empty", params: map[string]string{"v": "info"}, entry: Entry{Variable: "GONFIG_TEST_EMPTY"}, input: "  ", expected: "info"},
+		{desc: "if_empty keeps value", filter: "if_empty", params: map[string]string{"v": "info"}, input: "debug", expected: "debug"},
+		{desc: "required", filter: "required", input: "value", expected: "value"},
+		{desc: "required empty", filter: "required", entry: Entry{Variable: "DATABASE_URL"}, input: "", wantErr: "filter required: variable DATABASE_URL is required but not set or empty"},
+		{desc: "required msg", filter: "required", params: map[string]string{"msg": "set DATABASE_URL to the JDBC URL"}, input: " ", wantErr: "filter required: set DATABASE_URL to the JDBC URL"},
+		{desc: "required unknown parameter", filter: "required", params: map[string]string{"message": "x"}, input: "value", wantErr: "filter required: unknown parameter message, expected one of msg"},
+		{desc: "eq", filter: "eq", params: map[string]string{"v": "prod"}, input: "prod", expected: true},
+		{desc: "eq not equal", filter: "eq", params: map[string]string{"v": "prod"}, input: "dev", expected: false},
+		{desc: "eq then else", filter: "eq", params: map[string]string{"v": "prod", "then": "warn", "else": "debug"}, input: "dev", expected: "debug"},
+		{desc: "eq then", filter: "eq", params: map[string]string{"v": "prod", "then": "warn"}, input: "prod", expected: "warn"},
+		{desc: "eq then keeps value", filter: "eq", params: map[string]string{"v": "prod", "then": "warn"}, input: "dev", expected: "dev"},
+		{desc: "eq number", filter: "eq", params: map[string]string{"v": "3"}, input: 3, expected: true},
+		{desc: "coalesce", filter: "coalesce", params: map[string]string{"vars": "GONFIG_TEST_UNSET;GONFIG_TEST_EMPTY;GONFIG_TEST_FALLBACK"}, input: "", expected: "fallback"},
+		{desc: "coalesce keeps value", filter: "coalesce", params: map[string]string{"vars": "GONFIG_TEST_SET"}, input: "value", expected: "value"},
+		{desc: "coalesce encoded list", filter: "coalesce", params: map[string]string{"vars": EncodeList([]string{"GONFIG_TEST_UNSET", "GONFIG_TEST_FALLBACK"})}, input: "", expected: "fallback"},
+		{desc: "coalesce nothing set", filter: "coalesce", params: map[string]string{"vars": "GONFIG_TEST_UNSET"}, input: "", expected: ""},
+		{desc: "coalesce without vars", filter: "coalesce", input: "", wantErr: "filter coalesce: missing parameter vars"},
+		{desc: "map", filter: "map", params: map[string]string{"dev": "debug", "prod": "warn"}, input: "prod", expected: "warn"},
+		{desc: "map default", filter: "map", params: map[string]string{"dev": "debug", "default": "info"}, input: "staging", expected: "info"},
+		{desc: "map missing", filter: "map", params: map[string]string{"dev": "debug", "prod": "warn"}, input: "staging", wantErr: `filter map: no mapping for "staging", expected one of dev, prod`},
+		{desc: "map without mapping", filter: "map", input: "dev", wantErr: "filter map: missing mapping, e.g. map(dev=debug,prod=info)"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+			filter.(EntryFilter).AcceptEntry(tC.entry)
+
+			result, err := filter.Process(tC.input)
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	// Containers often lack a zoneinfo database, the embedded one is used as fallback
+	_ "time/tzdata"
+)
+
+// dateLayouts are the named layouts supported by the date filters, other layouts are used as Go reference layouts
+var dateLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"rfc850":      time.RFC850,
+	"ansic":       time.ANSIC,
+	"unixdate":    time.UnixDate,
+	"kitchen":     time.Kitchen,
+	"date":        time.DateOnly,
+	"datetime":    time.DateTime,
+	"time":        time.TimeOnly,
+}
+
+// defaultInputLayouts are tried in order if no input layout is given
+var defaultInputLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+// daysRe matches durations starting with a number of days, e.g. 30d or -1d12h
+var daysRe = regexp.MustCompile(`^([+-]?)(\d+)d(.*)$`)
+
+// fixedNow is the time returned by the clock of the date filters, if set
+var fixedNow time.Time
+
+// SetNow fixes the time used by the date filters, so renders are reproducible.
+// Passing the zero time restores the system clock
+func SetNow(t time.Time) {
+	fixedNow = t
+}
+
+// now returns the current time, which is the fixed time if set
+func now() time.Time {
+	if !fixedNow.IsZero() {
+		return fixedNow
+	}
+
+	return time.Now()
+}
+
+// locationParam returns the time zone given by the parameter, which defaults to UTC
+func locationParam(params map[string]string, key string) (*time.Location, error) {
+	name, found := params[key]
+	if !found || name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s must be a time zone like UTC or Europe/Berlin, got %q", key, name)
+	}
+
+	return loc, nil
+}
+
+// parseTime parses the value using the given layout. Without layout, RFC 3339, datetime and date values are accepted
+func parseTime(value any, layout string) (time.Time, error) {
+	if layout == "unix" || layout == "unix_ms" {
+		return fromUnixValue(value, layout)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected a date, got %T", value)
+	}
+	s = strings.TrimSpace(s)
+
+	layouts := defaultInputLayouts
+	if layout != "" {
+		if named, found := dateLayouts[layout]; found {
+			layout = named
+		}
+		layouts = []string{layout}
+	}
+
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+
+	if layout == "" {
+		return time.Time{}, fmt.Errorf("expected a date like 2006-01-02T15:04:05Z07:00, got %q", s)
+	}
+	return time.Time{}, fmt.Errorf("expected a date matching %q, got %q", layout, s)
+}
+
+// fromUnixValue parses the value as seconds or, for unix_ms, milliseconds since the epoch
+func fromUnixValue(value any, layout string) (time.Time, error) {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	case float64:
+		n = int64(v)
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expected a unix timestamp, got %q", v)
+		}
+		n = i
+	default:
+		return time.Time{}, fmt.Errorf("expected a unix timestamp, got %T", value)
+	}
+
+	if layout == "unix_ms" {
+		return time.UnixMilli(n).UTC(), nil
+	}
+	return time.Unix(n, 0).UTC(), nil
+}
+
+// formatTime formats the time using the given layout, which defaults to RFC 3339.
+// Unix timestamps are returned as integers
+func formatTime(t time.Time, layout string) any {
+	switch layout {
+	case "":
+		return t.Format(time.RFC3339)
+	case "unix":
+		return int(t.Unix())
+	case "unix_ms":
+		return int(t.UnixMilli())
+	}
+
+	if named, found := dateLayouts[layout]; found {
+		layout = named
+	}
+	return t.Format(layout)
+}
+
+// addDuration adds a duration like 1h30m to the time. Durations may start with a number of days, e.g. 30d or -1d12h
+func addDuration(t time.Time, duration string) (time.Time, error) {
+	s := strings.TrimSpace(duration)
+	if m := daysRe.FindStringSubmatch(s); m != nil {
+		days, err := strconv.Atoi(m[2])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parameter duration must be a duration like 30d or 1h30m, got %q", duration)
+		}
+		if m[1] == "-" {
+			days = -days
+		}
+		t = t.AddDate(0, 0, days)
+
+		if m[3] == "" {
+			return t, nil
+		}
+		s = m[1] + m[3]
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parameter duration must be a duration like 30d or 1h30m, got %q", duration)
+	}
+
+	return t.Add(d), nil
+}
+
+// nowFilter returns the current time. Values which aren't empty, e.g. because the variable is set, are returned as is
+func nowFilter(value any, params map[string]string) (any, error) {
+	if s, ok := value.(string); !ok || s != "" {
+		return value, nil
+	}
+
+	loc, err := locationParam(params, "tz")
+	if err != nil {
+		return nil, err
+	}
+
+	return formatTime(now().In(loc), params["format"]), nil
+}
+
+// dateFormat parses the value using the layout in and formats it using the layout out
+func dateFormat(value any, params map[string]string) (any, error) {
+	t, err := parseTime(value, params["in"])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := params["tz"]; found {
+		loc, err := locationParam(params, "tz")
+		if err != nil {
+			return nil, err
+		}
+		t = t.In(loc)
+	}
+
+	return formatTime(t, params["out"]), nil
+}
+
+// toUnix converts a date to the seconds since the epoch
+func toUnix(value any, params map[string]string) (any, error) {
+	t, err := parseTime(value, params["in"])
+	if err != nil {
+		return nil, err
+	}
+
+	return int(t.Unix()), nil
+}
+
+// fromUnix converts the seconds since the epoch to a date
+func fromUnix(value any, params map[string]string) (any, error) {
+	t, err := fromUnixValue(value, "unix")
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := locationParam(params, "tz")
+	if err != nil {
+		return nil, err
+	}
+
+	return formatTime(t.In(loc), params["format"]), nil
+}
+
+// dateAdd adds the duration to a date. The result has the layout of the input, unless out is given
+func dateAdd(value any, params map[string]string) (any, error) {
+	duration, err := requiredParam(params, "duration")
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := parseTime(value, params["in"])
+	if err != nil {
+		return nil, err
+	}
+
+	t, err = addDuration(t, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	out, found := params["out"]
+	if !found {
+		out = params["in"]
+	}
+	if out == "" {
+		// Keep date-only values without a time
+		if s, ok := value.(string); ok && len(strings.TrimSpace(s)) == len(time.DateOnly) {
+			out = "date"
+		}
+	}
+
+	return formatTime(t, out), nil
+}
+
+// init registers the date and time filters
+func init() {
+	filterMap["now"] = newValueFilter("now", nowFilter, "format", "tz")
+	filterMap["date_format"] = newValueFilter("date_format", dateFormat, "in", "out", "tz")
+	filterMap["to_unix"] = newValueFilter("to_unix", toUnix, "in")
+	filterMap["from_unix"] = newValueFilter("from_unix", fromUnix, "format", "tz")
+	filterMap["date_add"] = newValueFilter("date_add", dateAdd, "duration", "in", "out")
+}
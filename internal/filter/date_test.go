@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDateFilters(t *testing.T) {
+	SetNow(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+	t.Cleanup(func() { SetNow(time.Time{}) })
+
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		input    any
+		expected any
+		wantErr  string
+	}{
+		{desc: "now", filter: "now", input: "", expected: "2024-05-01T12:30:00Z"},
+		{desc: "now format", filter: "now", params: map[string]string{"format": "date"}, input: "", expected: "2024-05-01"},
+		{desc: "now layout", filter: "now", params: map[string]string{"format": "20060102150405"}, input: "", expected: "20240501123000"},
+		{desc: "now tz", filter: "now", params: map[string]string{"tz": "Europe/Berlin", "format": "datetime"}, input: "", expected: "2024-05-01 14:30:00"},
+		{desc: "now unix", filter: "now", params: map[string]string{"format": "unix"}, input: "", expected: 1714566600},
+		{desc: "now keeps value", filter: "now", input: "2020-01-01", expected: "2020-01-01"},
+		{desc: "now unknown tz", filter: "now", params: map[string]string{"tz": "Mars/Olympus"}, input: "", wantErr: `filter now: parameter tz must be a time zone like UTC or Europe/Berlin, got "Mars/Olympus"`},
+		{desc: "date_format", filter: "date_format", params: map[string]string{"out": "02.01.2006"}, input: "2025-03-31T23:59:59Z", expected: "31.03.2025"},
+		{desc: "date_format in", filter: "date_format", params: map[string]string{"in": "rfc1123", "out": "rfc3339"}, input: "Mon, 31 Mar 2025 23:59:59 UTC", expected: "2025-03-31T23:59:59Z"},
+		{desc: "date_format tz", filter: "date_format", params: map[string]string{"tz": "America/New_York", "out": "rfc3339"}, input: "2025-03-31T23:59:59Z", expected: "2025-03-31T19:59:59-04:00"},
+		{desc: "date_format date only", filter: "date_format", params: map[string]string{"out": "Jan 2, 2006"}, input: "2025-03-31", expected: "Mar 31, 2025"},
+		{desc: "date_format invalid", filter: "date_format", input: "tomorrow", wantErr: `filter date_format: expected a date like 2006-01-02T15:04:05Z07:00, got "tomorrow"`},
+		{desc: "date_format layout mismatch", filter: "date_format", params: map[string]string{"in": "date"}, input: "2025-03-31T23:59:59Z", wantErr: `filter date_format: expected a date matching "2006-01-02", got "2025-03-31T23:59:59Z"`},
+		{desc: "to_unix", filter: "to_unix", input: "2024-05-01T12:00:00+02:00", expected: 1714557600},
+		{desc: "to_unix list", filter: "to_unix", input: []string{"2024-05-01"}, wantErr: "filter to_unix: expected a date, got []string"},
+		{desc: "from_unix", filter: "from_unix", input: "1714557600", expected: "2024-05-01T10:00:00Z"},
+		{desc: "from_unix int", filter: "from_unix", params: map[string]string{"format": "date"}, input: 1714557600, expected: "2024-05-01"},
+		{desc: "from_unix invalid", filter: "from_unix", input: "soon", wantErr: `filter from_unix: expected a unix timestamp, got "soon"`},
+		{desc: "date_add", filter: "date_add", params: map[string]string{"duration": "90d"}, input: "2024-05-01T12:00:00Z", expected: "2024-07-30T12:00:00Z"},
+		{desc: "date_add days and hours", filter: "date_add", params: map[string]string{"duration": "-1d12h"}, input: "2024-05-01T12:00:00Z", expected: "2024-04-30T00:00:00Z"},
+		{desc: "date_add hours", filter: "date_add", params: map[string]string{"duration": "1h30m"}, input: "2024-05-01T12:00:00Z", expected: "2024-05-01T13:30:00Z"},
+		{desc: "date_add keeps date only", filter: "date_add", params: map[string]string{"duration": "30d"}, input: "2024-05-01", expected: "2024-05-31"},
+		{desc: "date_add out", filter: "date_add", params: map[string]string{"duration": "1d", "out": "unix"}, input: "2024-05-01T00:00:00Z", expected: 1714608000},
+		{desc: "date_add without duration", filter: "date_add", input: "2024-05-01", wantErr: "filter date_add: missing parameter duration"},
+		{desc: "date_add invalid duration", filter: "date_add", params: map[string]string{"duration": "1 month"}, input: "2024-05-01", wantErr: `filter date_add: parameter duration must be a duration like 30d or 1h30m, got "1 month"`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			result, err := filter.Process(tC.input)
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// encodeBase64 encodes the value using the standard base64 alphabet with padding
+func encodeBase64(value string, _ map[string]string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(value)), nil
+}
+
+// decodeBase64 decodes a value encoded using the standard base64 alphabet.
+// Line breaks and other whitespace, e.g. of PEM-style wrapped data, are ignored
+func decodeBase64(value string, _ map[string]string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(removeWhitespace(value))
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// encodeBase64URL encodes the value using the URL-safe base64 alphabet without padding, e.g. for JWTs
+func encodeBase64URL(value string, _ map[string]string) (string, error) {
+	return base64.RawURLEncoding.EncodeToString([]byte(value)), nil
+}
+
+// decodeBase64URL decodes a value encoded using the URL-safe base64 alphabet with or without padding
+func decodeBase64URL(value string, _ map[string]string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(removeWhitespace(value), "="))
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// encodeHex encodes the value as lower case hexadecimal string
+func encodeHex(value string, _ map[string]string) (string, error) {
+	return hex.EncodeToString([]byte(value)), nil
+}
+
+// decodeHex decodes a hexadecimal string
+func decodeHex(value string, _ map[string]string) (string, error) {
+	decoded, err := hex.DecodeString(removeWhitespace(value))
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// decodeURL decodes a percent-encoded value, + is decoded as space
+func decodeURL(value string, _ map[string]string) (string, error) {
+	return url.QueryUnescape(value)
+}
+
+// encodeGzipBase64 compresses the value using gzip and encodes the result using base64.
+// The gzip header doesn't contain a modification time, so the result only depends on the value
+func encodeGzipBase64(value string, _ map[string]string) (string, error) {
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// removeWhitespace removes all whitespace from the value
+func removeWhitespace(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// init registers the encoding filters.
+// Values are handled as byte sequences, so decoded binary data like keystores is kept as is
+func init() {
+	filterMap["base64"] = newStringFilter("base64", encodeBase64)
+	filterMap["base64_decode"] = newStringFilter("base64_decode", decodeBase64)
+	filterMap["base64url"] = newStringFilter("base64url", encodeBase64URL)
+	filterMap["base64url_decode"] = newStringFilter("base64url_decode", decodeBase64URL)
+	filterMap["hex"] = newStringFilter("hex", encodeHex)
+	filterMap["hex_decode"] = newStringFilter("hex_decode", decodeHex)
+	filterMap["url_decode"] = newStringFilter("url_decode", decodeURL)
+	filterMap["gzip+base64"] = newStringFilter("gzip+base64", encodeGzipBase64)
+
+	filterMap["json_encode"] = func(token string) Filter {
+		return &FuncFilter{
+			fn: func(value any, params map[string]string) (any, error) {
+				params, err := validateParams(params)
+				if err != nil {
+					return nil, fmt.Errorf("filter json_encode: %w", err)
+				}
+
+				buf := new(bytes.Buffer)
+				encoder := json.NewEncoder(buf)
+				encoder.SetEscapeHTML(false)
+				if err := encoder.Encode(value); err != nil {
+					return nil, fmt.Errorf("filter json_encode: %w", err)
+				}
+
+				return strings.TrimSuffix(buf.String(), "\n"), nil
+			},
+		}
+	}
+}
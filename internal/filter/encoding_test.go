@@ -0,0 +1,86 @@
+package filter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingFilters(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filter   string
+		input    any
+		expected any
+		wantErr  string
+	}{
+		{desc: "base64", filter: "base64", input: "user:päss", expected: "dXNlcjpww6Rzcw=="},
+		{desc: "base64_decode", filter: "base64_decode", input: "dXNlcjpww6Rzcw==", expected: "user:päss"},
+		{desc: "base64_decode wrapped", filter: "base64_decode", input: "dXNlcjpw\nw6Rzcw==\n", expected: "user:päss"},
+		{desc: "base64_decode invalid", filter: "base64_decode", input: "not base64!", wantErr: "filter base64_decode: illegal base64 data"},
+		{desc: "base64url", filter: "base64url", input: "\xfb\xff?", expected: "-_8_"},
+		{desc: "base64url_decode", filter: "base64url_decode", input: "-_8_", expected: "\xfb\xff?"},
+		{desc: "base64url_decode padded", filter: "base64url_decode", input: "YWI=", expected: "ab"},
+		{desc: "hex", filter: "hex", input: "\x00\xffA", expected: "00ff41"},
+		{desc: "hex_decode", filter: "hex_decode", input: "00FF41", expected: "\x00\xffA"},
+		{desc: "hex_decode invalid", filter: "hex_decode", input: "0g", wantErr: "filter hex_decode: encoding/hex: invalid byte"},
+		{desc: "url_encode", filter: "url_encode", input: "p@ss word/+", expected: "p%40ss%20word%2F%2B"},
+		{desc: "url_decode", filter: "url_decode", input: "p%40ss%20word%2F%2B", expected: "p@ss word/+"},
+		{desc: "url_decode invalid", filter: "url_decode", input: "100%", wantErr: "filter url_decode: invalid URL escape"},
+		{desc: "json_encode string", filter: "json_encode", input: "a \"b\" <c>", expected: `"a \"b\" <c>"`},
+		{desc: "json_encode list", filter: "json_encode", input: []string{"a", "b"}, expected: `["a","b"]`},
+		{desc: "json_encode number", filter: "json_encode", input: 42, expected: "42"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			result, err := NewFilter(tC.filter).Process(tC.input)
+			if tC.wantErr != "" {
+				assert.ErrorContains(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestEncodingFiltersBinarySafe(t *testing.T) {
+	binary := make([]byte, 256)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+	encoded := base64.StdEncoding.EncodeToString(binary)
+
+	decoded, err := NewFilter("base64_decode").Process(encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, binary, []byte(decoded.(string)))
+
+	reencoded, err := NewFilter("base64").Process(decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, reencoded)
+}
+
+func TestGzipBase64Filter(t *testing.T) {
+	filter := NewFilter("gzip+base64")
+
+	result, err := filter.Process("gonfig gonfig gonfig")
+	assert.NoError(t, err)
+
+	// The result only depends on the value
+	again, err := filter.Process("gonfig gonfig gonfig")
+	assert.NoError(t, err)
+	assert.Equal(t, result, again)
+
+	compressed, err := base64.StdEncoding.DecodeString(result.(string))
+	assert.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "gonfig gonfig gonfig", string(content))
+}
@@ -0,0 +1,146 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Names of the escape filters, which are used as escaping contexts as well
+const (
+	XmlEscape        = "xml_escape"
+	CDataEscape      = "cdata_escape"
+	JsonEscape       = "json_escape"
+	YamlQuote        = "yaml_quote"
+	ShellQuote       = "shell_quote"
+	PropertiesEscape = "properties_escape"
+	UrlEncode        = "url_encode"
+	Raw              = "raw"
+)
+
+// escapers maps the names of the escape filters to their functions
+var escapers = map[string]func(string) string{
+	XmlEscape:        xmlEscaper.Replace,
+	CDataEscape:      escapeCData,
+	JsonEscape:       escapeJSON,
+	YamlQuote:        quoteYAML,
+	ShellQuote:       quoteShell,
+	PropertiesEscape: escapeProperties,
+	UrlEncode:        encodeURL,
+	Raw:              func(value string) string { return value },
+}
+
+// EscapeFilter is a filter that escapes the value for the context it is inserted into.
+// Placeholders using an escape filter are never escaped automatically
+type EscapeFilter struct {
+	escape func(string) string
+}
+
+// Process escapes the value. Values other than strings are returned as is
+func (f *EscapeFilter) Process(value any) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+
+	return f.escape(s), nil
+}
+
+// IsEscapeFilter checks if the filter escapes its value
+func IsEscapeFilter(filter Filter) bool {
+	_, ok := filter.(*EscapeFilter)
+	return ok
+}
+
+// IsEscapeContext checks if name is the name of an escape filter
+func IsEscapeContext(name string) bool {
+	_, found := escapers[name]
+	return found
+}
+
+// Escape escapes the value for each of the given contexts in order, i.e. the innermost context comes first
+func Escape(value string, contexts []string) (string, error) {
+	for _, context := range contexts {
+		escape, found := escapers[context]
+		if !found {
+			return "", fmt.Errorf("unknown escaping context %s", context)
+		}
+		value = escape(value)
+	}
+
+	return value, nil
+}
+
+var xmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+	"'", "&apos;",
+)
+
+// escapeCData splits the end marker of CDATA sections, so the value can't terminate the section
+func escapeCData(value string) string {
+	return strings.ReplaceAll(value, "]]>", "]]]]><![CDATA[>")
+}
+
+// escapeJSON escapes the value for use within a JSON string, the surrounding quotes are not added
+func escapeJSON(value string) string {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string never fails
+	_ = encoder.Encode(value)
+
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	return quoted[1 : len(quoted)-1]
+}
+
+// quoteYAML returns the value as double-quoted YAML scalar
+func quoteYAML(value string) string {
+	return `"` + escapeJSON(value) + `"`
+}
+
+// quoteShell returns the value as single-quoted word for POSIX shells
+func quoteShell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+var propertiesEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"\f", `\f`,
+	"=", `\=`,
+	":", `\:`,
+	"#", `\#`,
+	"!", `\!`,
+)
+
+// escapeProperties escapes the value for use as value of a properties file like java.util.Properties does
+func escapeProperties(value string) string {
+	escaped := propertiesEscaper.Replace(value)
+
+	// Leading whitespace would be dropped while reading
+	trimmed := strings.TrimLeft(escaped, " ")
+	return strings.Repeat(`\ `, len(escaped)-len(trimmed)) + trimmed
+}
+
+// encodeURL percent-encodes the value, so it can be used as any component of a URL
+func encodeURL(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// init registers the escape filters
+func init() {
+	for name, escape := range escapers {
+		filterMap[name] = func(token string) Filter {
+			return &EscapeFilter{
+				escape: escape,
+			}
+		}
+	}
+}
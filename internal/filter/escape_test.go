@@ -0,0 +1,52 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeFilters(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		input    string
+		expected string
+	}{
+		{filter: XmlEscape, input: `a & b <c> "d" 'e'`, expected: "a &amp; b &lt;c&gt; &quot;d&quot; &apos;e&apos;"},
+		{filter: CDataEscape, input: "a]]>b", expected: "a]]]]><![CDATA[>b"},
+		{filter: JsonEscape, input: "say \"hi\"\n\\ <b>", expected: `say \"hi\"\n\\ <b>`},
+		{filter: YamlQuote, input: "yes: no # 'x'", expected: `"yes: no # 'x'"`},
+		{filter: ShellQuote, input: "it's $HOME", expected: `'it'\''s $HOME'`},
+		{filter: PropertiesEscape, input: "  a=b:c\\d\n#!", expected: `\ \ a\=b\:c\\d\n\#\!`},
+		{filter: UrlEncode, input: "p@ss word/+?", expected: "p%40ss%20word%2F%2B%3F"},
+		{filter: Raw, input: "<&>", expected: "<&>"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.filter, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			assert.True(t, IsEscapeFilter(filter))
+
+			result, err := filter.Process(tC.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+
+			// Other values than strings are not touched
+			result, err = filter.Process(42)
+			assert.NoError(t, err)
+			assert.Equal(t, 42, result)
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	result, err := Escape(`{"a": "]]>"}`, []string{JsonEscape, CDataEscape})
+	assert.NoError(t, err)
+	assert.Equal(t, `{\"a\": \"]]]]><![CDATA[>\"}`, result)
+
+	_, err = Escape("a", []string{"unknown"})
+	assert.Error(t, err)
+
+	assert.True(t, IsEscapeContext(ShellQuote))
+	assert.False(t, IsEscapeContext("upper"))
+	assert.False(t, IsEscapeFilter(NewFilter("upper")))
+}
@@ -1,14 +1,10 @@
 package filter
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"os"
-	"strconv"
 	"strings"
 
 	"github.com/denglertai/gonfig/pkg/logging"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
@@ -38,6 +34,28 @@ func (f *DefaultFilterParamsHandler) AcceptParams(params map[string]string) {
 	f.params = params
 }
 
+// Entry describes the config entry a value belongs to
+type Entry struct {
+	// File is the absolute path of the file containing the entry, empty if the value doesn't belong to a file
+	File string
+	// Path is the path of the entry within the file
+	Path string
+	// Variable is the variable of the placeholder being processed
+	Variable string
+	// Previous is the result of the placeholder within the existing output, if known
+	Previous string
+	// Newline is set for entries of line based files, e.g. plain files. Line breaks within the results of placeholders
+	// are converted to the line ending of the line they are inserted into, or to Newline if that line has none.
+	// Binary results which aren't valid UTF-8 are kept as is
+	Newline string
+}
+
+// EntryFilter is implemented by filters depending on the config entry the value belongs to
+type EntryFilter interface {
+	// AcceptEntry provides the entry the value being processed belongs to
+	AcceptEntry(entry Entry)
+}
+
 // EnvVarFilter is a filter that replaces the value with the value of an environment variable
 type EnvVarFilter struct {
 	envVar string
@@ -62,6 +80,15 @@ func NewEnvVarFilter(envVar string) *EnvVarFilter {
 	}
 }
 
+// fileReadObserver is notified about every file referenced through the FileInterceptorFilter
+var fileReadObserver func(path string)
+
+// ObserveFileReads registers a function which is called for every file referenced through the FileInterceptorFilter,
+// regardless of whether the file exists. Passing nil removes the observer
+func ObserveFileReads(observer func(path string)) {
+	fileReadObserver = observer
+}
+
 // FileInterceptorFilter is a filter that intercepts file references and reads the file content
 type FileInterceptorFilter struct {
 }
@@ -73,6 +100,9 @@ func (f *FileInterceptorFilter) Process(value any) (any, error) {
 	if strings.HasPrefix(s, "@") {
 		path := s[1:]
 		logging.Debug("Processing FileInterceptorFilter", "path", path)
+		if fileReadObserver != nil {
+			fileReadObserver(path)
+		}
 		if _, err := os.Stat(path); err != nil {
 			return value, nil
 		}
@@ -138,10 +168,16 @@ func NewFilter(token string) Filter {
 
 // FuncFilter is a filter that uses a function to process the value
 type FuncFilter struct {
-	fn func(any, map[string]string) (any, error)
+	fn    func(any, map[string]string) (any, error)
+	entry Entry
 	DefaultFilterParamsHandler
 }
 
+// AcceptEntry accepts the entry the value being processed belongs to
+func (f *FuncFilter) AcceptEntry(entry Entry) {
+	f.entry = entry
+}
+
 // Process executes the filter's function
 func (f *FuncFilter) Process(value any) (any, error) {
 	return f.fn(value, f.DefaultFilterParamsHandler.params)
@@ -163,80 +199,6 @@ func init() {
 			},
 		}
 	}
-	filterMap["trim"] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, _ map[string]string) (any, error) {
-				return strings.TrimSpace(value.(string)), nil
-			},
-		}
-	}
-	filterMap["trimleft"] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, _ map[string]string) (any, error) {
-				return strings.TrimLeft(value.(string), " "), nil
-			},
-		}
-	}
-	filterMap["trimright"] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, _ map[string]string) (any, error) {
-				return strings.TrimRight(value.(string), " "), nil
-			},
-		}
-	}
-
-	filterMap["to_int"] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, _ map[string]string) (any, error) {
-				return strconv.Atoi(value.(string))
-			},
-		}
-	}
-
-	filterMap["multiply"] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, params map[string]string) (any, error) {
-				multiplier, found := params["m"]
-				if !found {
-					multiplier = "1"
-				}
-
-				m, err := strconv.Atoi(multiplier)
-				if err != nil {
-					return "", err
-				}
-
-				return value.(int) * m, nil
-			},
-		}
-	}
-
-	filterMap[bcryptFilterKey] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, _ map[string]string) (any, error) {
-				hash, err := bcrypt.GenerateFromPassword([]byte(value.(string)), bcrypt.DefaultCost)
-				if err != nil {
-					return "", err
-				}
-
-				return string(hash), nil
-			},
-		}
-	}
-
-	filterMap[md5FilterKey] = func(token string) Filter {
-		return &FuncFilter{
-			fn: func(value any, _ map[string]string) (any, error) {
-				hash := md5.New()
-				_, err := hash.Write([]byte(value.(string)))
-				if err != nil {
-					return "", err
-				}
-				// return the md5 hash as a string
-				return hex.EncodeToString(hash.Sum(nil)), nil
-			},
-		}
-	}
 }
 
 // AddPluginFilters adds filters from a plugin
@@ -0,0 +1,222 @@
+package filter
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// charsets are the named sets of characters random passwords may consist of
+var charsets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"digits":  "0123456789",
+	"hex":     "0123456789abcdef",
+	"symbols": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#%+-.:=?@^_~",
+}
+
+// crockfordAlphabet is the base32 alphabet used by ULIDs
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// generateFunc generates a new value using the parameters of the filter
+type generateFunc func(params map[string]string) (string, error)
+
+// newGeneratorFilter returns the constructor of a filter generating values.
+// Values which aren't empty, e.g. because the variable is set, are returned as is.
+// Generated values are stored in the state, if set, and reused by subsequent renders
+func newGeneratorFilter(name string, fn generateFunc, accepted ...string) func(string) Filter {
+	return func(token string) Filter {
+		f := &FuncFilter{}
+		f.fn = func(value any, params map[string]string) (any, error) {
+			params, err := validateParams(params, accepted...)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", name, err)
+			}
+
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("filter %s: expected a string, got %T", name, value)
+			}
+			if s != "" {
+				return s, nil
+			}
+
+			// Values of entries which don't belong to a file, e.g. read from stdin, can't be identified later on
+			persist := state != nil && f.entry.File != ""
+			if persist {
+				if stored, found := state.get(f.entry); found {
+					return stored, nil
+				}
+			}
+
+			generated, err := fn(params)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", name, err)
+			}
+			if persist {
+				state.set(f.entry, generated)
+			}
+
+			return generated, nil
+		}
+
+		return f
+	}
+}
+
+// randomIndex returns a uniformly distributed random number between 0 and n - 1
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(i.Int64()), nil
+}
+
+// randomPassword generates a password of len characters of the given charset,
+// which is either the name of a predefined charset or the characters to be used
+func randomPassword(params map[string]string) (string, error) {
+	length, err := rangeParam(params, "len", 24, 1, 1024)
+	if err != nil {
+		return "", err
+	}
+
+	charset, found := params["charset"]
+	if !found {
+		charset = "alnum"
+	}
+	chars, found := charsets[charset]
+	if !found {
+		chars = charset
+	}
+	runes := []rune(chars)
+	if len(runes) < 2 {
+		return "", fmt.Errorf("parameter charset must be one of alnum, alpha, digits, hex or symbols or contain at least two characters, got %q", charset)
+	}
+
+	var sb strings.Builder
+	for range length {
+		i, err := randomIndex(len(runes))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteRune(runes[i])
+	}
+
+	return sb.String(), nil
+}
+
+// randomHex generates len random hexadecimal characters
+func randomHex(params map[string]string) (string, error) {
+	length, err := rangeParam(params, "len", 32, 1, 1024)
+	if err != nil {
+		return "", err
+	}
+
+	random, err := randomSalt((length + 1) / 2)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(random)[:length], nil
+}
+
+// newUUID generates a random UUID of version 4 or a time-ordered UUID of version 7
+func newUUID(params map[string]string) (string, error) {
+	version := params["version"]
+	if version != "" && version != "4" && version != "7" {
+		return "", fmt.Errorf("parameter version must be 4 or 7, got %q", version)
+	}
+
+	u, err := randomSalt(16)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "7" {
+		// The first 48 bits contain the milliseconds since the epoch
+		var millis [8]byte
+		binary.BigEndian.PutUint64(millis[:], uint64(time.Now().UnixMilli()))
+		copy(u[:6], millis[2:])
+		u[6] = u[6]&0x0f | 0x70
+	} else {
+		u[6] = u[6]&0x0f | 0x40
+	}
+	// RFC 9562 variant
+	u[8] = u[8]&0x3f | 0x80
+
+	h := hex.EncodeToString(u)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// newULID generates a ULID consisting of the milliseconds since the epoch and 80 random bits
+func newULID(_ map[string]string) (string, error) {
+	u, err := randomSalt(16)
+	if err != nil {
+		return "", err
+	}
+
+	var millis [8]byte
+	binary.BigEndian.PutUint64(millis[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], millis[2:])
+
+	// The 128 bits are encoded as 26 characters of 5 bits each, starting with the least significant bits
+	n := new(big.Int).SetBytes(u)
+	mask := big.NewInt(0x1f)
+	chars := make([]byte, 26)
+	for i := len(chars) - 1; i >= 0; i-- {
+		chars[i] = crockfordAlphabet[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+
+	return string(chars), nil
+}
+
+// randomPort returns a random TCP port between min and max which is currently not in use
+func randomPort(params map[string]string) (string, error) {
+	lower, err := rangeParam(params, "min", 1024, 1, 65535)
+	if err != nil {
+		return "", err
+	}
+	upper, err := rangeParam(params, "max", 65535, 1, 65535)
+	if err != nil {
+		return "", err
+	}
+	if lower > upper {
+		return "", fmt.Errorf("parameter min must not be greater than max, got %d and %d", lower, upper)
+	}
+
+	for range 100 {
+		i, err := randomIndex(upper - lower + 1)
+		if err != nil {
+			return "", err
+		}
+		port := strconv.Itoa(lower + i)
+
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			continue
+		}
+		listener.Close()
+
+		return port, nil
+	}
+
+	return "", fmt.Errorf("no free port found between %d and %d", lower, upper)
+}
+
+// init registers the generator filters
+func init() {
+	filterMap["random_password"] = newGeneratorFilter("random_password", randomPassword, "len", "charset")
+	filterMap["random_hex"] = newGeneratorFilter("random_hex", randomHex, "len")
+	filterMap["uuid"] = newGeneratorFilter("uuid", newUUID, "version")
+	filterMap["ulid"] = newGeneratorFilter("ulid", newULID)
+	filterMap["random_port"] = newGeneratorFilter("random_port", randomPort, "min", "max")
+}
@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratorFilters(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		filter  string
+		params  map[string]string
+		pattern string
+		wantErr string
+	}{
+		{desc: "random_password", filter: "random_password", pattern: `^[A-Za-z0-9]{24}$`},
+		{desc: "random_password digits", filter: "random_password", params: map[string]string{"len": "6", "charset": "digits"}, pattern: `^[0-9]{6}$`},
+		{desc: "random_password custom charset", filter: "random_password", params: map[string]string{"len": "32", "charset": "ab"}, pattern: `^[ab]{32}$`},
+		{desc: "random_password single character", filter: "random_password", params: map[string]string{"charset": "a"}, wantErr: `filter random_password: parameter charset must be one of alnum, alpha, digits, hex or symbols or contain at least two characters, got "a"`},
+		{desc: "random_password invalid len", filter: "random_password", params: map[string]string{"len": "0"}, wantErr: "filter random_password: parameter len must be between 1 and 1024, got 0"},
+		{desc: "random_hex", filter: "random_hex", pattern: `^[0-9a-f]{32}$`},
+		{desc: "random_hex odd len", filter: "random_hex", params: map[string]string{"len": "7"}, pattern: `^[0-9a-f]{7}$`},
+		{desc: "uuid", filter: "uuid", pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{desc: "uuid version 7", filter: "uuid", params: map[string]string{"version": "7"}, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{desc: "uuid unknown version", filter: "uuid", params: map[string]string{"version": "1"}, wantErr: `filter uuid: parameter version must be 4 or 7, got "1"`},
+		{desc: "ulid", filter: "ulid", pattern: `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`},
+		{desc: "ulid with parameter", filter: "ulid", params: map[string]string{"len": "4"}, wantErr: "filter ulid: unknown parameter len, the filter has no parameters"},
+		{desc: "random_port", filter: "random_port", params: map[string]string{"min": "20000", "max": "20010"}, pattern: `^200(0[0-9]|10)$`},
+		{desc: "random_port invalid range", filter: "random_port", params: map[string]string{"min": "2000", "max": "1000"}, wantErr: "filter random_port: parameter min must not be greater than max, got 2000 and 1000"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			result, err := filter.Process("")
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile(tC.pattern), result)
+		})
+	}
+}
+
+func TestGeneratorFiltersKeepValues(t *testing.T) {
+	filter := NewFilter("random_password")
+
+	result, err := filter.Process("given")
+	assert.NoError(t, err)
+	assert.Equal(t, "given", result)
+}
+
+func TestUlidOrder(t *testing.T) {
+	filter := NewFilter("ulid")
+
+	first, err := filter.Process("")
+	assert.NoError(t, err)
+	second, err := filter.Process("")
+	assert.NoError(t, err)
+
+	// ULIDs of different milliseconds sort by their time
+	assert.LessOrEqual(t, first.(string)[:10], second.(string)[:10])
+}
+
+func TestGeneratorState(t *testing.T) {
+	state := NewState()
+	UseState(state)
+	t.Cleanup(func() { UseState(nil) })
+
+	generate := func(entry Entry) string {
+		filter := NewFilter("random_hex")
+		filter.(EntryFilter).AcceptEntry(entry)
+
+		result, err := filter.Process("")
+		assert.NoError(t, err)
+		return result.(string)
+	}
+
+	entry := Entry{File: "/etc/app/app.properties", Path: "admin.password", Variable: "ADMIN_PASSWORD"}
+	first := generate(entry)
+	assert.True(t, state.Changed())
+	assert.Equal(t, first, generate(entry))
+	assert.NotEqual(t, first, generate(Entry{File: entry.File, Path: "user.password", Variable: "ADMIN_PASSWORD"}))
+	// Values of entries without a file aren't stored
+	assert.NotEqual(t, generate(Entry{Path: "admin.password"}), generate(Entry{Path: "admin.password"}))
+
+	data, err := state.Marshal()
+	assert.NoError(t, err)
+
+	parsed, err := ParseState(data)
+	assert.NoError(t, err)
+	assert.False(t, parsed.Changed())
+	UseState(parsed)
+	assert.Equal(t, first, generate(entry))
+
+	_, err = ParseState([]byte("[]"))
+	assert.ErrorContains(t, err, "invalid state")
+}
+
+func TestRandomPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	// The only port of the range can't be chosen while it is in use
+	filter := NewFilter("random_port")
+	filter.(FilterParams).AcceptParams(map[string]string{"min": port, "max": port})
+
+	_, err = filter.Process("")
+	assert.EqualError(t, err, fmt.Sprintf("filter random_port: no free port found between %s and %s", port, port))
+}
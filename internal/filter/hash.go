@@ -0,0 +1,529 @@
+package filter
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math/bits"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/scrypt"
+)
+
+// digests maps the names of the supported hash algorithms to their constructors
+var digests = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// digestParam returns the hash algorithm selected by the alg parameter
+func digestParam(params map[string]string, defaultAlg string) (string, func() hash.Hash, error) {
+	alg, found := params["alg"]
+	if !found {
+		alg = defaultAlg
+	}
+
+	newHash, found := digests[alg]
+	if !found {
+		return "", nil, fmt.Errorf("parameter alg must be one of md5, sha1, sha256 or sha512, got %q", alg)
+	}
+
+	return alg, newHash, nil
+}
+
+// encodeBytes encodes binary data using the encoding given by the encoding parameter, which defaults to hex
+func encodeBytes(data []byte, params map[string]string) (string, error) {
+	switch encoding := params["encoding"]; encoding {
+	case "", "hex":
+		return hex.EncodeToString(data), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data), nil
+	default:
+		return "", fmt.Errorf("parameter encoding must be hex or base64, got %q", encoding)
+	}
+}
+
+// saltParam returns the salt given by the salt parameter or a salt of salt_len bytes obtained from salts
+func saltParam(params map[string]string, defaultLen int, salts saltSource) ([]byte, error) {
+	if salt, found := params["salt"]; found {
+		return []byte(salt), nil
+	}
+
+	length, err := rangeParam(params, "salt_len", defaultLen, 0, 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	return salts(length)
+}
+
+// digestFilter returns a function hashing the value using the given algorithm.
+// If a salt is used, it is prepended to the value before hashing and to the digest before encoding
+func digestFilter(newHash func() hash.Hash) hashFunc {
+	return func(value string, params map[string]string, salts saltSource) (string, error) {
+		salt, err := saltParam(params, 0, salts)
+		if err != nil {
+			return "", err
+		}
+
+		h := newHash()
+		h.Write(salt)
+		h.Write([]byte(value))
+
+		return encodeBytes(h.Sum(salt), params)
+	}
+}
+
+// hmacFilter computes the HMAC of the value using the key read from the environment variable given by key_env
+func hmacFilter(value string, params map[string]string) (string, error) {
+	keyEnv, err := requiredParam(params, "key_env")
+	if err != nil {
+		return "", err
+	}
+	key, found := os.LookupEnv(keyEnv)
+	if !found {
+		return "", fmt.Errorf("environment variable %s containing the key is not set", keyEnv)
+	}
+
+	_, newHash, err := digestParam(params, "sha256")
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(newHash, []byte(key))
+	mac.Write([]byte(value))
+
+	return encodeBytes(mac.Sum(nil), params)
+}
+
+// bcryptFilter hashes the value using bcrypt
+func bcryptFilter(value string, params map[string]string, salts saltSource) (string, error) {
+	cost, err := rangeParam(params, "cost", bcrypt.DefaultCost, bcrypt.MinCost, bcrypt.MaxCost)
+	if err != nil {
+		return "", err
+	}
+
+	salt, err := salts(16)
+	if err != nil {
+		return "", err
+	}
+
+	return bcryptHash([]byte(value), cost, salt)
+}
+
+// bcryptEncoding is the base64 variant used by bcrypt
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// bcryptHash hashes the password using bcrypt with the given salt of 16 bytes.
+// The bcrypt package always uses random salts, thus the algorithm is implemented on top of blowfish
+func bcryptHash(password []byte, cost int, salt []byte) (string, error) {
+	if len(password) > 72 {
+		return "", bcrypt.ErrPasswordTooLong
+	}
+	if len(salt) != 16 {
+		return "", fmt.Errorf("bcrypt requires a salt of 16 bytes, got %d", len(salt))
+	}
+
+	// Like the C implementation, the terminating NUL byte is part of the key
+	key := append(slices.Clone(password), 0)
+	cipher, err := blowfish.NewSaltedCipher(key, salt)
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < 1<<cost; i++ {
+		blowfish.ExpandKey(key, cipher)
+		blowfish.ExpandKey(salt, cipher)
+	}
+
+	data := []byte("OrpheanBeholderScryDoubt")
+	for i := 0; i < len(data); i += 8 {
+		for j := 0; j < 64; j++ {
+			cipher.Encrypt(data[i:i+8], data[i:i+8])
+		}
+	}
+
+	// Only 23 of the 24 bytes are encoded, as done by the C implementation
+	return fmt.Sprintf("$2a$%02d$%s%s", cost, bcryptEncoding.EncodeToString(salt), bcryptEncoding.EncodeToString(data[:23])), nil
+}
+
+// argon2idFilter hashes the value using Argon2id and returns it in the PHC string format.
+// The defaults follow the recommendation of OWASP
+func argon2idFilter(value string, params map[string]string, salts saltSource) (string, error) {
+	memory, err := rangeParam(params, "memory", 19456, 8, 4*1024*1024)
+	if err != nil {
+		return "", err
+	}
+	time, err := rangeParam(params, "time", 2, 1, 1<<16)
+	if err != nil {
+		return "", err
+	}
+	threads, err := rangeParam(params, "threads", 1, 1, 255)
+	if err != nil {
+		return "", err
+	}
+	keyLen, err := rangeParam(params, "key_len", 32, 4, 1024)
+	if err != nil {
+		return "", err
+	}
+	salt, err := saltParam(params, 16, salts)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(value), salt, uint32(time), uint32(memory), uint8(threads), uint32(keyLen))
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// pbkdf2Iterations are the default iterations per hash algorithm as recommended by OWASP
+var pbkdf2Iterations = map[string]int{
+	"md5":    1_300_000,
+	"sha1":   1_300_000,
+	"sha256": 600_000,
+	"sha512": 210_000,
+}
+
+// pbkdf2Filter derives a key from the value using PBKDF2. It is returned in the PHC string format,
+// the format of mosquitto_passwd or as hex or base64 encoded key, e.g. for Grafana
+func pbkdf2Filter(value string, params map[string]string, salts saltSource) (string, error) {
+	format := params["format"]
+	defaultAlg, defaultKeyLen, defaultSaltLen := "sha256", 32, 16
+	if format == "mosquitto" {
+		// mosquitto only supports PBKDF2 using SHA-512
+		defaultAlg, defaultKeyLen, defaultSaltLen = "sha512", 64, 12
+	}
+
+	alg, newHash, err := digestParam(params, defaultAlg)
+	if err != nil {
+		return "", err
+	}
+	if format == "mosquitto" && alg != "sha512" {
+		return "", fmt.Errorf("the mosquitto format requires alg sha512, got %q", alg)
+	}
+	iterations, err := rangeParam(params, "iterations", pbkdf2Iterations[alg], 1, 1<<31-1)
+	if err != nil {
+		return "", err
+	}
+	keyLen, err := rangeParam(params, "key_len", defaultKeyLen, 4, 1024)
+	if err != nil {
+		return "", err
+	}
+	salt, err := saltParam(params, defaultSaltLen, salts)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := pbkdf2.Key(newHash, value, salt, iterations, keyLen)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case "", "phc":
+		return fmt.Sprintf("$pbkdf2-%s$i=%d,l=%d$%s$%s", alg, iterations, keyLen,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case "mosquitto":
+		return fmt.Sprintf("$7$%d$%s$%s", iterations, base64.StdEncoding.EncodeToString(salt), base64.StdEncoding.EncodeToString(key)), nil
+	case "hex", "base64":
+		return encodeBytes(key, map[string]string{"encoding": format})
+	default:
+		return "", fmt.Errorf("parameter format must be phc, mosquitto, hex or base64, got %q", format)
+	}
+}
+
+// scryptFilter derives a key from the value using scrypt. It is returned in the format used by passlib or as hex or base64 encoded key
+func scryptFilter(value string, params map[string]string, salts saltSource) (string, error) {
+	n, err := rangeParam(params, "n", 1<<15, 2, 1<<30)
+	if err != nil {
+		return "", err
+	}
+	if n&(n-1) != 0 {
+		return "", fmt.Errorf("parameter n must be a power of two, got %d", n)
+	}
+	r, err := rangeParam(params, "r", 8, 1, 1<<16)
+	if err != nil {
+		return "", err
+	}
+	p, err := rangeParam(params, "p", 1, 1, 1<<16)
+	if err != nil {
+		return "", err
+	}
+	keyLen, err := rangeParam(params, "key_len", 32, 4, 1024)
+	if err != nil {
+		return "", err
+	}
+	salt, err := saltParam(params, 16, salts)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(value), salt, n, r, p, keyLen)
+	if err != nil {
+		return "", err
+	}
+
+	switch format := params["format"]; format {
+	case "", "phc":
+		return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", bits.TrailingZeros(uint(n)), r, p,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case "hex", "base64":
+		return encodeBytes(key, map[string]string{"encoding": format})
+	default:
+		return "", fmt.Errorf("parameter format must be phc, hex or base64, got %q", format)
+	}
+}
+
+// cryptAlphabet is the alphabet of the base64 variant used by crypt(3)
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// cryptSaltParam returns the salt given by the salt parameter or a salt of length characters of the crypt alphabet obtained from salts
+func cryptSaltParam(params map[string]string, length int, salts saltSource) (string, error) {
+	if salt, found := params["salt"]; found {
+		if salt == "" || len(salt) > length {
+			return "", fmt.Errorf("parameter salt must have between 1 and %d characters, got %d", length, len(salt))
+		}
+		if i := strings.IndexFunc(salt, func(r rune) bool { return !strings.ContainsRune(cryptAlphabet, r) }); i >= 0 {
+			return "", fmt.Errorf("parameter salt may only contain the characters %s", cryptAlphabet)
+		}
+		return salt, nil
+	}
+
+	salt, err := salts(length)
+	if err != nil {
+		return "", err
+	}
+
+	chars := make([]byte, len(salt))
+	for i, b := range salt {
+		chars[i] = cryptAlphabet[int(b)%len(cryptAlphabet)]
+	}
+
+	return string(chars), nil
+}
+
+// cryptBase64 encodes the 24 bits of three bytes as n characters of the crypt alphabet, least significant bits first
+func cryptBase64(sb *strings.Builder, b2 byte, b1 byte, b0 byte, n int) {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		sb.WriteByte(cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+}
+
+// repeatBytes returns data repeated until it has the given length
+func repeatBytes(data []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result) < length {
+		result = append(result, data[:min(len(data), length-len(result))]...)
+	}
+
+	return result
+}
+
+// cryptSha512Filter hashes the value using SHA-crypt with SHA-512 as used by /etc/shadow ($6$)
+func cryptSha512Filter(value string, params map[string]string, salts saltSource) (string, error) {
+	_, customRounds := params["rounds"]
+	rounds, err := rangeParam(params, "rounds", 5000, 1000, 999_999_999)
+	if err != nil {
+		return "", err
+	}
+	salt, err := cryptSaltParam(params, 16, salts)
+	if err != nil {
+		return "", err
+	}
+
+	password := []byte(value)
+	saltBytes := []byte(salt)
+
+	h := sha512.New()
+	h.Write(password)
+	h.Write(saltBytes)
+	h.Write(password)
+	alternate := h.Sum(nil)
+
+	h.Reset()
+	h.Write(password)
+	h.Write(saltBytes)
+	h.Write(repeatBytes(alternate, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write(alternate)
+		} else {
+			h.Write(password)
+		}
+	}
+	digest := h.Sum(nil)
+
+	h.Reset()
+	for range password {
+		h.Write(password)
+	}
+	passwordSequence := repeatBytes(h.Sum(nil), len(password))
+
+	h.Reset()
+	for i := 0; i < 16+int(digest[0]); i++ {
+		h.Write(saltBytes)
+	}
+	saltSequence := repeatBytes(h.Sum(nil), len(saltBytes))
+
+	for round := 0; round < rounds; round++ {
+		h.Reset()
+		if round&1 != 0 {
+			h.Write(passwordSequence)
+		} else {
+			h.Write(digest)
+		}
+		if round%3 != 0 {
+			h.Write(saltSequence)
+		}
+		if round%7 != 0 {
+			h.Write(passwordSequence)
+		}
+		if round&1 != 0 {
+			h.Write(digest)
+		} else {
+			h.Write(passwordSequence)
+		}
+		digest = h.Sum(nil)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("$6$")
+	if customRounds {
+		sb.WriteString("rounds=" + strconv.Itoa(rounds) + "$")
+	}
+	sb.WriteString(salt + "$")
+
+	// The bytes of the digest are encoded in groups of three, which are rotated depending on their position
+	for i := 0; i < 21; i++ {
+		group := [3]byte{digest[i], digest[i+21], digest[i+42]}
+		rotation := i % 3
+		cryptBase64(&sb, group[rotation], group[(rotation+1)%3], group[(rotation+2)%3], 4)
+	}
+	cryptBase64(&sb, 0, 0, digest[63], 2)
+
+	return sb.String(), nil
+}
+
+// apr1 hashes the password using the MD5 based algorithm of the Apache HTTP server ($apr1$)
+func apr1(password []byte, salt string) string {
+	const magic = "$apr1$"
+
+	h := md5.New()
+	h.Write(password)
+	h.Write([]byte(salt))
+	h.Write(password)
+	alternate := h.Sum(nil)
+
+	h.Reset()
+	h.Write(password)
+	h.Write([]byte(magic + salt))
+	h.Write(repeatBytes(alternate, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(password[:1])
+		}
+	}
+	digest := h.Sum(nil)
+
+	for round := 0; round < 1000; round++ {
+		h.Reset()
+		if round&1 != 0 {
+			h.Write(password)
+		} else {
+			h.Write(digest)
+		}
+		if round%3 != 0 {
+			h.Write([]byte(salt))
+		}
+		if round%7 != 0 {
+			h.Write(password)
+		}
+		if round&1 != 0 {
+			h.Write(digest)
+		} else {
+			h.Write(password)
+		}
+		digest = h.Sum(nil)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(magic + salt + "$")
+	for i := 0; i < 5; i++ {
+		third := i + 12
+		if i == 4 {
+			third = 5
+		}
+		cryptBase64(&sb, digest[i], digest[i+6], digest[third], 4)
+	}
+	cryptBase64(&sb, 0, 0, digest[11], 2)
+
+	return sb.String()
+}
+
+// htpasswdFilter hashes the value using one of the algorithms supported by htpasswd files.
+// If a user is given, the result is a complete line of a htpasswd file
+func htpasswdFilter(value string, params map[string]string, salts saltSource) (string, error) {
+	var hashed string
+	switch alg := params["alg"]; alg {
+	case "", "bcrypt":
+		hash, err := bcryptFilter(value, params, salts)
+		if err != nil {
+			return "", err
+		}
+		hashed = hash
+	case "apr1":
+		salt, err := cryptSaltParam(params, 8, salts)
+		if err != nil {
+			return "", err
+		}
+		hashed = apr1([]byte(value), salt)
+	case "sha1":
+		digest := sha1.Sum([]byte(value))
+		hashed = "{SHA}" + base64.StdEncoding.EncodeToString(digest[:])
+	default:
+		return "", fmt.Errorf("parameter alg must be bcrypt, apr1 or sha1, got %q", alg)
+	}
+
+	if user, found := params["user"]; found {
+		if user == "" || strings.Contains(user, ":") {
+			return "", fmt.Errorf("parameter user must not be empty or contain a colon, got %q", user)
+		}
+		return user + ":" + hashed, nil
+	}
+
+	return hashed, nil
+}
+
+// init registers the hashing filters
+func init() {
+	filterMap[md5FilterKey] = newHashFilter(md5FilterKey, digestFilter(md5.New), "encoding", "salt", "salt_len")
+	filterMap["sha1"] = newHashFilter("sha1", digestFilter(sha1.New), "encoding", "salt", "salt_len")
+	filterMap["sha256"] = newHashFilter("sha256", digestFilter(sha256.New), "encoding", "salt", "salt_len")
+	filterMap["sha512"] = newHashFilter("sha512", digestFilter(sha512.New), "encoding", "salt", "salt_len")
+	filterMap["hmac"] = newStringFilter("hmac", hmacFilter, "alg", "key_env", "encoding")
+	filterMap[bcryptFilterKey] = newHashFilter(bcryptFilterKey, bcryptFilter, "cost")
+	filterMap["argon2id"] = newHashFilter("argon2id", argon2idFilter, "memory", "time", "threads", "key_len", "salt", "salt_len")
+	filterMap["pbkdf2"] = newHashFilter("pbkdf2", pbkdf2Filter, "alg", "iterations", "key_len", "salt", "salt_len", "format")
+	filterMap["scrypt"] = newHashFilter("scrypt", scryptFilter, "n", "r", "p", "key_len", "salt", "salt_len", "format")
+	filterMap["crypt_sha512"] = newHashFilter("crypt_sha512", cryptSha512Filter, "rounds", "salt")
+	filterMap["htpasswd"] = newHashFilter("htpasswd", htpasswdFilter, "alg", "user", "cost", "salt")
+}
@@ -0,0 +1,265 @@
+package filter
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashFilters(t *testing.T) {
+	t.Setenv("GONFIG_TEST_HMAC_KEY", "key")
+
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		input    any
+		expected any
+		wantErr  string
+	}{
+		{desc: "md5", filter: "md5", input: "secret", expected: "5ebe2294ecd0e0f08eab7690d2a6ee69"},
+		{desc: "sha1", filter: "sha1", input: "secret", expected: "e5e9fa1ba31ecd1ae84f75caaa474f3a663f05f4"},
+		{desc: "sha256", filter: "sha256", input: "secret", expected: "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"},
+		{desc: "sha512 base64", filter: "sha512", params: map[string]string{"encoding": "base64"}, input: "secret", expected: "vSsar3708Jvp9Szi2NWZZ02Bqp1qRCFpbcTZPdBhnWgs5WtNZKnvCXdhztmeD2cmW192CF5bDufKRpayrW/isg=="},
+		{desc: "sha256 salted", filter: "sha256", params: map[string]string{"salt": "salt", "encoding": "base64"}, input: "secret", expected: "c2FsdL7ekDhtRQzqi3e4IviIcGXk5avxMsL53M/Mf71Mul41"},
+		{desc: "sha256 invalid encoding", filter: "sha256", params: map[string]string{"encoding": "base32"}, input: "secret", wantErr: `filter sha256: parameter encoding must be hex or base64, got "base32"`},
+		{desc: "sha1 list", filter: "sha1", input: []string{"secret"}, expected: []string{"e5e9fa1ba31ecd1ae84f75caaa474f3a663f05f4"}},
+		{desc: "hmac", filter: "hmac", params: map[string]string{"key_env": "GONFIG_TEST_HMAC_KEY"}, input: "secret", expected: "25cf3c44c8f39313e8cbf7c23e22fe8b2ee8b288ee5206b0a6397583a1f7f0ef"},
+		{desc: "hmac sha1", filter: "hmac", params: map[string]string{"alg": "sha1", "key_env": "GONFIG_TEST_HMAC_KEY"}, input: "secret", expected: "bb1e45ed87beae9c2fcac6f35012eefb019a6170"},
+		{desc: "hmac without key_env", filter: "hmac", input: "secret", wantErr: "filter hmac: missing parameter key_env"},
+		{desc: "hmac unset key", filter: "hmac", params: map[string]string{"key_env": "GONFIG_TEST_UNSET"}, input: "secret", wantErr: "filter hmac: environment variable GONFIG_TEST_UNSET containing the key is not set"},
+		{desc: "hmac unknown alg", filter: "hmac", params: map[string]string{"alg": "sha3", "key_env": "GONFIG_TEST_HMAC_KEY"}, input: "secret", wantErr: `filter hmac: parameter alg must be one of md5, sha1, sha256 or sha512, got "sha3"`},
+		{desc: "bcrypt invalid cost", filter: "bcrypt", params: map[string]string{"cost": "32"}, input: "secret", wantErr: "filter bcrypt: parameter cost must be between 4 and 31, got 32"},
+		{desc: "pbkdf2", filter: "pbkdf2", params: map[string]string{"iterations": "1000", "salt": "saltsaltsaltsalt"}, input: "secret", expected: "$pbkdf2-sha256$i=1000,l=32$c2FsdHNhbHRzYWx0c2FsdA$dClvKSmj66n6MdMWNv3Go4mvH1Ym2WIGiJvquqa+mfE"},
+		{desc: "pbkdf2 hex", filter: "pbkdf2", params: map[string]string{"alg": "sha1", "iterations": "1", "salt": "salt", "key_len": "20", "format": "hex"}, input: "password", expected: "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{desc: "pbkdf2 mosquitto", filter: "pbkdf2", params: map[string]string{"iterations": "101", "salt": "saltsaltsalt", "format": "mosquitto"}, input: "secret", expected: "$7$101$c2FsdHNhbHRzYWx0$Hd71hnNoKcARd6Fkl1rUE+opfs3V78ZJB2AgXXC1kaKQiWfi5S5qX7D1mY5b+bIzvyidWMnS8VA+YAsAygq9EA=="},
+		{desc: "pbkdf2 mosquitto with sha256", filter: "pbkdf2", params: map[string]string{"alg": "sha256", "format": "mosquitto"}, input: "secret", wantErr: `filter pbkdf2: the mosquitto format requires alg sha512, got "sha256"`},
+		{desc: "pbkdf2 unknown format", filter: "pbkdf2", params: map[string]string{"format": "ldap"}, input: "secret", wantErr: `filter pbkdf2: parameter format must be phc, mosquitto, hex or base64, got "ldap"`},
+		{desc: "scrypt", filter: "scrypt", params: map[string]string{"n": "1024", "salt": "saltsaltsaltsalt"}, input: "secret", expected: "$scrypt$ln=10,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$punalsgLVDmabDdlbwVieUjVAmz5/abwyjX3gfy3Xlo"},
+		{desc: "scrypt hex", filter: "scrypt", params: map[string]string{"n": "16", "r": "1", "salt": "salt", "key_len": "8", "format": "hex"}, input: "pw", expected: "086be1ce38ba574b"},
+		{desc: "scrypt n not a power of two", filter: "scrypt", params: map[string]string{"n": "1000"}, input: "secret", wantErr: "filter scrypt: parameter n must be a power of two, got 1000"},
+		{desc: "crypt_sha512", filter: "crypt_sha512", params: map[string]string{"salt": "saltstring"}, input: "Hello world!", expected: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{desc: "crypt_sha512 rounds", filter: "crypt_sha512", params: map[string]string{"rounds": "10000", "salt": "saltstringsaltst"}, input: "Hello world!", expected: "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v."},
+		{desc: "crypt_sha512 too few rounds", filter: "crypt_sha512", params: map[string]string{"rounds": "10"}, input: "secret", wantErr: "filter crypt_sha512: parameter rounds must be between 1000 and 999999999, got 10"},
+		{desc: "crypt_sha512 invalid salt", filter: "crypt_sha512", params: map[string]string{"salt": "salt$"}, input: "secret", wantErr: "filter crypt_sha512: parameter salt may only contain the characters"},
+		{desc: "htpasswd apr1", filter: "htpasswd", params: map[string]string{"alg": "apr1", "salt": "r31....."}, input: "myPassword", expected: "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/"},
+		{desc: "htpasswd sha1 with user", filter: "htpasswd", params: map[string]string{"alg": "sha1", "user": "admin"}, input: "secret", expected: "admin:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ="},
+		{desc: "htpasswd invalid user", filter: "htpasswd", params: map[string]string{"alg": "sha1", "user": "ad:min"}, input: "secret", wantErr: `filter htpasswd: parameter user must not be empty or contain a colon, got "ad:min"`},
+		{desc: "htpasswd unknown alg", filter: "htpasswd", params: map[string]string{"alg": "crypt"}, input: "secret", wantErr: `filter htpasswd: parameter alg must be bcrypt, apr1 or sha1, got "crypt"`},
+		{desc: "argon2id unknown parameter", filter: "argon2id", params: map[string]string{"rounds": "3"}, input: "secret", wantErr: "filter argon2id: unknown parameter rounds, expected one of memory, time, threads, key_len, salt, salt_len"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			result, err := filter.Process(tC.input)
+			if tC.wantErr != "" {
+				assert.ErrorContains(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestHashFiltersRandomSalt(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		filter  string
+		params  map[string]string
+		pattern string
+	}{
+		{desc: "sha256", filter: "sha256", params: map[string]string{"salt_len": "4"}, pattern: `^[0-9a-f]{72}$`},
+		{desc: "argon2id", filter: "argon2id", params: map[string]string{"memory": "64", "time": "1"}, pattern: `^\$argon2id\$v=19\$m=64,t=1,p=1\$[./+0-9A-Za-z]{22}\$[./+0-9A-Za-z]{43}$`},
+		{desc: "pbkdf2", filter: "pbkdf2", params: map[string]string{"iterations": "1"}, pattern: `^\$pbkdf2-sha256\$i=1,l=32\$[+/0-9A-Za-z]{22}\$[+/0-9A-Za-z]{43}$`},
+		{desc: "scrypt", filter: "scrypt", params: map[string]string{"n": "16"}, pattern: `^\$scrypt\$ln=4,r=8,p=1\$[+/0-9A-Za-z]{22}\$[+/0-9A-Za-z]{43}$`},
+		{desc: "crypt_sha512", filter: "crypt_sha512", pattern: `^\$6\$[./0-9A-Za-z]{16}\$[./0-9A-Za-z]{86}$`},
+		{desc: "htpasswd apr1", filter: "htpasswd", params: map[string]string{"alg": "apr1"}, pattern: `^\$apr1\$[./0-9A-Za-z]{8}\$[./0-9A-Za-z]{22}$`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			first, err := filter.Process("secret")
+			assert.NoError(t, err)
+			assert.Regexp(t, regexp.MustCompile(tC.pattern), first)
+
+			// Every hash uses a new salt
+			second, err := filter.Process("secret")
+			assert.NoError(t, err)
+			assert.NotEqual(t, first, second)
+		})
+	}
+}
+
+func TestArgon2idFilter(t *testing.T) {
+	filter := NewFilter("argon2id")
+	filter.(FilterParams).AcceptParams(map[string]string{"memory": "64", "time": "1", "salt": "saltsalt"})
+
+	result, err := filter.Process("secret")
+	assert.NoError(t, err)
+
+	parts := strings.Split(result.(string), "$")
+	assert.Equal(t, []string{"", "argon2id", "v=19", "m=64,t=1,p=1", "c2FsdHNhbHQ"}, parts[:5])
+
+	key := argon2.IDKey([]byte("secret"), []byte("saltsalt"), 1, 64, 1, 32)
+	assert.Equal(t, base64.RawStdEncoding.EncodeToString(key), parts[5])
+}
+
+func TestHtpasswdBcrypt(t *testing.T) {
+	filter := NewFilter("htpasswd")
+	filter.(FilterParams).AcceptParams(map[string]string{"user": "admin", "cost": "4"})
+
+	result, err := filter.Process("secret")
+	assert.NoError(t, err)
+
+	user, hash, found := strings.Cut(result.(string), ":")
+	assert.True(t, found)
+	assert.Equal(t, "admin", user)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")))
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, cost)
+}
+
+func TestDeterministicSalts(t *testing.T) {
+	SetSeed("gitops")
+	t.Cleanup(func() { SetSeed("") })
+
+	hash := func(name string, params map[string]string, entry Entry, value string) string {
+		filter := NewFilter(name)
+		filter.(FilterParams).AcceptParams(params)
+		filter.(EntryFilter).AcceptEntry(entry)
+
+		result, err := filter.Process(value)
+		assert.NoError(t, err)
+		return result.(string)
+	}
+
+	testCases := []struct {
+		desc   string
+		filter string
+		params map[string]string
+	}{
+		{desc: "bcrypt", filter: "bcrypt", params: map[string]string{"cost": "4"}},
+		{desc: "argon2id", filter: "argon2id", params: map[string]string{"memory": "64", "time": "1"}},
+		{desc: "crypt_sha512", filter: "crypt_sha512", params: map[string]string{}},
+		{desc: "htpasswd apr1", filter: "htpasswd", params: map[string]string{"alg": "apr1", "user": "admin"}},
+		{desc: "sha256 salted", filter: "sha256", params: map[string]string{"salt_len": "4"}},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			entry := Entry{File: "/etc/app/a.properties", Path: "admin.password"}
+			first := hash(tC.filter, tC.params, entry, "secret")
+			assert.Equal(t, first, hash(tC.filter, tC.params, entry, "secret"))
+			assert.NotEqual(t, first, hash(tC.filter, tC.params, Entry{File: entry.File, Path: "user.password"}, "secret"))
+			assert.NotEqual(t, first, hash(tC.filter, tC.params, Entry{File: "/etc/app/b.properties", Path: entry.Path}, "secret"))
+			assert.NotEqual(t, first, hash(tC.filter, tC.params, entry, "changed"))
+		})
+	}
+
+	t.Run("bcrypt verifies", func(t *testing.T) {
+		result := hash("bcrypt", map[string]string{"cost": "4"}, Entry{File: "/etc/app/a.properties", Path: "admin.password"}, "secret")
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(result), []byte("secret")))
+	})
+
+	t.Run("Checkouts at different locations", func(t *testing.T) {
+		params := map[string]string{"cost": "4"}
+		first, second := t.TempDir(), t.TempDir()
+
+		t.Chdir(first)
+		expected := hash("bcrypt", params, Entry{File: filepath.Join(first, "conf/a.properties"), Path: "admin.password"}, "secret")
+		t.Chdir(second)
+		assert.Equal(t, expected, hash("bcrypt", params, Entry{File: filepath.Join(second, "conf/a.properties"), Path: "admin.password"}, "secret"))
+	})
+
+	t.Run("Identified by salt_id", func(t *testing.T) {
+		entry := Entry{Path: "admin.password"}
+		first := hash("bcrypt", map[string]string{"cost": "4", "salt_id": "a"}, entry, "secret")
+		assert.Equal(t, first, hash("bcrypt", map[string]string{"cost": "4", "salt_id": "a"}, entry, "secret"))
+		assert.NotEqual(t, first, hash("bcrypt", map[string]string{"cost": "4", "salt_id": "b"}, entry, "secret"))
+		assert.Equal(t, first, hash("bcrypt", map[string]string{"cost": "4", "salt_id": "a"}, Entry{File: "/etc/app/a.properties", Path: entry.Path}, "secret"))
+	})
+
+	t.Run("Values without file", func(t *testing.T) {
+		filter := NewFilter("bcrypt")
+		filter.(EntryFilter).AcceptEntry(Entry{Path: "admin.password"})
+
+		_, err := filter.Process("secret")
+		assert.EqualError(t, err, "filter bcrypt: deriving salts for values which don't belong to a file requires salt_id")
+	})
+}
+
+func TestSaltFrom(t *testing.T) {
+	t.Setenv("GONFIG_TEST_SALT_SECRET", "tenant")
+
+	filter := NewFilter("crypt_sha512")
+	filter.(FilterParams).AcceptParams(map[string]string{"salt_from": "GONFIG_TEST_SALT_SECRET"})
+	filter.(EntryFilter).AcceptEntry(Entry{File: "/etc/shadow", Path: "root"})
+
+	first, err := filter.Process("secret")
+	assert.NoError(t, err)
+	second, err := filter.Process("secret")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	filter.(FilterParams).AcceptParams(map[string]string{"salt_from": "GONFIG_TEST_UNSET"})
+	_, err = filter.Process("secret")
+	assert.EqualError(t, err, "filter crypt_sha512: environment variable GONFIG_TEST_UNSET containing the secret for deriving salts is not set")
+}
+
+func TestKeepPreviousHash(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		value    string
+		previous string
+		keep     bool
+	}{
+		{desc: "bcrypt", filter: "bcrypt", params: map[string]string{"cost": "4"}, value: "secret", previous: "$2a$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sK", keep: true},
+		{desc: "bcrypt 2b", filter: "bcrypt", params: map[string]string{"cost": "4"}, value: "secret", previous: "$2b$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sK", keep: true},
+		{desc: "bcrypt 2y", filter: "bcrypt", params: map[string]string{"cost": "4"}, value: "secret", previous: "$2y$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sK", keep: true},
+		{desc: "htpasswd bcrypt", filter: "htpasswd", params: map[string]string{"user": "admin", "cost": "4"}, value: "secret", previous: "admin:$2y$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sK", keep: true},
+		{desc: "htpasswd bcrypt other user", filter: "htpasswd", params: map[string]string{"user": "root", "cost": "4"}, value: "secret", previous: "admin:$2y$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sK"},
+		{desc: "bcrypt other cost", filter: "bcrypt", params: map[string]string{"cost": "5"}, value: "secret", previous: "$2a$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sK"},
+		{desc: "bcrypt not verifying", filter: "bcrypt", params: map[string]string{"cost": "4"}, value: "secret", previous: "$2a$04$R4UUt5bIIE.t.vJlVMbtouOk1a4/ZrBpEKg2dRlhB0Jb4owzLg8sX"},
+		{desc: "crypt_sha512", filter: "crypt_sha512", params: map[string]string{}, value: "Hello world!", previous: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", keep: true},
+		{desc: "crypt_sha512 rounds", filter: "crypt_sha512", params: map[string]string{"rounds": "10000"}, value: "Hello world!", previous: "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.", keep: true},
+		{desc: "htpasswd apr1 without user", filter: "htpasswd", params: map[string]string{"alg": "apr1"}, value: "myPassword", previous: "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/", keep: true},
+		{desc: "htpasswd apr1", filter: "htpasswd", params: map[string]string{"alg": "apr1", "user": "admin"}, value: "myPassword", previous: "admin:$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/", keep: true},
+		{desc: "htpasswd other user", filter: "htpasswd", params: map[string]string{"alg": "apr1", "user": "root"}, value: "myPassword", previous: "admin:$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/"},
+		{desc: "sha256 salted", filter: "sha256", params: map[string]string{"salt_len": "4", "encoding": "base64"}, value: "secret", previous: "c2FsdL7ekDhtRQzqi3e4IviIcGXk5avxMsL53M/Mf71Mul41", keep: true},
+		{desc: "not a hash", filter: "bcrypt", params: map[string]string{"cost": "4"}, value: "secret", previous: "${ADMIN_PASSWORD | bcrypt}"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			filter.(FilterParams).AcceptParams(tC.params)
+			filter.(EntryFilter).AcceptEntry(Entry{Path: "password", Previous: tC.previous})
+
+			result, err := filter.Process(tC.value)
+			assert.NoError(t, err)
+			if tC.keep {
+				assert.Equal(t, tC.previous, result)
+			} else {
+				assert.NotEqual(t, tC.previous, result)
+			}
+		})
+	}
+}
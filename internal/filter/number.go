@@ -0,0 +1,416 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteUnits are the factors of the units supported by the bytes filter
+var byteUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"k":   1e3,
+	"K":   1e3,
+	"KB":  1e3,
+	"M":   1e6,
+	"MB":  1e6,
+	"G":   1e9,
+	"GB":  1e9,
+	"T":   1e12,
+	"TB":  1e12,
+	"P":   1e15,
+	"PB":  1e15,
+	"E":   1e18,
+	"EB":  1e18,
+	"Ki":  1 << 10,
+	"KiB": 1 << 10,
+	"Mi":  1 << 20,
+	"MiB": 1 << 20,
+	"Gi":  1 << 30,
+	"GiB": 1 << 30,
+	"Ti":  1 << 40,
+	"TiB": 1 << 40,
+	"Pi":  1 << 50,
+	"PiB": 1 << 50,
+	"Ei":  1 << 60,
+	"EiB": 1 << 60,
+}
+
+// durationUnits are the units supported by the duration filter
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// valueFunc transforms a value using the parameters of the filter
+type valueFunc func(value any, params map[string]string) (any, error)
+
+// newValueFilter returns the constructor of a filter transforming values of any type.
+// The parameters are validated against the accepted ones before the function is called
+func newValueFilter(name string, fn valueFunc, accepted ...string) func(string) Filter {
+	return func(token string) Filter {
+		return &FuncFilter{
+			fn: func(value any, params map[string]string) (any, error) {
+				params, err := validateParams(params, accepted...)
+				if err != nil {
+					return nil, fmt.Errorf("filter %s: %w", name, err)
+				}
+
+				result, err := fn(value, params)
+				if err != nil {
+					return nil, fmt.Errorf("filter %s: %w", name, err)
+				}
+
+				return result, nil
+			},
+		}
+	}
+}
+
+// newNumberFilter returns the constructor of a filter transforming numbers.
+// Strings are parsed as numbers, the result keeps being an integer as long as possible
+func newNumberFilter(name string, fn valueFunc, accepted ...string) func(string) Filter {
+	return newValueFilter(name, func(value any, params map[string]string) (any, error) {
+		number, err := toNumber(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return fn(number, params)
+	}, accepted...)
+}
+
+// toNumber converts the value to an int or a float64
+func toNumber(value any) (any, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.Atoi(s); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+		return nil, fmt.Errorf("expected a number, got %q", v)
+	default:
+		return nil, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// numberParam returns the value of a numeric parameter, which has to be given, as int or float64
+func numberParam(params map[string]string, key string) (any, error) {
+	value, err := requiredParam(params, key)
+	if err != nil {
+		return nil, err
+	}
+
+	number, err := toNumber(value)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s must be a number, got %q", key, value)
+	}
+
+	return number, nil
+}
+
+// toFloat returns the number as float64
+func toFloat(number any) float64 {
+	if i, ok := number.(int); ok {
+		return float64(i)
+	}
+
+	return number.(float64)
+}
+
+// normalize returns the float as int if it has no fractional part and fits into an int
+func normalize(f float64) any {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int(f)
+	}
+
+	return f
+}
+
+// arithmetic returns a function combining the value with the parameter n.
+// If both are integers, the result is calculated using integers
+func arithmetic(intFn func(a int, b int) (any, error), floatFn func(a float64, b float64) (any, error)) valueFunc {
+	return func(value any, params map[string]string) (any, error) {
+		n, err := numberParam(params, "n")
+		if err != nil {
+			return nil, err
+		}
+
+		a, aIsInt := value.(int)
+		b, bIsInt := n.(int)
+		if aIsInt && bIsInt {
+			return intFn(a, b)
+		}
+
+		return floatFn(toFloat(value), toFloat(n))
+	}
+}
+
+// div divides the value by n. The result is an integer if the division has no remainder
+func div(value any, params map[string]string) (any, error) {
+	n, err := numberParam(params, "n")
+	if err != nil {
+		return nil, err
+	}
+	if toFloat(n) == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+
+	a, aIsInt := value.(int)
+	b, bIsInt := n.(int)
+	if aIsInt && bIsInt && a%b == 0 {
+		return a / b, nil
+	}
+
+	return toFloat(value) / toFloat(n), nil
+}
+
+// multiply multiplies the value by m, which defaults to 1
+func multiply(value any, params map[string]string) (any, error) {
+	if _, found := params["m"]; !found {
+		return value, nil
+	}
+
+	m, err := numberParam(params, "m")
+	if err != nil {
+		return nil, err
+	}
+
+	a, aIsInt := value.(int)
+	b, bIsInt := m.(int)
+	if aIsInt && bIsInt {
+		return a * b, nil
+	}
+
+	return toFloat(value) * toFloat(m), nil
+}
+
+// round rounds the value to the given number of decimal places. Without decimal places, the result is an integer
+func round(value any, params map[string]string) (any, error) {
+	precision, err := rangeParam(params, "precision", 0, 0, 15)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := params["mode"]
+	var fn func(float64) float64
+	switch mode {
+	case "", "half_up":
+		fn = math.Round
+	case "half_even":
+		fn = math.RoundToEven
+	case "up":
+		fn = math.Ceil
+	case "down":
+		fn = math.Floor
+	default:
+		return nil, fmt.Errorf("parameter mode must be half_up, half_even, up or down, got %q", mode)
+	}
+
+	if i, ok := value.(int); ok {
+		return i, nil
+	}
+
+	factor := math.Pow(10, float64(precision))
+	rounded := fn(value.(float64)*factor) / factor
+	if precision == 0 {
+		return int(rounded), nil
+	}
+
+	return rounded, nil
+}
+
+// toInt converts the value to an integer, fractional parts of floats are truncated
+func toInt(value any, _ map[string]string) (any, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case float64:
+		return int(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.Atoi(strings.TrimSpace(v))
+	default:
+		return nil, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// toFloatFilter converts the value to a float
+func toFloatFilter(value any, _ map[string]string) (any, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	default:
+		return nil, fmt.Errorf("expected a number, got %T", value)
+	}
+}
+
+// toBool converts the value to a boolean. Besides true and false, yes, no, on, off, 1 and 0 are accepted
+func toBool(value any, _ map[string]string) (any, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int:
+		return v != 0, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "y", "1":
+			return true, nil
+		case "false", "no", "off", "n", "0", "":
+			return false, nil
+		}
+		return nil, fmt.Errorf("expected a boolean like true, yes or on, got %q", v)
+	default:
+		return nil, fmt.Errorf("expected a boolean, got %T", value)
+	}
+}
+
+// parseByteSize parses a size like 512Mi, 2G or 1.5GiB and returns the number of bytes
+func parseByteSize(value any) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		s := strings.TrimSpace(v)
+		end := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		if end < 0 {
+			end = len(s)
+		}
+
+		number, err := strconv.ParseFloat(s[:end], 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a size like 512Mi or 2G, got %q", v)
+		}
+		factor, found := byteUnits[strings.TrimSpace(s[end:])]
+		if !found {
+			return 0, fmt.Errorf("unknown unit %q of %q", strings.TrimSpace(s[end:]), v)
+		}
+
+		return number * factor, nil
+	default:
+		return 0, fmt.Errorf("expected a size, got %T", value)
+	}
+}
+
+// bytesFilter converts a size like 512Mi or 2G to the unit given by to, which defaults to bytes
+func bytesFilter(value any, params map[string]string) (any, error) {
+	to := params["to"]
+	factor, found := byteUnits[to]
+	if !found {
+		return nil, fmt.Errorf("unknown unit %q, expected one of B, K, M, G, T, P, E, Ki, Mi, Gi, Ti, Pi or Ei", to)
+	}
+
+	size, err := parseByteSize(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalize(size / factor), nil
+}
+
+// durationFilter converts a duration like 1h30m to the unit given by to, which defaults to seconds.
+// Numbers are treated as seconds
+func durationFilter(value any, params map[string]string) (any, error) {
+	to, found := params["to"]
+	if !found {
+		to = "s"
+	}
+	unit, found := durationUnits[to]
+	if !found {
+		return nil, fmt.Errorf("unknown unit %q, expected one of ns, us, ms, s, m or h", to)
+	}
+
+	var d time.Duration
+	switch v := value.(type) {
+	case int:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	case string:
+		s := strings.TrimSpace(v)
+		if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+			d = time.Duration(seconds * float64(time.Second))
+			break
+		}
+
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("expected a duration like 1h30m, got %q", v)
+		}
+	default:
+		return nil, fmt.Errorf("expected a duration, got %T", value)
+	}
+
+	return normalize(float64(d) / float64(unit)), nil
+}
+
+// init registers the numeric, boolean and conversion filters
+func init() {
+	filterMap["to_int"] = newValueFilter("to_int", toInt)
+	filterMap["to_float"] = newValueFilter("to_float", toFloatFilter)
+	filterMap["to_bool"] = newValueFilter("to_bool", toBool)
+
+	filterMap["add"] = newNumberFilter("add", arithmetic(
+		func(a int, b int) (any, error) { return a + b, nil },
+		func(a float64, b float64) (any, error) { return a + b, nil },
+	), "n")
+	filterMap["sub"] = newNumberFilter("sub", arithmetic(
+		func(a int, b int) (any, error) { return a - b, nil },
+		func(a float64, b float64) (any, error) { return a - b, nil },
+	), "n")
+	filterMap["mod"] = newNumberFilter("mod", arithmetic(
+		func(a int, b int) (any, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return a % b, nil
+		},
+		func(a float64, b float64) (any, error) {
+			if b == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return math.Mod(a, b), nil
+		},
+	), "n")
+	filterMap["min"] = newNumberFilter("min", arithmetic(
+		func(a int, b int) (any, error) { return min(a, b), nil },
+		func(a float64, b float64) (any, error) { return min(a, b), nil },
+	), "n")
+	filterMap["max"] = newNumberFilter("max", arithmetic(
+		func(a int, b int) (any, error) { return max(a, b), nil },
+		func(a float64, b float64) (any, error) { return max(a, b), nil },
+	), "n")
+	filterMap["div"] = newNumberFilter("div", div, "n")
+	filterMap["multiply"] = newNumberFilter("multiply", multiply, "m")
+	filterMap["round"] = newNumberFilter("round", round, "precision", "mode")
+
+	filterMap["bytes"] = newValueFilter("bytes", bytesFilter, "to")
+	filterMap["duration"] = newValueFilter("duration", durationFilter, "to")
+}
@@ -0,0 +1,105 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberFilters(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		input    any
+		expected any
+		wantErr  string
+	}{
+		{desc: "to_int", filter: "to_int", input: " 42 ", expected: 42},
+		{desc: "to_int float", filter: "to_int", input: 2.9, expected: 2},
+		{desc: "to_int invalid", filter: "to_int", input: "4.2", wantErr: `filter to_int: strconv.Atoi: parsing "4.2": invalid syntax`},
+		{desc: "to_int list", filter: "to_int", input: []string{"1"}, wantErr: "filter to_int: expected a number, got []string"},
+		{desc: "to_float", filter: "to_float", input: "123.45", expected: 123.45},
+		{desc: "to_float int", filter: "to_float", input: 2, expected: 2.0},
+		{desc: "to_bool yes", filter: "to_bool", input: "Yes", expected: true},
+		{desc: "to_bool off", filter: "to_bool", input: "off", expected: false},
+		{desc: "to_bool invalid", filter: "to_bool", input: "maybe", wantErr: `filter to_bool: expected a boolean like true, yes or on, got "maybe"`},
+		{desc: "add", filter: "add", params: map[string]string{"n": "2"}, input: "40", expected: 42},
+		{desc: "add float", filter: "add", params: map[string]string{"n": "0.5"}, input: 1, expected: 1.5},
+		{desc: "add without n", filter: "add", input: 1, wantErr: "filter add: missing parameter n"},
+		{desc: "add invalid n", filter: "add", params: map[string]string{"n": "two"}, input: 1, wantErr: `filter add: parameter n must be a number, got "two"`},
+		{desc: "add string", filter: "add", params: map[string]string{"n": "1"}, input: "one", wantErr: `filter add: expected a number, got "one"`},
+		{desc: "add bool", filter: "add", params: map[string]string{"n": "1"}, input: true, wantErr: "filter add: expected a number, got bool"},
+		{desc: "sub", filter: "sub", params: map[string]string{"n": "256"}, input: 1024, expected: 768},
+		{desc: "div exact", filter: "div", params: map[string]string{"n": "4"}, input: 1024, expected: 256},
+		{desc: "div", filter: "div", params: map[string]string{"n": "4"}, input: 10, expected: 2.5},
+		{desc: "div by zero", filter: "div", params: map[string]string{"n": "0"}, input: 10, wantErr: "filter div: division by zero"},
+		{desc: "mod", filter: "mod", params: map[string]string{"n": "3"}, input: 10, expected: 1},
+		{desc: "mod by zero", filter: "mod", params: map[string]string{"n": "0"}, input: 10, wantErr: "filter mod: division by zero"},
+		{desc: "min", filter: "min", params: map[string]string{"n": "4096"}, input: "8192", expected: 4096},
+		{desc: "max", filter: "max", params: map[string]string{"n": "256"}, input: 128, expected: 256},
+		{desc: "multiply", filter: "multiply", params: map[string]string{"m": "2"}, input: 21, expected: 42},
+		{desc: "multiply float", filter: "multiply", params: map[string]string{"m": "0.75"}, input: "1024", expected: 768.0},
+		{desc: "multiply string", filter: "multiply", params: map[string]string{"m": "2"}, input: "abc", wantErr: `filter multiply: expected a number, got "abc"`},
+		{desc: "multiply without m", filter: "multiply", input: "5", expected: 5},
+		{desc: "round", filter: "round", input: 767.5, expected: 768},
+		{desc: "round down", filter: "round", params: map[string]string{"mode": "down"}, input: 767.9, expected: 767},
+		{desc: "round precision", filter: "round", params: map[string]string{"precision": "2"}, input: "3.14159", expected: 3.14},
+		{desc: "round int", filter: "round", input: 3, expected: 3},
+		{desc: "round unknown mode", filter: "round", params: map[string]string{"mode": "sideways"}, input: 3.5, wantErr: `filter round: parameter mode must be half_up, half_even, up or down, got "sideways"`},
+		{desc: "bytes", filter: "bytes", input: "512Mi", expected: 536870912},
+		{desc: "bytes to Mi", filter: "bytes", params: map[string]string{"to": "Mi"}, input: "2Gi", expected: 2048},
+		{desc: "bytes decimal", filter: "bytes", params: map[string]string{"to": "M"}, input: "2G", expected: 2000},
+		{desc: "bytes fraction", filter: "bytes", params: map[string]string{"to": "Gi"}, input: "1536Mi", expected: 1.5},
+		{desc: "bytes number", filter: "bytes", params: map[string]string{"to": "Ki"}, input: "1048576", expected: 1024},
+		{desc: "bytes unknown unit", filter: "bytes", input: "2X", wantErr: `filter bytes: unknown unit "X" of "2X"`},
+		{desc: "bytes unknown target unit", filter: "bytes", params: map[string]string{"to": "lb"}, input: "2G", wantErr: `filter bytes: unknown unit "lb"`},
+		{desc: "bytes invalid", filter: "bytes", input: "Mi", wantErr: `filter bytes: expected a size like 512Mi or 2G, got "Mi"`},
+		{desc: "duration", filter: "duration", input: "1h30m", expected: 5400},
+		{desc: "duration to ms", filter: "duration", params: map[string]string{"to": "ms"}, input: "1.5s", expected: 1500},
+		{desc: "duration fraction", filter: "duration", params: map[string]string{"to": "m"}, input: "90s", expected: 1.5},
+		{desc: "duration seconds", filter: "duration", params: map[string]string{"to": "ms"}, input: "30", expected: 30000},
+		{desc: "duration invalid", filter: "duration", input: "soon", wantErr: `filter duration: expected a duration like 1h30m, got "soon"`},
+		{desc: "duration unknown unit", filter: "duration", params: map[string]string{"to": "d"}, input: "1h", wantErr: `filter duration: unknown unit "d", expected one of ns, us, ms, s, m or h`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			result, err := filter.Process(tC.input)
+			if tC.wantErr != "" {
+				assert.ErrorContains(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestHeapFromContainerLimit(t *testing.T) {
+	value := any("2Gi")
+	for _, step := range []struct {
+		filter string
+		params map[string]string
+	}{
+		{filter: "bytes", params: map[string]string{"to": "Mi"}},
+		{filter: "multiply", params: map[string]string{"m": "0.75"}},
+		{filter: "round"},
+	} {
+		filter := NewFilter(step.filter)
+		if step.params != nil {
+			filter.(FilterParams).AcceptParams(step.params)
+		}
+
+		var err error
+		value, err = filter.Process(value)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 1536, value)
+}
@@ -0,0 +1,218 @@
+package filter
+
+import (
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/denglertai/gonfig/pkg/logging"
+)
+
+// seed is the secret the salts of the hashing filters are derived from in deterministic mode
+var seed string
+
+// SetSeed enables the deterministic mode of the hashing filters. Rather than random salts, salts derived from the seed,
+// the filter, the input and the path of the entry are used, so processing the same file twice yields the same output.
+// Passing an empty seed disables the deterministic mode
+func SetSeed(s string) {
+	seed = s
+}
+
+// saltSource returns a salt of the given length
+type saltSource func(length int) ([]byte, error)
+
+// randomSalt returns a random salt
+func randomSalt(length int) ([]byte, error) {
+	salt := make([]byte, length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+// derivedSalt returns a source of salts derived from the secret, the name of the filter, the input and the path of the entry.
+// The input is part of the info, so entries sharing their path in different files don't share their salts
+func derivedSalt(secret string, name string, input string, path string) saltSource {
+	return func(length int) ([]byte, error) {
+		if length == 0 {
+			return []byte{}, nil
+		}
+
+		return hkdf.Key(sha256.New, []byte(secret), nil, name+"\x00"+input+"\x00"+path, length)
+	}
+}
+
+// saltInput returns the identifier of the input the entry belongs to, which salts are derived from. It is given by salt_id or
+// is the path of the file relative to the working directory, so checkouts at different locations derive the same salts.
+// Values which don't belong to a file, e.g. read from stdin, require salt_id
+func saltInput(entry Entry, params map[string]string) (string, error) {
+	if id, found := params["salt_id"]; found {
+		if id == "" {
+			return "", fmt.Errorf("parameter salt_id must not be empty")
+		}
+		return id, nil
+	}
+
+	if entry.File == "" {
+		return "", fmt.Errorf("deriving salts for values which don't belong to a file requires salt_id")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, entry.File)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// fixedSalt returns a source always returning the given salt, regardless of the requested length
+func fixedSalt(salt []byte) saltSource {
+	return func(int) ([]byte, error) {
+		return salt, nil
+	}
+}
+
+// hashFunc hashes a value, salts are obtained from the given source unless the parameters contain a salt
+type hashFunc func(value string, params map[string]string, salts saltSource) (string, error)
+
+// newHashFilter returns the constructor of a filter hashing strings using salts.
+// Salts are random unless the deterministic mode is enabled or the secret is given by salt_from.
+// An existing hash of the entry is kept if it still verifies against the value
+func newHashFilter(name string, fn hashFunc, accepted ...string) func(string) Filter {
+	accepted = append(accepted, "salt_from", "salt_id")
+
+	return func(token string) Filter {
+		f := &FuncFilter{}
+		f.fn = func(value any, params map[string]string) (any, error) {
+			return applyStringFunc(name, func(value string, params map[string]string) (string, error) {
+				return hashValue(name, fn, value, params, f.entry)
+			}, value, params, accepted...)
+		}
+
+		return f
+	}
+}
+
+// hashValue hashes the value of the entry, keeping the previous hash of the entry if it verifies against the value
+func hashValue(name string, fn hashFunc, value string, params map[string]string, entry Entry) (string, error) {
+	if entry.Previous != "" {
+		if salt := saltOf(entry.Previous, params); salt != nil {
+			hash, err := fn(value, params, fixedSalt(salt))
+			if err == nil && sameHash(hash, entry.Previous) {
+				logging.Debug("Keeping the existing hash", "filter", name, "entry", entry.Path)
+				return entry.Previous, nil
+			}
+		}
+	}
+
+	secret := seed
+	if env, found := params["salt_from"]; found {
+		value, found := os.LookupEnv(env)
+		if !found || value == "" {
+			return "", fmt.Errorf("environment variable %s containing the secret for deriving salts is not set", env)
+		}
+		secret = value
+	}
+
+	if secret == "" {
+		return fn(value, params, randomSalt)
+	}
+
+	input, err := saltInput(entry, params)
+	if err != nil {
+		return "", err
+	}
+
+	return fn(value, params, derivedSalt(secret, name, input, entry.Path))
+}
+
+// saltOf returns the salt of an existing hash in one of the formats produced by the hashing filters or nil if there is none
+func saltOf(hash string, params map[string]string) []byte {
+	// Lines of htpasswd files start with the user
+	if _, found := params["user"]; found {
+		_, hash, _ = strings.Cut(hash, ":")
+	}
+
+	fields := strings.Split(hash, "$")
+	var salt []byte
+	var err error
+	switch {
+	case bcryptVersion.MatchString(hash) && len(fields) == 4 && len(fields[3]) == 53:
+		salt, err = bcryptEncoding.DecodeString(fields[3][:22])
+	case strings.HasPrefix(hash, "$6$") || strings.HasPrefix(hash, "$apr1$"):
+		if len(fields) < 4 {
+			return nil
+		}
+		salt = cryptSaltBytes(fields[len(fields)-2])
+	case strings.HasPrefix(hash, "$7$") && len(fields) == 5:
+		salt, err = base64.StdEncoding.DecodeString(fields[3])
+	case strings.HasPrefix(hash, "$argon2id$") || strings.HasPrefix(hash, "$pbkdf2-") || strings.HasPrefix(hash, "$scrypt$"):
+		salt, err = base64.RawStdEncoding.DecodeString(fields[len(fields)-2])
+	case !strings.HasPrefix(hash, "$"):
+		// Salted digests start with the salt
+		length, _ := intParam(params, "salt_len", 0)
+		if params["encoding"] == "base64" {
+			salt, err = base64.StdEncoding.DecodeString(hash)
+		} else {
+			salt, err = hex.DecodeString(hash)
+		}
+		if err != nil || length <= 0 || length > len(salt) {
+			return nil
+		}
+		salt = salt[:length]
+	}
+	if err != nil {
+		return nil
+	}
+
+	return salt
+}
+
+// bcryptVersion matches the versions of bcrypt hashes, which only differ in bugs of some implementations not affecting
+// hashes of the bcrypt filters, e.g. $2y$ produced by htpasswd -B
+var bcryptVersion = regexp.MustCompile(`^\$2[aby]\$`)
+
+// sameHash checks if both hashes are equal, treating the versions of bcrypt hashes as equal
+func sameHash(hash string, previous string) bool {
+	if hash == previous {
+		return true
+	}
+
+	// Lines of htpasswd files start with the user
+	prefix, hashed := "", hash
+	if user, rest, found := strings.Cut(hash, ":"); found {
+		prefix, hashed = user+":", rest
+	}
+	previousHashed, found := strings.CutPrefix(previous, prefix)
+	if !found {
+		return false
+	}
+
+	return bcryptVersion.MatchString(hashed) && bcryptVersion.MatchString(previousHashed) && hashed[4:] == previousHashed[4:]
+}
+
+// cryptSaltBytes returns the bytes a salt consisting of characters of the crypt alphabet is generated from
+func cryptSaltBytes(salt string) []byte {
+	result := make([]byte, len(salt))
+	for i := range len(salt) {
+		index := strings.IndexByte(cryptAlphabet, salt[i])
+		if index < 0 {
+			return nil
+		}
+		result[i] = byte(index)
+	}
+
+	return result
+}
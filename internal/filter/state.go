@@ -0,0 +1,71 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// State holds the values created by generator filters, so subsequent renders reuse them rather than generating new values
+type State struct {
+	// values holds the generated values by file, entry path and variable
+	values map[string]map[string]map[string]string
+	// changed is set as soon as a value has been added
+	changed bool
+}
+
+// NewState creates an empty state
+func NewState() *State {
+	return &State{
+		values: map[string]map[string]map[string]string{},
+	}
+}
+
+// ParseState parses a state written by Marshal
+func ParseState(data []byte) (*State, error) {
+	s := NewState()
+	if err := json.Unmarshal(data, &s.values); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	if s.values == nil {
+		s.values = map[string]map[string]map[string]string{}
+	}
+
+	return s, nil
+}
+
+// Marshal returns the state as JSON
+func (s *State) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s.values, "", "  ")
+}
+
+// Changed returns whether values have been added since the state was created or parsed
+func (s *State) Changed() bool {
+	return s.changed
+}
+
+// get returns the value stored for the placeholder of the entry
+func (s *State) get(entry Entry) (string, bool) {
+	value, found := s.values[entry.File][entry.Path][entry.Variable]
+	return value, found
+}
+
+// set stores the value for the placeholder of the entry
+func (s *State) set(entry Entry, value string) {
+	if s.values[entry.File] == nil {
+		s.values[entry.File] = map[string]map[string]string{}
+	}
+	if s.values[entry.File][entry.Path] == nil {
+		s.values[entry.File][entry.Path] = map[string]string{}
+	}
+
+	s.values[entry.File][entry.Path][entry.Variable] = value
+	s.changed = true
+}
+
+// state is the state used by the generator filters, if set
+var state *State
+
+// UseState sets the state generated values are stored in and reused from. Passing nil disables the persistence
+func UseState(s *State) {
+	state = s
+}
@@ -0,0 +1,471 @@
+package filter
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// stringFunc transforms a single string using the parameters of the filter
+type stringFunc func(value string, params map[string]string) (string, error)
+
+// newStringFilter returns the constructor of a filter transforming strings.
+// The parameters are validated against the accepted ones before the function is called.
+// Lists, e.g. created by split, are transformed item by item
+func newStringFilter(name string, fn stringFunc, accepted ...string) func(string) Filter {
+	return func(token string) Filter {
+		return &FuncFilter{
+			fn: func(value any, params map[string]string) (any, error) {
+				return applyStringFunc(name, fn, value, params, accepted...)
+			},
+		}
+	}
+}
+
+// applyStringFunc validates the parameters and applies the function to the value or to every item of a list
+func applyStringFunc(name string, fn stringFunc, value any, params map[string]string, accepted ...string) (any, error) {
+	params, err := validateParams(params, accepted...)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %w", name, err)
+	}
+
+	switch v := value.(type) {
+	case string:
+		result, err := fn(v, params)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", name, err)
+		}
+		return result, nil
+	case []string:
+		results := make([]string, len(v))
+		for i, item := range v {
+			result, err := fn(item, params)
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", name, err)
+			}
+			results[i] = result
+		}
+		return results, nil
+	default:
+		return nil, fmt.Errorf("filter %s: expected a string, got %T", name, value)
+	}
+}
+
+// validateParams assigns positional parameters to the accepted ones in order and checks that only accepted parameters are given.
+// The parameters are copied since filters are applied repeatedly, e.g. to every value of a file, using the same parameters
+func validateParams(params map[string]string, accepted ...string) (map[string]string, error) {
+	params = maps.Clone(params)
+	if params == nil {
+		params = make(map[string]string)
+	}
+
+	if err := assignPositionalParams(params, accepted...); err != nil {
+		return nil, err
+	}
+
+	for key := range params {
+		if slices.Contains(accepted, key) {
+			continue
+		}
+
+		if len(accepted) == 0 {
+			return nil, fmt.Errorf("unknown parameter %s, the filter has no parameters", key)
+		}
+		return nil, fmt.Errorf("unknown parameter %s, expected one of %s", key, strings.Join(accepted, ", "))
+	}
+
+	return params, nil
+}
+
+// assignPositionalParams replaces the positional parameters, which are given by their index, by the accepted parameter at that index
+func assignPositionalParams(params map[string]string, accepted ...string) error {
+	for i := 0; ; i++ {
+		value, found := params[strconv.Itoa(i)]
+		if !found {
+			return nil
+		}
+
+		if i >= len(accepted) {
+			if len(accepted) == 0 {
+				return fmt.Errorf("unexpected parameter %q, the filter has no parameters", value)
+			}
+			return fmt.Errorf("too many parameters, expected at most %d: %s", len(accepted), strings.Join(accepted, ", "))
+		}
+		if _, found := params[accepted[i]]; found {
+			return fmt.Errorf("parameter %s is given twice", accepted[i])
+		}
+
+		delete(params, strconv.Itoa(i))
+		params[accepted[i]] = value
+	}
+}
+
+// requiredParam returns the value of a parameter which has to be given
+func requiredParam(params map[string]string, key string) (string, error) {
+	value, found := params[key]
+	if !found {
+		return "", fmt.Errorf("missing parameter %s", key)
+	}
+
+	return value, nil
+}
+
+// intParam returns the value of an integer parameter or the default if it is not given
+func intParam(params map[string]string, key string, defaultValue int) (int, error) {
+	value, found := params[key]
+	if !found {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s must be an integer, got %q", key, value)
+	}
+
+	return i, nil
+}
+
+// nonNegativeIntParam returns the value of an integer parameter which must not be negative
+func nonNegativeIntParam(params map[string]string, key string, defaultValue int) (int, error) {
+	i, err := intParam(params, key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("parameter %s must not be negative, got %d", key, i)
+	}
+
+	return i, nil
+}
+
+// rangeParam returns the value of an integer parameter which has to be within min and max
+func rangeParam(params map[string]string, key string, defaultValue int, min int, max int) (int, error) {
+	i, err := intParam(params, key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if i < min || i > max {
+		return 0, fmt.Errorf("parameter %s must be between %d and %d, got %d", key, min, max, i)
+	}
+
+	return i, nil
+}
+
+// replace replaces all occurrences of old by new
+func replace(value string, params map[string]string) (string, error) {
+	old, err := requiredParam(params, "old")
+	if err != nil {
+		return "", err
+	}
+	if old == "" {
+		return "", fmt.Errorf("parameter old must not be empty")
+	}
+
+	return strings.ReplaceAll(value, old, params["new"]), nil
+}
+
+// regexReplace replaces all matches of pattern by repl, which may reference groups like $1
+func regexReplace(value string, params map[string]string) (string, error) {
+	pattern, err := requiredParam(params, "pattern")
+	if err != nil {
+		return "", err
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	return re.ReplaceAllString(value, params["repl"]), nil
+}
+
+// substr returns len characters starting at start. A negative start counts from the end
+func substr(value string, params map[string]string) (string, error) {
+	runes := []rune(value)
+
+	start, err := intParam(params, "start", 0)
+	if err != nil {
+		return "", err
+	}
+	length, err := nonNegativeIntParam(params, "len", len(runes))
+	if err != nil {
+		return "", err
+	}
+
+	if start < 0 {
+		start = max(len(runes)+start, 0)
+	}
+	start = min(start, len(runes))
+	end := min(start+length, len(runes))
+
+	return string(runes[start:end]), nil
+}
+
+// prefix prepends text unless the value already starts with it
+func prefix(value string, params map[string]string) (string, error) {
+	text, err := requiredParam(params, "text")
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(value, text) {
+		return value, nil
+	}
+
+	return text + value, nil
+}
+
+// suffix appends text unless the value already ends with it
+func suffix(value string, params map[string]string) (string, error) {
+	text, err := requiredParam(params, "text")
+	if err != nil {
+		return "", err
+	}
+	if strings.HasSuffix(value, text) {
+		return value, nil
+	}
+
+	return value + text, nil
+}
+
+// pad pads the value with char to len characters on the given side
+func pad(value string, params map[string]string) (string, error) {
+	if _, err := requiredParam(params, "len"); err != nil {
+		return "", err
+	}
+	width, err := nonNegativeIntParam(params, "len", 0)
+	if err != nil {
+		return "", err
+	}
+
+	char, found := params["char"]
+	if !found {
+		char = " "
+	}
+	if utf8.RuneCountInString(char) != 1 {
+		return "", fmt.Errorf("parameter char must be a single character, got %q", char)
+	}
+
+	side := params["side"]
+	if side != "" && side != "left" && side != "right" {
+		return "", fmt.Errorf("parameter side must be left or right, got %q", side)
+	}
+
+	missing := width - utf8.RuneCountInString(value)
+	if missing <= 0 {
+		return value, nil
+	}
+	padding := strings.Repeat(char, missing)
+
+	if side == "right" {
+		return value + padding, nil
+	}
+
+	return padding + value, nil
+}
+
+// truncate shortens the value to len characters including the suffix, if the value is longer
+func truncate(value string, params map[string]string) (string, error) {
+	if _, err := requiredParam(params, "len"); err != nil {
+		return "", err
+	}
+	length, err := nonNegativeIntParam(params, "len", 0)
+	if err != nil {
+		return "", err
+	}
+
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value, nil
+	}
+
+	ellipsis := []rune(params["suffix"])
+	if len(ellipsis) > length {
+		return "", fmt.Errorf("parameter suffix must not be longer than %d characters", length)
+	}
+
+	return string(runes[:length-len(ellipsis)]) + string(ellipsis), nil
+}
+
+// trim removes the given characters or whitespace from both ends
+func trim(value string, params map[string]string) (string, error) {
+	if chars, found := params["chars"]; found {
+		return strings.Trim(value, chars), nil
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+// trimLeft removes the given characters or spaces from the start
+func trimLeft(value string, params map[string]string) (string, error) {
+	if chars, found := params["chars"]; found {
+		return strings.TrimLeft(value, chars), nil
+	}
+
+	return strings.TrimLeft(value, " "), nil
+}
+
+// trimRight removes the given characters or spaces from the end
+func trimRight(value string, params map[string]string) (string, error) {
+	if chars, found := params["chars"]; found {
+		return strings.TrimRight(value, chars), nil
+	}
+
+	return strings.TrimRight(value, " "), nil
+}
+
+// title capitalizes the first letter of every word
+func title(value string, _ map[string]string) (string, error) {
+	return cases.Title(language.Und, cases.NoLower).String(value), nil
+}
+
+// snake converts the value to snake_case
+func snake(value string, _ map[string]string) (string, error) {
+	return strings.ToLower(strings.Join(words(value), "_")), nil
+}
+
+// kebab converts the value to kebab-case
+func kebab(value string, _ map[string]string) (string, error) {
+	return strings.ToLower(strings.Join(words(value), "-")), nil
+}
+
+// camel converts the value to camelCase
+func camel(value string, _ map[string]string) (string, error) {
+	var sb strings.Builder
+	for i, word := range words(value) {
+		word = strings.ToLower(word)
+		if i > 0 {
+			r, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(r)) + word[size:]
+		}
+		sb.WriteString(word)
+	}
+
+	return sb.String(), nil
+}
+
+// words splits the value into words at characters other than letters and digits and at changes of case,
+// e.g. HTTPServer_port becomes HTTP, Server and port
+func words(value string) []string {
+	result := make([]string, 0)
+	runes := []rune(value)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				result = append(result, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+
+		if start >= 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			// Either the start of a word after a lower case letter or digit or the last letter of an acronym followed by a word
+			if !unicode.IsUpper(previous) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				result = append(result, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		result = append(result, string(runes[start:]))
+	}
+
+	return result
+}
+
+// init registers the string filters
+func init() {
+	filterMap["replace"] = newStringFilter("replace", replace, "old", "new")
+	filterMap["regex_replace"] = newStringFilter("regex_replace", regexReplace, "pattern", "repl")
+	filterMap["substr"] = newStringFilter("substr", substr, "start", "len")
+	filterMap["prefix"] = newStringFilter("prefix", prefix, "text")
+	filterMap["suffix"] = newStringFilter("suffix", suffix, "text")
+	filterMap["pad"] = newStringFilter("pad", pad, "len", "char", "side")
+	filterMap["truncate"] = newStringFilter("truncate", truncate, "len", "suffix")
+	filterMap["trim"] = newStringFilter("trim", trim, "chars")
+	filterMap["trimleft"] = newStringFilter("trimleft", trimLeft, "chars")
+	filterMap["trimright"] = newStringFilter("trimright", trimRight, "chars")
+	filterMap["title"] = newStringFilter("title", title)
+	filterMap["snake"] = newStringFilter("snake", snake)
+	filterMap["kebab"] = newStringFilter("kebab", kebab)
+	filterMap["camel"] = newStringFilter("camel", camel)
+
+	filterMap["split"] = func(token string) Filter {
+		return &FuncFilter{
+			fn: func(value any, params map[string]string) (any, error) {
+				params, err := validateParams(params, "sep", "index")
+				if err != nil {
+					return nil, fmt.Errorf("filter split: %w", err)
+				}
+
+				s, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("filter split: expected a string, got %T", value)
+				}
+
+				sep, found := params["sep"]
+				if !found {
+					sep = ","
+				}
+				parts := strings.Split(s, sep)
+
+				if _, found := params["index"]; !found {
+					return parts, nil
+				}
+
+				// A single part may be selected, negative indices count from the end
+				index, err := intParam(params, "index", 0)
+				if err != nil {
+					return nil, fmt.Errorf("filter split: %w", err)
+				}
+				if index < 0 {
+					index += len(parts)
+				}
+				if index < 0 || index >= len(parts) {
+					return nil, fmt.Errorf("filter split: index %s out of range, the value has %d parts", params["index"], len(parts))
+				}
+
+				return parts[index], nil
+			},
+		}
+	}
+
+	filterMap["join"] = func(token string) Filter {
+		return &FuncFilter{
+			fn: func(value any, params map[string]string) (any, error) {
+				params, err := validateParams(params, "sep")
+				if err != nil {
+					return nil, fmt.Errorf("filter join: %w", err)
+				}
+
+				sep, found := params["sep"]
+				if !found {
+					sep = ","
+				}
+
+				switch v := value.(type) {
+				case string:
+					return v, nil
+				case []string:
+					return strings.Join(v, sep), nil
+				default:
+					return nil, fmt.Errorf("filter join: expected a list, got %T", value)
+				}
+			},
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringFilters(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		input    any
+		expected any
+		wantErr  string
+	}{
+		{desc: "replace", filter: "replace", params: map[string]string{"old": ".", "new": "-"}, input: "db.example.com", expected: "db-example-com"},
+		{desc: "replace with empty new", filter: "replace", params: map[string]string{"old": "-dev"}, input: "shop-dev", expected: "shop"},
+		{desc: "replace without old", filter: "replace", params: map[string]string{"new": "-"}, input: "a.b", wantErr: "filter replace: missing parameter old"},
+		{desc: "replace with unknown parameter", filter: "replace", params: map[string]string{"old": ".", "with": "-"}, input: "a.b", wantErr: "filter replace: unknown parameter with, expected one of old, new"},
+		{desc: "regex_replace", filter: "regex_replace", params: map[string]string{"pattern": `^(\w+)-(\w+)$`, "repl": "$2.$1"}, input: "shop-eu", expected: "eu.shop"},
+		{desc: "regex_replace with invalid pattern", filter: "regex_replace", params: map[string]string{"pattern": "[a"}, input: "shop", wantErr: "filter regex_replace: invalid pattern"},
+		{desc: "substr", filter: "substr", params: map[string]string{"start": "1", "len": "3"}, input: "gönfig", expected: "önf"},
+		{desc: "substr from the end", filter: "substr", params: map[string]string{"start": "-3"}, input: "gonfig", expected: "fig"},
+		{desc: "substr out of range", filter: "substr", params: map[string]string{"start": "10"}, input: "gonfig", expected: ""},
+		{desc: "substr with invalid start", filter: "substr", params: map[string]string{"start": "one"}, input: "gonfig", wantErr: `filter substr: parameter start must be an integer, got "one"`},
+		{desc: "substr with negative len", filter: "substr", params: map[string]string{"len": "-1"}, input: "gonfig", wantErr: "filter substr: parameter len must not be negative, got -1"},
+		{desc: "split", filter: "split", params: map[string]string{"sep": ";"}, input: "a;b;c", expected: []string{"a", "b", "c"}},
+		{desc: "split with index", filter: "split", params: map[string]string{"sep": ".", "index": "-1"}, input: "db.example.com", expected: "com"},
+		{desc: "split with index out of range", filter: "split", params: map[string]string{"index": "3"}, input: "a,b", wantErr: "filter split: index 3 out of range, the value has 2 parts"},
+		{desc: "join", filter: "join", params: map[string]string{"sep": ";"}, input: []string{"a", "b"}, expected: "a;b"},
+		{desc: "join string", filter: "join", input: "a", expected: "a"},
+		{desc: "prefix", filter: "prefix", params: map[string]string{"text": "https://"}, input: "example.com", expected: "https://example.com"},
+		{desc: "prefix already present", filter: "prefix", params: map[string]string{"text": "https://"}, input: "https://example.com", expected: "https://example.com"},
+		{desc: "prefix list", filter: "prefix", params: map[string]string{"text": "node-"}, input: []string{"a", "b"}, expected: []string{"node-a", "node-b"}},
+		{desc: "suffix", filter: "suffix", params: map[string]string{"text": ".internal"}, input: "db", expected: "db.internal"},
+		{desc: "suffix without text", filter: "suffix", input: "db", wantErr: "filter suffix: missing parameter text"},
+		{desc: "pad", filter: "pad", params: map[string]string{"len": "5", "char": "0"}, input: "42", expected: "00042"},
+		{desc: "pad right", filter: "pad", params: map[string]string{"len": "4", "side": "right"}, input: "ab", expected: "ab  "},
+		{desc: "pad longer value", filter: "pad", params: map[string]string{"len": "1"}, input: "ab", expected: "ab"},
+		{desc: "pad with invalid char", filter: "pad", params: map[string]string{"len": "4", "char": "ab"}, input: "ab", wantErr: `filter pad: parameter char must be a single character, got "ab"`},
+		{desc: "pad with invalid side", filter: "pad", params: map[string]string{"len": "1", "side": "center"}, input: "ab", wantErr: `filter pad: parameter side must be left or right, got "center"`},
+		{desc: "truncate", filter: "truncate", params: map[string]string{"len": "5", "suffix": "..."}, input: "gonfig rocks", expected: "go..."},
+		{desc: "truncate short value", filter: "truncate", params: map[string]string{"len": "63"}, input: "gonfig", expected: "gonfig"},
+		{desc: "truncate without len", filter: "truncate", input: "gonfig", wantErr: "filter truncate: missing parameter len"},
+		{desc: "trim", filter: "trim", input: " gonfig\n", expected: "gonfig"},
+		{desc: "trim chars", filter: "trim", params: map[string]string{"chars": "/"}, input: "/api/", expected: "api"},
+		{desc: "trimleft chars", filter: "trimleft", params: map[string]string{"chars": "0"}, input: "0042", expected: "42"},
+		{desc: "trimright", filter: "trimright", input: "a  ", expected: "a"},
+		{desc: "title", filter: "title", input: "hello gonfig world", expected: "Hello Gonfig World"},
+		{desc: "title without parameters", filter: "title", params: map[string]string{"x": "1"}, input: "a", wantErr: "filter title: unknown parameter x, the filter has no parameters"},
+		{desc: "snake", filter: "snake", input: "HTTPServer port-Name", expected: "http_server_port_name"},
+		{desc: "kebab", filter: "kebab", input: "myShopEU_v2", expected: "my-shop-eu-v2"},
+		{desc: "camel", filter: "camel", input: "db-connection_URL", expected: "dbConnectionUrl"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			assert.IsType(t, &FuncFilter{}, filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			result, err := filter.Process(tC.input)
+			if tC.wantErr != "" {
+				assert.ErrorContains(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestStringFilterRejectsNonStrings(t *testing.T) {
+	_, err := NewFilter("snake").Process(42)
+	assert.EqualError(t, err, "filter snake: expected a string, got int")
+}
+
+func TestPositionalParams(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filter   string
+		params   map[string]string
+		expected any
+		wantErr  string
+	}{
+		{desc: "positional", filter: "replace", params: map[string]string{"0": "a", "1": "b"}, expected: "bbc"},
+		{desc: "positional and named", filter: "replace", params: map[string]string{"0": "a", "new": "c"}, expected: "cbc"},
+		{desc: "too many", filter: "replace", params: map[string]string{"0": "a", "1": "b", "2": "c"}, wantErr: "filter replace: too many parameters, expected at most 2: old, new"},
+		{desc: "given twice", filter: "replace", params: map[string]string{"0": "a", "old": "b"}, wantErr: "filter replace: parameter old is given twice"},
+		{desc: "no parameters", filter: "title", params: map[string]string{"0": "a"}, wantErr: `filter title: unexpected parameter "a", the filter has no parameters`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			filter.(FilterParams).AcceptParams(tC.params)
+
+			result, err := filter.Process("abc")
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestPositionalParamsReused(t *testing.T) {
+	params := map[string]string{"0": "a", "1": "b"}
+
+	filter := NewFilter("replace")
+	filter.(FilterParams).AcceptParams(params)
+
+	// The same filter is applied to every value of a file
+	for value, expected := range map[string]string{"abc": "bbc", "cab": "cbb"} {
+		result, err := filter.Process(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result)
+	}
+	assert.Equal(t, map[string]string{"0": "a", "1": "b"}, params)
+}
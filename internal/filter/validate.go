@@ -0,0 +1,307 @@
+package filter
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// hostnameLabelRe matches a single label of a hostname as defined by RFC 1123
+var hostnameLabelRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// checkFunc returns a check of single values using the parameters of the filter.
+// The check describes what was expected if a value is invalid
+type checkFunc func(params map[string]string) (func(value string) error, error)
+
+// valueCheck returns a checkFunc for checks without parameters
+func valueCheck(check func(value string) error) checkFunc {
+	return func(map[string]string) (func(value string) error, error) {
+		return check, nil
+	}
+}
+
+// newValidationFilter returns the constructor of a filter validating values.
+// Valid values are returned unchanged, lists are validated item by item and other values by their text.
+// Errors name the variable and the path of the entry and contain the value masked, as it may be a secret
+func newValidationFilter(name string, fn checkFunc, accepted ...string) func(string) Filter {
+	return newEntryFilter(name, func(value any, params map[string]string, entry Entry) (any, error) {
+		check, err := fn(params)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []string
+		switch v := value.(type) {
+		case string:
+			items = []string{v}
+		case []string:
+			items = v
+		default:
+			items = []string{fmt.Sprint(v)}
+		}
+
+		for _, item := range items {
+			if err := check(item); err != nil {
+				return nil, invalidValue(item, entry, err)
+			}
+		}
+
+		return value, nil
+	}, accepted...)
+}
+
+// invalidValue returns an error describing the invalid value, the entry it belongs to and the reason
+func invalidValue(value string, entry Entry, err error) error {
+	description := "invalid value " + mask(value)
+	if entry.Variable != "" {
+		description += " of variable " + entry.Variable
+	}
+	if entry.Path != "" {
+		description += " at " + entry.Path
+	}
+
+	return fmt.Errorf("%s: %w", description, err)
+}
+
+// mask hides most of the value, only a few leading characters and the length are kept to help identifying it
+func mask(value string) string {
+	length := utf8.RuneCountInString(value)
+	visible := min(length/4, 4)
+
+	return fmt.Sprintf("%q (%d characters)", string([]rune(value)[:visible])+"***", length)
+}
+
+// isInt checks that the value is an integer
+func isInt(value string) error {
+	if _, err := strconv.Atoi(strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("expected an integer")
+	}
+
+	return nil
+}
+
+// isURL checks that values are absolute URLs, using one of the schemes separated by ; if given
+func isURL(params map[string]string) (func(value string) error, error) {
+	var schemes []string
+	if _, found := params["schemes"]; found {
+		var err error
+		if schemes, err = listParam(params, "schemes"); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(value string) error {
+		u, err := url.Parse(strings.TrimSpace(value))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("expected an absolute URL like https://example.com")
+		}
+		if schemes != nil && !slices.Contains(schemes, strings.ToLower(u.Scheme)) {
+			return fmt.Errorf("expected a URL with scheme %s, got %s", strings.Join(schemes, ", "), u.Scheme)
+		}
+
+		return nil
+	}, nil
+}
+
+// isEmail checks that the value is a plain email address without display name
+func isEmail(value string) error {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		return fmt.Errorf("expected an email address like user@example.com")
+	}
+
+	return nil
+}
+
+// isHostname checks that the value is a hostname as defined by RFC 1123
+func isHostname(value string) error {
+	hostname := strings.TrimSuffix(value, ".")
+	if hostname == "" || len(hostname) > 253 {
+		return fmt.Errorf("expected a hostname of at most 253 characters")
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		if !hostnameLabelRe.MatchString(label) {
+			return fmt.Errorf("expected a hostname consisting of letters, digits and hyphens")
+		}
+	}
+
+	return nil
+}
+
+// isPort checks that the value is a TCP or UDP port
+func isPort(value string) error {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("expected a port between 1 and 65535")
+	}
+
+	return nil
+}
+
+// isIP checks that values are IP addresses of the given version, if any
+func isIP(params map[string]string) (func(value string) error, error) {
+	version := params["version"]
+	if version != "" && version != "4" && version != "6" {
+		return nil, fmt.Errorf("parameter version must be 4 or 6, got %q", version)
+	}
+
+	return func(value string) error {
+		addr, err := netip.ParseAddr(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("expected an IP address")
+		}
+		if version == "4" && !addr.Is4() {
+			return fmt.Errorf("expected an IPv4 address")
+		}
+		if version == "6" && !addr.Is6() {
+			return fmt.Errorf("expected an IPv6 address")
+		}
+
+		return nil
+	}, nil
+}
+
+// isCIDR checks that the value is an IP network in CIDR notation
+func isCIDR(value string) error {
+	if _, err := netip.ParsePrefix(strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("expected a network in CIDR notation like 10.0.0.0/8")
+	}
+
+	return nil
+}
+
+// isJSON checks that the value is valid JSON
+func isJSON(value string) error {
+	if !json.Valid([]byte(value)) {
+		return fmt.Errorf("expected valid JSON")
+	}
+
+	return nil
+}
+
+// isPEM checks that values consist of PEM blocks, of the given type if any
+func isPEM(params map[string]string) (func(value string) error, error) {
+	blockType := params["type"]
+
+	return func(value string) error {
+		rest := []byte(value)
+		blocks := 0
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			if blockType != "" && block.Type != blockType {
+				return fmt.Errorf("expected PEM blocks of type %s, got %s", blockType, block.Type)
+			}
+			blocks++
+		}
+
+		if blocks == 0 || strings.TrimSpace(string(rest)) != "" {
+			return fmt.Errorf("expected PEM encoded data like -----BEGIN CERTIFICATE-----")
+		}
+
+		return nil
+	}, nil
+}
+
+// matchRegex returns a checkFunc whether values match the regular expression given by the parameter key
+func matchRegex(key string) checkFunc {
+	return func(params map[string]string) (func(value string) error, error) {
+		pattern, err := requiredParam(params, key)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s must be a valid regular expression: %w", key, err)
+		}
+
+		return func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("expected a value matching %q", pattern)
+			}
+
+			return nil
+		}, nil
+	}
+}
+
+// oneOf checks that values are one of the values separated by ;
+func oneOf(params map[string]string) (func(value string) error, error) {
+	values, err := listParam(params, "values")
+	if err != nil {
+		return nil, err
+	}
+
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return fmt.Errorf("expected one of %s", strings.Join(values, ", "))
+		}
+
+		return nil
+	}, nil
+}
+
+// length checks that the value has between min and max characters. Lists are checked by their number of items
+func length(value any, params map[string]string, entry Entry) (any, error) {
+	lower, err := nonNegativeIntParam(params, "min", 0)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := intParam(params, "max", -1)
+	if err != nil {
+		return nil, err
+	}
+	if _, found := params["max"]; found && upper < lower {
+		return nil, fmt.Errorf("parameter max must not be less than min, got %d and %d", upper, lower)
+	}
+
+	var n int
+	var text string
+	switch v := value.(type) {
+	case []string:
+		n = len(v)
+		text = strings.Join(v, ";")
+	default:
+		text = fmt.Sprint(v)
+		n = utf8.RuneCountInString(text)
+	}
+
+	if n < lower || (upper >= 0 && n > upper) {
+		expected := fmt.Sprintf("at least %d", lower)
+		if upper >= 0 {
+			expected = fmt.Sprintf("between %d and %d", lower, upper)
+		}
+		return nil, invalidValue(text, entry, fmt.Errorf("expected a length of %s, got %d", expected, n))
+	}
+
+	return value, nil
+}
+
+// init registers the validation filters
+func init() {
+	filterMap["is_int"] = newValidationFilter("is_int", valueCheck(isInt))
+	filterMap["is_url"] = newValidationFilter("is_url", isURL, "schemes")
+	filterMap["is_email"] = newValidationFilter("is_email", valueCheck(isEmail))
+	filterMap["is_hostname"] = newValidationFilter("is_hostname", valueCheck(isHostname))
+	filterMap["is_port"] = newValidationFilter("is_port", valueCheck(isPort))
+	filterMap["is_ip"] = newValidationFilter("is_ip", isIP, "version")
+	filterMap["is_cidr"] = newValidationFilter("is_cidr", valueCheck(isCIDR))
+	filterMap["is_json"] = newValidationFilter("is_json", valueCheck(isJSON))
+	filterMap["is_pem"] = newValidationFilter("is_pem", isPEM, "type")
+	filterMap["regex"] = newValidationFilter("regex", matchRegex("pattern"), "pattern")
+	filterMap["match"] = newValidationFilter("match", matchRegex("regex"), "regex")
+	filterMap["oneof"] = newValidationFilter("oneof", oneOf, "values")
+	filterMap["len"] = newEntryFilter("len", length, "min", "max")
+}
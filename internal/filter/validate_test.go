@@ -0,0 +1,87 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCertificate = `-----BEGIN CERTIFICATE-----
+MIIBszCCAVmgAwIBAgIUAnEzLXIUx6pS1Ra2gg+/nm5TX/AwCgYIKoZIzj0EAwIw
+-----END CERTIFICATE-----
+`
+
+func TestValidationFilters(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		filter  string
+		params  map[string]string
+		input   any
+		wantErr string
+	}{
+		{desc: "is_int", filter: "is_int", input: " 42 "},
+		{desc: "is_int number", filter: "is_int", input: 42},
+		{desc: "is_int invalid", filter: "is_int", input: "4.2", wantErr: `filter is_int: invalid value "***" (3 characters): expected an integer`},
+		{desc: "is_url", filter: "is_url", input: "postgres://app:s3cret@db:5432/app"},
+		{desc: "is_url schemes", filter: "is_url", params: map[string]string{"schemes": "postgres;postgresql"}, input: "postgresql://db/app"},
+		{desc: "is_url wrong scheme", filter: "is_url", params: map[string]string{"schemes": "postgres;postgresql"}, input: "mysql://db:3306/app", wantErr: `filter is_url: invalid value "mysq***" (19 characters): expected a URL with scheme postgres, postgresql, got mysql`},
+		{desc: "is_url relative", filter: "is_url", input: "db:5432/app", wantErr: `filter is_url: invalid value "db***" (11 characters): expected an absolute URL like https://example.com`},
+		{desc: "is_email", filter: "is_email", input: "admin@example.com"},
+		{desc: "is_email display name", filter: "is_email", input: "Admin <admin@example.com>", wantErr: `filter is_email: invalid value "Admi***" (25 characters): expected an email address like user@example.com`},
+		{desc: "is_hostname", filter: "is_hostname", input: "db-1.example.com"},
+		{desc: "is_hostname trailing dot", filter: "is_hostname", input: "example.com."},
+		{desc: "is_hostname invalid", filter: "is_hostname", input: "db_1.example.com", wantErr: `filter is_hostname: invalid value "db_1***" (16 characters): expected a hostname consisting of letters, digits and hyphens`},
+		{desc: "is_port", filter: "is_port", input: "8080"},
+		{desc: "is_port out of range", filter: "is_port", input: 70000, wantErr: `filter is_port: invalid value "7***" (5 characters): expected a port between 1 and 65535`},
+		{desc: "is_ip", filter: "is_ip", input: "10.0.0.1"},
+		{desc: "is_ip v6", filter: "is_ip", params: map[string]string{"version": "6"}, input: "::1"},
+		{desc: "is_ip wrong version", filter: "is_ip", params: map[string]string{"version": "4"}, input: "::1", wantErr: `filter is_ip: invalid value "***" (3 characters): expected an IPv4 address`},
+		{desc: "is_ip invalid version", filter: "is_ip", params: map[string]string{"version": "5"}, input: "::1", wantErr: `filter is_ip: parameter version must be 4 or 6, got "5"`},
+		{desc: "is_cidr", filter: "is_cidr", input: "10.0.0.0/8"},
+		{desc: "is_cidr address", filter: "is_cidr", input: "10.0.0.1", wantErr: `filter is_cidr: invalid value "10***" (8 characters): expected a network in CIDR notation like 10.0.0.0/8`},
+		{desc: "is_json", filter: "is_json", input: `{"a": [1, 2]}`},
+		{desc: "is_json invalid", filter: "is_json", input: `{"a": }`, wantErr: `filter is_json: invalid value "{***" (7 characters): expected valid JSON`},
+		{desc: "is_pem", filter: "is_pem", params: map[string]string{"type": "CERTIFICATE"}, input: testCertificate},
+		{desc: "is_pem wrong type", filter: "is_pem", params: map[string]string{"type": "PRIVATE KEY"}, input: testCertificate, wantErr: "filter is_pem: invalid value \"----***\" (119 characters): expected PEM blocks of type PRIVATE KEY, got CERTIFICATE"},
+		{desc: "is_pem trailing data", filter: "is_pem", input: testCertificate + "garbage", wantErr: "filter is_pem: invalid value \"----***\" (126 characters): expected PEM encoded data like -----BEGIN CERTIFICATE-----"},
+		{desc: "len", filter: "len", params: map[string]string{"min": "8", "max": "64"}, input: "s3cretpassword"},
+		{desc: "len too short", filter: "len", params: map[string]string{"min": "12"}, input: "s3cret", wantErr: `filter len: invalid value "s***" (6 characters): expected a length of at least 12, got 6`},
+		{desc: "len list", filter: "len", params: map[string]string{"max": "1"}, input: []string{"a", "b"}, wantErr: `filter len: invalid value "***" (3 characters): expected a length of between 0 and 1, got 2`},
+		{desc: "len invalid range", filter: "len", params: map[string]string{"min": "8", "max": "4"}, input: "abc", wantErr: "filter len: parameter max must not be less than min, got 4 and 8"},
+		{desc: "regex", filter: "regex", params: map[string]string{"pattern": `^[a-z]+$`}, input: "abc"},
+		{desc: "regex fails", filter: "regex", params: map[string]string{"pattern": `^[a-z]+$`}, input: "ABCDEF", wantErr: `filter regex: invalid value "A***" (6 characters): expected a value matching "^[a-z]+$"`},
+		{desc: "regex invalid pattern", filter: "regex", params: map[string]string{"pattern": `[`}, input: "abc", wantErr: "filter regex: parameter pattern must be a valid regular expression: error parsing regexp: missing closing ]: `[`"},
+		{desc: "regex without pattern", filter: "regex", input: "abc", wantErr: "filter regex: missing parameter pattern"},
+		{desc: "match list", filter: "match", params: map[string]string{"regex": `^db\d$`}, input: []string{"db1", "db2"}},
+		{desc: "match fails", filter: "match", params: map[string]string{"regex": `^db\d$`}, input: []string{"db1", "cache"}, wantErr: `filter match: invalid value "c***" (5 characters): expected a value matching "^db\\d$"`},
+		{desc: "oneof", filter: "oneof", params: map[string]string{"values": "debug;info;warn"}, input: "info"},
+		{desc: "oneof fails", filter: "oneof", params: map[string]string{"values": "debug;info;warn"}, input: "trace", wantErr: `filter oneof: invalid value "t***" (5 characters): expected one of debug, info, warn`},
+		{desc: "oneof encoded list", filter: "oneof", params: map[string]string{"values": EncodeList([]string{"a;b", " c "})}, input: " c "},
+		{desc: "oneof encoded list fails", filter: "oneof", params: map[string]string{"values": EncodeList([]string{"a;b"})}, input: "a", wantErr: `filter oneof: invalid value "***" (1 characters): expected one of a;b`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			filter := NewFilter(tC.filter)
+			if tC.params != nil {
+				filter.(FilterParams).AcceptParams(tC.params)
+			}
+
+			result, err := filter.Process(tC.input)
+			if tC.wantErr != "" {
+				assert.EqualError(t, err, tC.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tC.input, result)
+		})
+	}
+}
+
+func TestValidationErrorNamesEntry(t *testing.T) {
+	filter := NewFilter("is_url")
+	filter.(EntryFilter).AcceptEntry(Entry{Path: "spring.datasource.url", Variable: "DATABASE_URL"})
+
+	_, err := filter.Process("jdbc-postgresql-db-5432")
+	assert.EqualError(t, err, `filter is_url: invalid value "jdbc***" (23 characters) of variable DATABASE_URL at spring.datasource.url: expected an absolute URL like https://example.com`)
+}
@@ -14,6 +14,8 @@ const (
 	YML FileType = "yml"
 	// XML represents an XML file
 	XML FileType = "xml"
+	// TOML represents a TOML file
+	TOML FileType = "toml"
 	// PROPERTIES represents a properties file
 	PROPERTIES FileType = "properties"
 	// PLAIN represents a plain text file
@@ -0,0 +1,433 @@
+package value
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/denglertai/gonfig/internal/filter"
+	"github.com/denglertai/gonfig/pkg/logging"
+)
+
+// The placeholders of a value follow the grammar
+//
+//	placeholder = "${" variable { "|" filter } "}"
+//	filter      = name [ "(" [ param { "," param } ] ")" ]
+//	param       = [ key "=" ] ( string | list | bare )
+//	list        = "[" [ string { "," string } ] "]"
+//
+// Strings are enclosed in single or double quotes and support the escapes \\, \', \", \n, \r and \t.
+// Bare values, which include numbers, end at the next "," or ")" not enclosed in parentheses, brackets or braces and are trimmed.
+// Only named parameters may have empty bare values, e.g. replace(old=a,new=).
+// A "[" only starts a list if it is followed by a quote or "]", otherwise it is part of a bare value like [0-9]+.
+// Lists are passed to the filters encoded by filter.EncodeList, positional parameters by their index.
+
+// SyntaxError describes an invalid placeholder
+type SyntaxError struct {
+	// Value is the value containing the placeholder
+	Value string
+	// Offset is the byte offset of the offending character within the value
+	Offset int
+	// Msg describes the error
+	Msg string
+}
+
+// Error returns the description of the error followed by the line of the value containing it and a caret pointing at the offending column
+func (e *SyntaxError) Error() string {
+	lineStart := strings.LastIndexByte(e.Value[:e.Offset], '\n') + 1
+	lineEnd := strings.IndexByte(e.Value[e.Offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(e.Value)
+	} else {
+		lineEnd += e.Offset
+	}
+	line := e.Value[lineStart:lineEnd]
+	column := utf8.RuneCountInString(e.Value[lineStart:e.Offset]) + 1
+
+	// Tabs are kept, so the caret lines up with the offending character
+	indent := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, e.Value[lineStart:e.Offset])
+
+	position := fmt.Sprintf("column %d", column)
+	if lineStart > 0 || lineEnd < len(e.Value) {
+		position = fmt.Sprintf("line %d, column %d", strings.Count(e.Value[:lineStart], "\n")+1, column)
+	}
+
+	return fmt.Sprintf("syntax error at %s: %s\n  %s\n  %s^", position, e.Msg, line, indent)
+}
+
+// placeholderParser parses a single placeholder of a value
+type placeholderParser struct {
+	value string
+	pos   int
+}
+
+// parse returns the placeholders of the value.
+// Text starting with ${ which isn't followed by a variable name and | or }, e.g. ${VAR:-x} of shells or ${project.version} of Maven,
+// and placeholders which aren't closed at all are kept as text
+func parse(value string) ([]ApplyableTokenParam, error) {
+	result := make([]ApplyableTokenParam, 0)
+
+	for offset := 0; ; {
+		start := strings.Index(value[offset:], "${")
+		if start < 0 {
+			break
+		}
+		start += offset
+
+		if !strings.Contains(value[start:], "}") {
+			break
+		}
+
+		p := &placeholderParser{value: value, pos: start + 2}
+		if !p.isPlaceholder() {
+			offset = start + 2
+			continue
+		}
+
+		param, err := p.placeholder(start)
+		if err != nil {
+			return nil, err
+		}
+
+		logging.Debug("Found param", "param", param.token, "start", param.start, "end", param.end)
+
+		result = append(result, param)
+		offset = param.end
+	}
+
+	return result, nil
+}
+
+// errorf returns a syntax error at the given offset
+func (p *placeholderParser) errorf(offset int, format string, args ...any) error {
+	return &SyntaxError{Value: p.value, Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+// peek returns the current character or 0 at the end of the value
+func (p *placeholderParser) peek() byte {
+	if p.pos >= len(p.value) {
+		return 0
+	}
+
+	return p.value[p.pos]
+}
+
+// skipSpaces advances to the next character which isn't whitespace
+func (p *placeholderParser) skipSpaces() {
+	for p.pos < len(p.value) && strings.IndexByte(" \t\r\n", p.value[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// describe returns the current character for error messages
+func (p *placeholderParser) describe() string {
+	if p.pos >= len(p.value) {
+		return "end of value"
+	}
+
+	r, _ := utf8.DecodeRuneInString(p.value[p.pos:])
+	return strconv.QuoteRune(r)
+}
+
+// name returns the name starting at the current position, which consists of the characters accepted by valid
+func (p *placeholderParser) name(valid func(c byte) bool) string {
+	start := p.pos
+	for p.pos < len(p.value) && valid(p.value[p.pos]) {
+		p.pos++
+	}
+
+	return p.value[start:p.pos]
+}
+
+// isNameChar reports whether the character may be part of variable names and parameter keys
+func isNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isFilterNameChar reports whether the character may be part of filter names like gzip+base64
+func isFilterNameChar(c byte) bool {
+	return isNameChar(c) || c == '+'
+}
+
+// isKeyChar reports whether the character may be part of parameter keys, e.g. of the lookup table of map
+func isKeyChar(c byte) bool {
+	return isNameChar(c) || c == '-' || c == '.'
+}
+
+// isPlaceholder reports whether the text behind "${" is a variable name followed by | or }, without advancing
+func (p *placeholderParser) isPlaceholder() bool {
+	start := p.pos
+	defer func() { p.pos = start }()
+
+	p.skipSpaces()
+	if p.name(isNameChar) == "" {
+		return false
+	}
+	p.skipSpaces()
+
+	return p.peek() == '|' || p.peek() == '}'
+}
+
+// placeholder parses the placeholder starting at start, the current position is behind "${"
+func (p *placeholderParser) placeholder(start int) (TokenFilterParam, error) {
+	p.skipSpaces()
+	variable := p.name(isNameChar)
+
+	param := TokenFilterParam{
+		variable: variable,
+		start:    start,
+		filters:  []filter.Filter{filter.NewEnvVarFilter(variable), filter.NewFileInterceptorFilter()},
+	}
+
+	for {
+		p.skipSpaces()
+		switch p.peek() {
+		case '}':
+			p.pos++
+			param.end = p.pos
+			param.token = p.value[start:param.end]
+			return param, nil
+		case '|':
+			p.pos++
+			f, err := p.filter()
+			if err != nil {
+				return TokenFilterParam{}, err
+			}
+			param.filters = append(param.filters, f)
+		default:
+			return TokenFilterParam{}, p.errorf(p.pos, "expected | or }, got %s", p.describe())
+		}
+	}
+}
+
+// filter parses a filter and its parameters
+func (p *placeholderParser) filter() (filter.Filter, error) {
+	p.skipSpaces()
+	name := p.name(isFilterNameChar)
+	if name == "" {
+		return nil, p.errorf(p.pos, "expected a filter name, got %s", p.describe())
+	}
+
+	f := filter.NewFilter(name)
+
+	p.skipSpaces()
+	if p.peek() != '(' {
+		return f, nil
+	}
+	p.pos++
+
+	params, err := p.params()
+	if err != nil {
+		return nil, err
+	}
+	if withParams, ok := f.(filter.FilterParams); ok {
+		withParams.AcceptParams(params)
+	}
+
+	return f, nil
+}
+
+// params parses the parameters of a filter, the current position is behind "("
+func (p *placeholderParser) params() (map[string]string, error) {
+	params := map[string]string{}
+
+	p.skipSpaces()
+	if p.peek() == ')' {
+		p.pos++
+		return params, nil
+	}
+
+	positional := 0
+	named := false
+	for {
+		p.skipSpaces()
+		keyStart := p.pos
+		key := p.key()
+		valueStart := p.pos
+
+		// Named parameters may be empty, e.g. replace(old=a,new=), positional ones would be ambiguous
+		if key == "" {
+			p.skipSpaces()
+			if next := p.peek(); next == ',' || next == ')' {
+				return nil, p.errorf(p.pos, "expected a parameter, got %s", p.describe())
+			}
+		}
+
+		value, err := p.paramValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if key == "" {
+			if named {
+				return nil, p.errorf(valueStart, "positional parameters must precede named parameters")
+			}
+			key = strconv.Itoa(positional)
+			positional++
+		} else {
+			if _, found := params[key]; found {
+				return nil, p.errorf(keyStart, "parameter %s is given twice", key)
+			}
+			named = true
+		}
+		params[key] = value
+
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return params, nil
+		default:
+			return nil, p.errorf(p.pos, "expected , or ), got %s", p.describe())
+		}
+	}
+}
+
+// key returns the key of a named parameter and advances behind "=", or returns an empty key for positional parameters
+func (p *placeholderParser) key() string {
+	start := p.pos
+	key := p.name(isKeyChar)
+	p.skipSpaces()
+	if key == "" || p.peek() != '=' {
+		p.pos = start
+		return ""
+	}
+
+	p.pos++
+	return key
+}
+
+// paramValue parses a string, a list or a bare value
+func (p *placeholderParser) paramValue() (string, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek() == '"' || p.peek() == '\'':
+		return p.quoted()
+	case p.isList():
+		return p.list()
+	default:
+		return p.bare(), nil
+	}
+}
+
+// isList reports whether a list starts at the current position. Lists consist of strings,
+// so brackets followed by anything else, e.g. character classes like [0-9] of regular expressions, start bare values
+func (p *placeholderParser) isList() bool {
+	if p.peek() != '[' {
+		return false
+	}
+
+	next := strings.TrimLeft(p.value[p.pos+1:], " \t\r\n")
+	return next != "" && strings.IndexByte(`"']`, next[0]) >= 0
+}
+
+// quoted parses a string enclosed in quotes
+func (p *placeholderParser) quoted() (string, error) {
+	start := p.pos
+	quote := p.value[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.value) {
+		c := p.value[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			if p.pos+1 >= len(p.value) {
+				return "", p.errorf(start, "unterminated string")
+			}
+			switch escaped := p.value[p.pos+1]; escaped {
+			case '\\', '\'', '"':
+				sb.WriteByte(escaped)
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				return "", p.errorf(p.pos, "unknown escape sequence \\%c", escaped)
+			}
+			p.pos += 2
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", p.errorf(start, "unterminated string")
+}
+
+// list parses a list of strings
+func (p *placeholderParser) list() (string, error) {
+	start := p.pos
+	p.pos++
+
+	items := make([]string, 0)
+	p.skipSpaces()
+	if p.peek() == ']' {
+		p.pos++
+		return filter.EncodeList(items), nil
+	}
+
+	for {
+		p.skipSpaces()
+		switch p.peek() {
+		case '"', '\'':
+			item, err := p.quoted()
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		case 0:
+			return "", p.errorf(start, "unterminated list")
+		default:
+			return "", p.errorf(p.pos, "expected a quoted list item, got %s", p.describe())
+		}
+
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return filter.EncodeList(items), nil
+		case 0:
+			return "", p.errorf(start, "unterminated list")
+		default:
+			return "", p.errorf(p.pos, "expected , or ], got %s", p.describe())
+		}
+	}
+}
+
+// bare parses a value up to the next "," or ")" or "}" which isn't enclosed in parentheses, brackets or braces and trims it,
+// so values like [a-z]{2}(b|c) don't need quotes
+func (p *placeholderParser) bare() string {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.value) {
+		c := p.value[p.pos]
+		if depth == 0 && strings.IndexByte(",)}", c) >= 0 {
+			break
+		}
+		switch c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		p.pos++
+	}
+
+	return strings.TrimSpace(p.value[start:p.pos])
+}
@@ -0,0 +1,139 @@
+package value
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseParams(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		input    string
+		expected any
+	}{
+		{desc: "Double quoted", input: `${URL | replace(old="a=b,c", new="x)}")}`, expected: "http://host/?x)}"},
+		{desc: "Single quoted with escapes", input: `${URL | suffix(text='\'q\'\t\\')}`, expected: "http://host/?a=b,c'q'\t\\"},
+		{desc: "Positional", input: `${URL | replace("http://", "https://")}`, expected: "https://host/?a=b,c"},
+		{desc: "Positional and named", input: `${URL | replace("?a=b,c", new="")}`, expected: "http://host/"},
+		{desc: "Number", input: `${PI | round(2)}`, expected: 3.14},
+		{desc: "List", input: `${UNSET_URL | coalesce(["UNSET_HOST", 'HOST'])}`, expected: "db.internal"},
+		{desc: "List items containing the separator", input: `${SEPARATED | oneof(values=["a;b", "c"])}`, expected: "a;b"},
+		{desc: "Empty list", input: `${UNSET_URL | coalesce([]) | default(none)}`, expected: "none"},
+		{desc: "Character class", input: `${CHAR | regex(pattern=[abc])}`, expected: "b"},
+		{desc: "Character class with quantifier", input: `${PORT | regex(pattern=[0-9]+)}`, expected: "8080"},
+		{desc: "Character class containing a comma", input: `${CHAR | regex(pattern=^[a,b]$)}`, expected: "b"},
+		{desc: "Bare value with braces and parentheses", input: `${LEVEL | regex(pattern=^(debug|info)[a-z]{0,2}$)}`, expected: "info"},
+		{desc: "Bare value with padding", input: `${LEVEL | eq(v=aW5mbw==, then=yes, else=no)}`, expected: "no"},
+		{desc: "Empty parameter", input: `${URL | replace(old=http://,new=)}`, expected: "host/?a=b,c"},
+		{desc: "Unclosed placeholder", input: `${URL`, expected: "${URL"},
+		{desc: "Shell default", input: `a ${X:-def} ${HOST} b`, expected: "a ${X:-def} db.internal b"},
+		{desc: "Maven property", input: `${project.version}`, expected: "${project.version}"},
+		{desc: "Log4j lookup", input: `${sys:user.home}/${HOST}`, expected: "${sys:user.home}/db.internal"},
+		{desc: "Missing variable", input: `${ | upper}`, expected: "${ | upper}"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("URL", "http://host/?a=b,c")
+			t.Setenv("PI", "3.14159")
+			t.Setenv("HOST", "db.internal")
+			t.Setenv("LEVEL", "info")
+			t.Setenv("SEPARATED", "a;b")
+			t.Setenv("CHAR", "b")
+			t.Setenv("PORT", "8080")
+
+			result, err := ProcessValue(tC.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tC.expected, result)
+		})
+	}
+}
+
+func TestParseListParams(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		input    string
+		value    string
+		expected string
+	}{
+		{desc: "Item containing the separator is not split", input: `${X | oneof(values=["a;b", "c"])}`, value: "b", expected: "expected one of a;b, c"},
+		{desc: "Character class is not a list", input: `${X | regex(pattern=[abc])}`, value: "xyz", expected: `expected a value matching "[abc]"`},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Setenv("X", tC.value)
+
+			_, err := ProcessValue(tC.input)
+			assert.ErrorContains(t, err, tC.expected)
+		})
+	}
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		input    string
+		expected string
+	}{
+		{
+			desc:     "Unterminated string",
+			input:    `${X | replace(old="a, new=b)}`,
+			expected: "syntax error at column 19: unterminated string\n  ${X | replace(old=\"a, new=b)}\n                    ^",
+		},
+		{
+			desc:     "Unclosed parameters",
+			input:    `${X | upper(}`,
+			expected: "syntax error at column 13: expected , or ), got '}'\n  ${X | upper(}\n              ^",
+		},
+		{
+			desc:     "Missing filter",
+			input:    `${X | }`,
+			expected: "syntax error at column 7: expected a filter name, got '}'\n  ${X | }\n        ^",
+		},
+		{
+			desc:     "Unknown escape",
+			input:    `${X | prefix(text="\d")}`,
+			expected: "syntax error at column 20: unknown escape sequence \\d\n  ${X | prefix(text=\"\\d\")}\n                     ^",
+		},
+		{
+			desc:     "Positional after named",
+			input:    `${X | replace(old=a, b)}`,
+			expected: "syntax error at column 22: positional parameters must precede named parameters\n  ${X | replace(old=a, b)}\n                       ^",
+		},
+		{
+			desc:     "Trailing empty positional",
+			input:    `${X | replace("a",)}`,
+			expected: "syntax error at column 19: expected a parameter, got ')'\n  ${X | replace(\"a\",)}\n                    ^",
+		},
+		{
+			desc:     "Leading empty positional",
+			input:    `${X | replace( , "b")}`,
+			expected: "syntax error at column 16: expected a parameter, got ','\n  ${X | replace( , \"b\")}\n                 ^",
+		},
+		{
+			desc:     "Given twice",
+			input:    `${X | replace(old=a, old=b)}`,
+			expected: "syntax error at column 22: parameter old is given twice\n  ${X | replace(old=a, old=b)}\n                       ^",
+		},
+		{
+			desc:     "Unquoted list item",
+			input:    `${X | oneof(["a", b])}`,
+			expected: "syntax error at column 19: expected a quoted list item, got 'b'\n  ${X | oneof([\"a\", b])}\n                    ^",
+		},
+		{
+			desc:     "Multiple lines",
+			input:    "first\n\tkey: ${X | upper(}\nlast",
+			expected: "syntax error at line 2, column 19: expected , or ), got '}'\n  \tkey: ${X | upper(}\n  \t                 ^",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			_, err := ProcessValue(tC.input)
+			assert.EqualError(t, err, tC.expected)
+
+			var syntaxErr *SyntaxError
+			assert.True(t, errors.As(err, &syntaxErr))
+		})
+	}
+}
@@ -2,47 +2,24 @@ package value
 
 import (
 	"fmt"
-	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
-	"github.com/bzick/tokenizer"
 	"github.com/denglertai/gonfig/internal/filter"
 	"github.com/denglertai/gonfig/pkg/logging"
 )
 
-var parser *tokenizer.Tokenizer
-var filterParser *tokenizer.Tokenizer
-
-const (
-	TokenFilterSeparator = iota
-	TokenParam
-)
-
-func init() {
-	parser = tokenizer.New()
-	parser.DefineStringToken(TokenParam, "${", "}")
-
-	filterParser = tokenizer.New()
-	filterParser.DefineTokens(TokenFilterSeparator, []string{"|", " |", " | "})
-	filterParser.DefineStringToken(TokenParam, "(", ")")
-	filterParser.AllowKeywordSymbols(tokenizer.Underscore, tokenizer.Numbers)
+// ProcessValue takes the input value and processes it as needed.
+// The results of placeholders are escaped for the given contexts in order, unless they use an escape filter themselves
+func ProcessValue(value string, escape ...string) (any, error) {
+	return ProcessEntryValue(value, filter.Entry{}, escape...)
 }
 
-// Tokenize returns a stream of tokens
-func tokenize(value string) (*tokenizer.Stream, error) {
-	return parser.ParseString(value), nil
-}
-
-// ProcessValue takes the input value and processes it as needed
-func ProcessValue(value string) (any, error) {
-	stream, err := tokenize(value)
-	if err != nil {
-		return nil, err
-	}
-
-	defer stream.Close()
-
-	params, err := processStream(stream)
-
+// ProcessEntryValue processes the value of the given entry like ProcessValue.
+// The entry is passed to the filters, the previous value of the entry is only passed on if the value contains a single placeholder
+func ProcessEntryValue(value string, entry filter.Entry, escape ...string) (any, error) {
+	params, err := parse(value)
 	if err != nil {
 		return value, err
 	}
@@ -56,7 +33,24 @@ func ProcessValue(value string) (any, error) {
 		return value, nil
 	}
 
+	entry.Previous = previousResult(value, params, entry.Previous)
+
 	for _, param := range params {
+		if tokenParam, ok := param.(TokenFilterParam); ok {
+			tokenParam.escape = escape
+			if entry.Newline != "" {
+				tokenParam.newline = lineEnding(value, tokenParam.end, entry.Newline)
+			}
+			paramEntry := entry
+			paramEntry.Variable = tokenParam.variable
+			for _, f := range tokenParam.filters {
+				if entryFilter, ok := f.(filter.EntryFilter); ok {
+					entryFilter.AcceptEntry(paramEntry)
+				}
+			}
+			param = tokenParam
+		}
+
 		paramResult, lenDiff, err := param.Apply(result, sumDiff)
 		if err != nil {
 			return nil, err
@@ -75,14 +69,68 @@ func ProcessValue(value string) (any, error) {
 	return finalResult, nil
 }
 
+// previousResult returns the part of the previous value produced by the placeholder, if the value contains a single placeholder
+// and the previous value still contains the text around it
+func previousResult(value string, params []ApplyableTokenParam, previous string) string {
+	if previous == "" || len(params) != 1 {
+		return ""
+	}
+
+	tokenParam, ok := params[0].(TokenFilterParam)
+	if !ok {
+		return ""
+	}
+
+	before, after := value[:tokenParam.start], value[tokenParam.end:]
+	if len(previous) < len(before)+len(after) || !strings.HasPrefix(previous, before) || !strings.HasSuffix(previous, after) {
+		return ""
+	}
+
+	return previous[len(before) : len(previous)-len(after)]
+}
+
+// lineEnding returns the line ending of the line of the value containing the offset, or the fallback if the line has none
+func lineEnding(value string, offset int, fallback string) string {
+	i := strings.IndexByte(value[offset:], '\n')
+	if i < 0 {
+		return fallback
+	}
+	if i > 0 && value[offset+i-1] == '\r' {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// Variables returns the names of the variables referenced by the placeholders of the value
+func Variables(value string) ([]string, error) {
+	params, err := parse(value)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make([]string, 0, len(params))
+	for _, param := range params {
+		if tokenParam, ok := param.(TokenFilterParam); ok && tokenParam.variable != "" {
+			variables = append(variables, tokenParam.variable)
+		}
+	}
+
+	return variables, nil
+}
+
 type ApplyableTokenParam interface {
 	// Apply applies the token to the input string
 	Apply(input string, offset int) (any, int, error)
 }
 
 type TokenFilterParam struct {
-	token   string
-	filters []filter.Filter
+	token    string
+	variable string
+	filters  []filter.Filter
+	escape   []string
+	// newline is the line ending line breaks within the result are converted to, if set
+	newline string
 	start   int
 	end     int
 }
@@ -95,92 +143,31 @@ func (t TokenFilterParam) Apply(input string, offset int) (any, int, error) {
 		return "", 0, err
 	}
 
-	lenBefore := len(input)
-
-	asRunes := []rune(input)
-	before := string(asRunes[0 : t.start+offset])
-	after := string(asRunes[t.end+offset:])
-
-	switch result.(type) {
-	case string:
-		result := fmt.Sprintf("%s%s%s", before, result, after)
-
-		return result, len(result) - lenBefore, nil
-	default:
-		return result, 0, nil
+	if str, ok := result.(string); ok && len(t.escape) > 0 && !slices.ContainsFunc(t.filters, filter.IsEscapeFilter) {
+		result, err = filter.Escape(str, t.escape)
+		if err != nil {
+			return "", 0, err
+		}
 	}
-}
-
-var kvPairRe = regexp.MustCompile(`(.*?)=([^=]*)(?:,|$)`)
 
-// parseKV parses a key value string into a map
-func parseKV(kvStr string) map[string]string {
-	// remove leading and trailing brackets
-	kvStr = kvStr[1 : len(kvStr)-1]
-
-	res := map[string]string{}
-	for _, kv := range kvPairRe.FindAllStringSubmatch(kvStr, -1) {
-		res[kv[1]] = kv[2]
+	// Binary results, e.g. decoded keystores, are kept as is
+	if str, ok := result.(string); ok && t.newline != "" && utf8.ValidString(str) {
+		result = strings.ReplaceAll(strings.ReplaceAll(str, "\r\n", "\n"), "\n", t.newline)
 	}
-	return res
-}
-
-func processStream(stream *tokenizer.Stream) ([]ApplyableTokenParam, error) {
-	logging.Trace("Processing stream", "stream", stream)
-
-	result := make([]ApplyableTokenParam, 0)
-
-	for stream.IsValid() {
-		currentToken := stream.CurrentToken()
-
-		if currentToken.Is(tokenizer.TokenString) {
-			if currentToken.StringSettings().Key == TokenParam {
-				param := TokenFilterParam{
-					token:   currentToken.ValueString(),
-					start:   currentToken.Offset(),
-					end:     currentToken.Offset() + len(currentToken.ValueString()),
-					filters: make([]filter.Filter, 0),
-				}
-
-				filterStream := filterParser.ParseString(param.token[2 : len(param.token)-1])
-
-				defer filterStream.Close()
-				for filterStream.IsValid() {
-					filterToken := filterStream.CurrentToken()
-
-					// Skip filter separator
-					if filterToken.Is(TokenFilterSeparator) {
-						filterStream.GoNext()
-						continue
-					}
-
-					// The first token is always the name of the env var
-					// Thus append the env var filter and the file interceptor filter
-					if len(param.filters) == 0 {
-						param.filters = append(param.filters, filter.NewEnvVarFilter(filterToken.ValueString()), filter.NewFileInterceptorFilter())
-					} else if filterToken.Is(tokenizer.TokenKeyword) {
-						// Other tokens have to be filters
-						param.filters = append(param.filters, filter.NewFilter(filterToken.ValueString()))
-					} else if filterToken.Is(tokenizer.TokenString) && filterToken.StringSettings().Key == TokenParam {
-						// Parse the Params for the filter and apply it to the last generated filter
-						lastFilter, acceptsParams := param.filters[len(param.filters)-1].(filter.FilterParams)
-						if acceptsParams {
-							params := parseKV(filterToken.ValueString())
-							lastFilter.AcceptParams(params)
-						}
-					}
-
-					filterStream.GoNext()
-				}
 
-				logging.Debug("Found param", "param", param.token, "start", param.start, "end", param.end)
+	lenBefore := len(input)
 
-				result = append(result, param)
-			}
-		}
+	// The offsets of the tokens are byte offsets, slicing runes would break values containing multi-byte characters
+	// and converting the input to runes would replace invalid UTF-8, e.g. within decoded binary values
+	before := input[:t.start+offset]
+	after := input[t.end+offset:]
 
-		stream.GoNext()
+	// Typed results, e.g. numbers, are only kept if the placeholder is the whole value
+	if _, isString := result.(string); !isString && before == "" && after == "" {
+		return result, 0, nil
 	}
 
-	return result, nil
+	combined := fmt.Sprintf("%s%v%s", before, result, after)
+
+	return combined, len(combined) - lenBefore, nil
 }
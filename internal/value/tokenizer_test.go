@@ -3,8 +3,10 @@ package value
 import (
 	"os"
 	"path"
+	"strings"
 	"testing"
 
+	"github.com/denglertai/gonfig/internal/filter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -133,12 +135,28 @@ func TestComplexValues(t *testing.T) {
 			input: "${TEST1}${TEST2}${TEST3}",
 			want:  "http://url.tldpathsomething",
 		},
+		{
+			desc:  "Derive hostname",
+			input: "${TEST1 | replace(old=http://,new=) | split(sep=.,index=0) | suffix(text=.internal)}",
+			want:  "url.internal",
+		},
+		{
+			desc:  "Transform list",
+			input: "${TEST4 | split(sep=;) | trim | prefix(text=jdbc:postgresql://) | join(sep=;)}",
+			want:  "jdbc:postgresql://db1;jdbc:postgresql://db2",
+		},
+		{
+			desc:    "Invalid parameter",
+			input:   "${TEST2 | pad(len=x)}",
+			wantErr: true,
+		},
 	}
 	for _, tC := range testCases {
 		t.Run(tC.desc, func(t *testing.T) {
 			t.Setenv("TEST1", "http://url.tld")
 			t.Setenv("TEST2", "path")
 			t.Setenv("TEST3", "something")
+			t.Setenv("TEST4", "db1; db2")
 
 			result, err := ProcessValue(tC.input)
 			if tC.wantErr {
@@ -150,3 +168,98 @@ func TestComplexValues(t *testing.T) {
 		})
 	}
 }
+
+func TestVariables(t *testing.T) {
+	variables, err := Variables("${HOST|lower}:${PORT}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"HOST", "PORT"}, variables)
+
+	variables, err = Variables("plain")
+	assert.NoError(t, err)
+	assert.Empty(t, variables)
+}
+
+func TestProcessValueEscaped(t *testing.T) {
+	t.Setenv("NAME", `a "b" & c`)
+
+	result, err := ProcessValue(`{"name": "${NAME}"}`, "json_escape")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "a \"b\" & c"}`, result)
+
+	result, err = ProcessValue("<${NAME}|${NAME|raw}|${NAME|xml_escape}>", "xml_escape")
+	assert.NoError(t, err)
+	assert.Equal(t, `<a &quot;b&quot; &amp; c|a "b" & c|a &quot;b&quot; &amp; c>`, result)
+}
+
+func TestProcessValueBinarySafe(t *testing.T) {
+	t.Setenv("KEYSTORE_B64", "/+7dzAA=")
+	t.Setenv("NAME", "gönfig")
+
+	result, err := ProcessValue("ä${NAME}ö${KEYSTORE_B64|base64_decode}ü${NAME | upper}")
+	assert.NoError(t, err)
+	assert.Equal(t, "ägönfigö\xff\xee\xdd\xcc\x00üGÖNFIG", result)
+
+	result, err = ProcessValue("${NAME | gzip+base64 | base64_decode | hex | substr(len=4)}")
+	assert.NoError(t, err)
+	assert.Equal(t, "1f8b", result)
+}
+
+func TestProcessEntryValuePrevious(t *testing.T) {
+	t.Setenv("ADMIN_PASSWORD", "myPassword")
+	hash := "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/"
+
+	testCases := []struct {
+		desc     string
+		value    string
+		previous string
+		expected string
+		keep     bool
+	}{
+		{desc: "Single placeholder", value: "${ADMIN_PASSWORD | htpasswd(alg=apr1)}", previous: hash, expected: hash, keep: true},
+		{desc: "Text around the placeholder", value: "admin:${ADMIN_PASSWORD | htpasswd(alg=apr1)}", previous: "admin:" + hash, expected: "admin:" + hash, keep: true},
+		{desc: "Changed text", value: "root:${ADMIN_PASSWORD | htpasswd(alg=apr1)}", previous: "admin:" + hash, expected: "root:$apr1$"},
+		{desc: "Several placeholders", value: "${ADMIN_PASSWORD | htpasswd(alg=apr1)}${ADMIN_PASSWORD | htpasswd(alg=apr1)}", previous: hash + hash, expected: "$apr1$"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			result, err := ProcessEntryValue(tC.value, filter.Entry{Path: "admin", Previous: tC.previous})
+			assert.NoError(t, err)
+			if tC.keep {
+				assert.Equal(t, tC.expected, result)
+			} else {
+				assert.NotEqual(t, tC.previous, result)
+				assert.True(t, strings.HasPrefix(result.(string), tC.expected))
+			}
+		})
+	}
+}
+
+func TestProcessValueTypedResults(t *testing.T) {
+	t.Setenv("MEMORY_LIMIT", "2Gi")
+
+	result, err := ProcessValue("${MEMORY_LIMIT | bytes(to=Mi) | multiply(m=0.75) | round}")
+	assert.NoError(t, err)
+	assert.Equal(t, 1536, result)
+
+	// Results embedded into text become part of the text
+	result, err = ProcessValue("-Xms256m -Xmx${MEMORY_LIMIT | bytes(to=Mi) | multiply(m=0.75) | round}m")
+	assert.NoError(t, err)
+	assert.Equal(t, "-Xms256m -Xmx1536m", result)
+}
+
+func TestProcessValueFallbacks(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("DB_HOST", "db.internal")
+
+	// The variable isn't set, so the URL is derived from another variable
+	result, err := ProcessValue("${GONFIG_TEST_UNSET_URL | coalesce(vars=DB_HOST) | prefix(text=postgres://)}")
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://db.internal", result)
+
+	result, err = ProcessValue("level=${APP_ENV | map(dev=debug,prod=warn)} port=${GONFIG_TEST_UNSET_PORT | default(v=8080)}")
+	assert.NoError(t, err)
+	assert.Equal(t, "level=warn port=8080", result)
+
+	_, err = ProcessValue("${GONFIG_TEST_UNSET_URL | required}")
+	assert.EqualError(t, err, "filter required: variable GONFIG_TEST_UNSET_URL is required but not set or empty")
+}